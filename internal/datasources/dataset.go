@@ -8,7 +8,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var _ datasource.DataSource = &DatasetDataSource{}
@@ -18,7 +18,7 @@ func NewDatasetDataSource() datasource.DataSource {
 }
 
 type DatasetDataSource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type DatasetDataSourceModel struct {
@@ -105,15 +105,17 @@ func (d *DatasetDataSource) Configure(ctx context.Context, req datasource.Config
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	d.client = client
 }
 
 func (d *DatasetDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = truenas.WithResourceAddress(ctx, "data.trueform_dataset")
+
 	var config DatasetDataSourceModel
 	diags := req.Config.Get(ctx, &config)
 	resp.Diagnostics.Append(diags...)
@@ -121,19 +123,19 @@ func (d *DatasetDataSource) Read(ctx context.Context, req datasource.ReadRequest
 		return
 	}
 
-	var result map[string]interface{}
-	err := d.client.GetInstance(ctx, "pool.dataset", config.ID.ValueString(), &result)
+	var dataset truenas.Dataset
+	err := d.client.GetInstance(ctx, "pool.dataset", config.ID.ValueString(), &dataset)
 	if err != nil {
 		resp.Diagnostics.AddError("Error Reading Dataset", "Could not read dataset: "+err.Error())
 		return
 	}
 
-	config.ID = types.StringValue(result["id"].(string))
-	config.Name = types.StringValue(result["name"].(string))
-	config.Type = types.StringValue(result["type"].(string))
+	config.ID = types.StringValue(dataset.ID)
+	config.Name = types.StringValue(dataset.Name)
+	config.Type = types.StringValue(dataset.Type)
 
 	// Extract pool from name
-	name := result["name"].(string)
+	name := dataset.Name
 	for i, c := range name {
 		if c == '/' {
 			config.Pool = types.StringValue(name[:i])
@@ -144,44 +146,32 @@ func (d *DatasetDataSource) Read(ctx context.Context, req datasource.ReadRequest
 		config.Pool = types.StringValue(name)
 	}
 
-	if compression, ok := result["compression"].(map[string]interface{}); ok {
-		if value, ok := compression["value"].(string); ok {
-			config.Compression = types.StringValue(value)
-		}
+	if value, ok := dataset.Compression.StringValue(); ok {
+		config.Compression = types.StringValue(value)
 	}
-	if atime, ok := result["atime"].(map[string]interface{}); ok {
-		if value, ok := atime["value"].(string); ok {
-			config.Atime = types.StringValue(value)
-		}
+	if value, ok := dataset.Atime.StringValue(); ok {
+		config.Atime = types.StringValue(value)
 	}
-	if dedup, ok := result["deduplication"].(map[string]interface{}); ok {
-		if value, ok := dedup["value"].(string); ok {
-			config.Deduplication = types.StringValue(value)
-		}
+	if value, ok := dataset.Deduplication.StringValue(); ok {
+		config.Deduplication = types.StringValue(value)
 	}
-	if quota, ok := result["quota"].(map[string]interface{}); ok {
-		if parsed, ok := quota["parsed"].(float64); ok {
-			config.Quota = types.Int64Value(int64(parsed))
-		}
+	if parsed, ok := dataset.Quota.Int64Value(); ok {
+		config.Quota = types.Int64Value(parsed)
 	}
-	if used, ok := result["used"].(map[string]interface{}); ok {
-		if parsed, ok := used["parsed"].(float64); ok {
-			config.Used = types.Int64Value(int64(parsed))
-		}
+	if parsed, ok := dataset.Used.Int64Value(); ok {
+		config.Used = types.Int64Value(parsed)
 	}
-	if available, ok := result["available"].(map[string]interface{}); ok {
-		if parsed, ok := available["parsed"].(float64); ok {
-			config.Available = types.Int64Value(int64(parsed))
-		}
+	if parsed, ok := dataset.Available.Int64Value(); ok {
+		config.Available = types.Int64Value(parsed)
 	}
-	if mountpoint, ok := result["mountpoint"].(string); ok {
-		config.Mountpoint = types.StringValue(mountpoint)
+	if dataset.Mountpoint != nil {
+		config.Mountpoint = types.StringValue(*dataset.Mountpoint)
 	}
-	if encrypted, ok := result["encrypted"].(bool); ok {
-		config.Encrypted = types.BoolValue(encrypted)
+	if dataset.Encrypted != nil {
+		config.Encrypted = types.BoolValue(*dataset.Encrypted)
 	}
-	if keyLoaded, ok := result["key_loaded"].(bool); ok {
-		config.KeyLoaded = types.BoolValue(keyLoaded)
+	if dataset.KeyLoaded != nil {
+		config.KeyLoaded = types.BoolValue(*dataset.KeyLoaded)
 	}
 
 	diags = resp.State.Set(ctx, &config)
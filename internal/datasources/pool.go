@@ -8,7 +8,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var _ datasource.DataSource = &PoolDataSource{}
@@ -18,7 +18,7 @@ func NewPoolDataSource() datasource.DataSource {
 }
 
 type PoolDataSource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type PoolDataSourceModel struct {
@@ -87,15 +87,17 @@ func (d *PoolDataSource) Configure(ctx context.Context, req datasource.Configure
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	d.client = client
 }
 
 func (d *PoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = truenas.WithResourceAddress(ctx, "data.trueform_pool")
+
 	var config PoolDataSourceModel
 	diags := req.Config.Get(ctx, &config)
 	resp.Diagnostics.Append(diags...)
@@ -103,18 +105,18 @@ func (d *PoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 		return
 	}
 
-	var result map[string]interface{}
+	var pool truenas.Pool
 	var err error
 
 	if !config.ID.IsNull() {
-		err = d.client.GetInstance(ctx, "pool", config.ID.ValueInt64(), &result)
+		err = d.client.GetInstance(ctx, "pool", config.ID.ValueInt64(), &pool)
 	} else if !config.Name.IsNull() {
 		// Query by name
-		params := client.NewQueryParams().WithFilter("name", "=", config.Name.ValueString())
-		var results []map[string]interface{}
+		params := truenas.NewQueryParams().WithFilter("name", "=", config.Name.ValueString())
+		var results []truenas.Pool
 		err = d.client.Query(ctx, "pool", params, &results)
 		if err == nil && len(results) > 0 {
-			result = results[0]
+			pool = results[0]
 		} else if len(results) == 0 {
 			resp.Diagnostics.AddError("Pool Not Found", fmt.Sprintf("Pool with name %s not found", config.Name.ValueString()))
 			return
@@ -129,23 +131,23 @@ func (d *PoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 		return
 	}
 
-	config.ID = types.Int64Value(int64(result["id"].(float64)))
-	config.Name = types.StringValue(result["name"].(string))
-	config.Status = types.StringValue(result["status"].(string))
-	config.Healthy = types.BoolValue(result["healthy"].(bool))
-	config.Path = types.StringValue(result["path"].(string))
+	config.ID = types.Int64Value(pool.ID)
+	config.Name = types.StringValue(pool.Name)
+	config.Status = types.StringValue(pool.Status)
+	config.Healthy = types.BoolValue(pool.Healthy)
+	config.Path = types.StringValue(pool.Path)
 
-	if size, ok := result["size"].(float64); ok {
-		config.Size = types.Int64Value(int64(size))
+	if pool.Size != nil {
+		config.Size = types.Int64Value(int64(*pool.Size))
 	}
-	if free, ok := result["free"].(float64); ok {
-		config.Free = types.Int64Value(int64(free))
+	if pool.Free != nil {
+		config.Free = types.Int64Value(int64(*pool.Free))
 	}
-	if allocated, ok := result["allocated"].(float64); ok {
-		config.Allocated = types.Int64Value(int64(allocated))
+	if pool.Allocated != nil {
+		config.Allocated = types.Int64Value(int64(*pool.Allocated))
 	}
-	if fragmentation, ok := result["fragmentation"].(float64); ok {
-		config.Fragmentation = types.Int64Value(int64(fragmentation))
+	if pool.Fragmentation != nil {
+		config.Fragmentation = types.Int64Value(int64(*pool.Fragmentation))
 	}
 
 	diags = resp.State.Set(ctx, &config)
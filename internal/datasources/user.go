@@ -8,7 +8,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var _ datasource.DataSource = &UserDataSource{}
@@ -18,7 +18,7 @@ func NewUserDataSource() datasource.DataSource {
 }
 
 type UserDataSource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type UserDataSourceModel struct {
@@ -103,15 +103,17 @@ func (d *UserDataSource) Configure(ctx context.Context, req datasource.Configure
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	d.client = client
 }
 
 func (d *UserDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = truenas.WithResourceAddress(ctx, "data.trueform_user")
+
 	var config UserDataSourceModel
 	diags := req.Config.Get(ctx, &config)
 	resp.Diagnostics.Append(diags...)
@@ -119,27 +121,27 @@ func (d *UserDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 		return
 	}
 
-	var result map[string]interface{}
+	var user truenas.User
 	var err error
 
 	if !config.ID.IsNull() {
-		err = d.client.GetInstance(ctx, "user", config.ID.ValueInt64(), &result)
+		err = d.client.GetInstance(ctx, "user", config.ID.ValueInt64(), &user)
 	} else if !config.Username.IsNull() {
-		params := client.NewQueryParams().WithFilter("username", "=", config.Username.ValueString())
-		var results []map[string]interface{}
+		params := truenas.NewQueryParams().WithFilter("username", "=", config.Username.ValueString())
+		var results []truenas.User
 		err = d.client.Query(ctx, "user", params, &results)
 		if err == nil && len(results) > 0 {
-			result = results[0]
+			user = results[0]
 		} else if len(results) == 0 {
 			resp.Diagnostics.AddError("User Not Found", fmt.Sprintf("User with username %s not found", config.Username.ValueString()))
 			return
 		}
 	} else if !config.UID.IsNull() {
-		params := client.NewQueryParams().WithFilter("uid", "=", config.UID.ValueInt64())
-		var results []map[string]interface{}
+		params := truenas.NewQueryParams().WithFilter("uid", "=", config.UID.ValueInt64())
+		var results []truenas.User
 		err = d.client.Query(ctx, "user", params, &results)
 		if err == nil && len(results) > 0 {
-			result = results[0]
+			user = results[0]
 		} else if len(results) == 0 {
 			resp.Diagnostics.AddError("User Not Found", fmt.Sprintf("User with UID %d not found", config.UID.ValueInt64()))
 			return
@@ -154,38 +156,36 @@ func (d *UserDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 		return
 	}
 
-	config.ID = types.Int64Value(int64(result["id"].(float64)))
-	config.UID = types.Int64Value(int64(result["uid"].(float64)))
-	config.Username = types.StringValue(result["username"].(string))
+	config.ID = types.Int64Value(user.ID)
+	config.UID = types.Int64Value(user.UID)
+	config.Username = types.StringValue(user.Username)
 
-	if fullName, ok := result["full_name"].(string); ok {
-		config.FullName = types.StringValue(fullName)
+	if user.FullName != nil {
+		config.FullName = types.StringValue(*user.FullName)
 	}
-	if email, ok := result["email"].(string); ok {
-		config.Email = types.StringValue(email)
+	if user.Email != nil {
+		config.Email = types.StringValue(*user.Email)
 	}
-	if group, ok := result["group"].(map[string]interface{}); ok {
-		if gid, ok := group["id"].(float64); ok {
-			config.Group = types.Int64Value(int64(gid))
-		}
+	if user.Group != nil {
+		config.Group = types.Int64Value(user.Group.ID)
 	}
-	if home, ok := result["home"].(string); ok {
-		config.Home = types.StringValue(home)
+	if user.Home != nil {
+		config.Home = types.StringValue(*user.Home)
 	}
-	if shell, ok := result["shell"].(string); ok {
-		config.Shell = types.StringValue(shell)
+	if user.Shell != nil {
+		config.Shell = types.StringValue(*user.Shell)
 	}
-	if locked, ok := result["locked"].(bool); ok {
-		config.Locked = types.BoolValue(locked)
+	if user.Locked != nil {
+		config.Locked = types.BoolValue(*user.Locked)
 	}
-	if smb, ok := result["smb"].(bool); ok {
-		config.SMB = types.BoolValue(smb)
+	if user.SMB != nil {
+		config.SMB = types.BoolValue(*user.SMB)
 	}
-	if sudo, ok := result["sudo"].(bool); ok {
-		config.Sudo = types.BoolValue(sudo)
+	if user.Sudo != nil {
+		config.Sudo = types.BoolValue(*user.Sudo)
 	}
-	if builtin, ok := result["builtin"].(bool); ok {
-		config.Builtin = types.BoolValue(builtin)
+	if user.Builtin != nil {
+		config.Builtin = types.BoolValue(*user.Builtin)
 	}
 
 	diags = resp.State.Set(ctx, &config)
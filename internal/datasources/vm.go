@@ -8,7 +8,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var _ datasource.DataSource = &VMDataSource{}
@@ -18,7 +18,7 @@ func NewVMDataSource() datasource.DataSource {
 }
 
 type VMDataSource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type VMDataSourceModel struct {
@@ -97,15 +97,17 @@ func (d *VMDataSource) Configure(ctx context.Context, req datasource.ConfigureRe
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	d.client = client
 }
 
 func (d *VMDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = truenas.WithResourceAddress(ctx, "data.trueform_vm")
+
 	var config VMDataSourceModel
 	diags := req.Config.Get(ctx, &config)
 	resp.Diagnostics.Append(diags...)
@@ -113,17 +115,17 @@ func (d *VMDataSource) Read(ctx context.Context, req datasource.ReadRequest, res
 		return
 	}
 
-	var result map[string]interface{}
+	var vm truenas.VM
 	var err error
 
 	if !config.ID.IsNull() {
-		err = d.client.GetInstance(ctx, "vm", config.ID.ValueInt64(), &result)
+		err = d.client.GetInstance(ctx, "vm", config.ID.ValueInt64(), &vm)
 	} else if !config.Name.IsNull() {
-		params := client.NewQueryParams().WithFilter("name", "=", config.Name.ValueString())
-		var results []map[string]interface{}
+		params := truenas.NewQueryParams().WithFilter("name", "=", config.Name.ValueString())
+		var results []truenas.VM
 		err = d.client.Query(ctx, "vm", params, &results)
 		if err == nil && len(results) > 0 {
-			result = results[0]
+			vm = results[0]
 		} else if len(results) == 0 {
 			resp.Diagnostics.AddError("VM Not Found", fmt.Sprintf("VM with name %s not found", config.Name.ValueString()))
 			return
@@ -138,37 +140,35 @@ func (d *VMDataSource) Read(ctx context.Context, req datasource.ReadRequest, res
 		return
 	}
 
-	config.ID = types.Int64Value(int64(result["id"].(float64)))
-	config.Name = types.StringValue(result["name"].(string))
+	config.ID = types.Int64Value(vm.ID)
+	config.Name = types.StringValue(vm.Name)
 
-	if description, ok := result["description"].(string); ok {
-		config.Description = types.StringValue(description)
+	if vm.Description != nil {
+		config.Description = types.StringValue(*vm.Description)
 	}
-	if vcpus, ok := result["vcpus"].(float64); ok {
-		config.VCPUs = types.Int64Value(int64(vcpus))
+	if vm.VCPUs != nil {
+		config.VCPUs = types.Int64Value(int64(*vm.VCPUs))
 	}
-	if cores, ok := result["cores"].(float64); ok {
-		config.Cores = types.Int64Value(int64(cores))
+	if vm.Cores != nil {
+		config.Cores = types.Int64Value(int64(*vm.Cores))
 	}
-	if threads, ok := result["threads"].(float64); ok {
-		config.Threads = types.Int64Value(int64(threads))
+	if vm.Threads != nil {
+		config.Threads = types.Int64Value(int64(*vm.Threads))
 	}
-	if memory, ok := result["memory"].(float64); ok {
-		config.Memory = types.Int64Value(int64(memory))
+	if vm.Memory != nil {
+		config.Memory = types.Int64Value(int64(*vm.Memory))
 	}
-	if bootloader, ok := result["bootloader"].(string); ok {
-		config.Bootloader = types.StringValue(bootloader)
+	if vm.Bootloader != nil {
+		config.Bootloader = types.StringValue(*vm.Bootloader)
 	}
-	if autostart, ok := result["autostart"].(bool); ok {
-		config.Autostart = types.BoolValue(autostart)
+	if vm.Autostart != nil {
+		config.Autostart = types.BoolValue(*vm.Autostart)
 	}
-	if status, ok := result["status"].(map[string]interface{}); ok {
-		if state, ok := status["state"].(string); ok {
-			config.Status = types.StringValue(state)
-		}
+	if vm.Status != nil {
+		config.Status = types.StringValue(vm.Status.State)
 	}
-	if uuid, ok := result["uuid"].(string); ok {
-		config.UUID = types.StringValue(uuid)
+	if vm.UUID != nil {
+		config.UUID = types.StringValue(*vm.UUID)
 	}
 
 	diags = resp.State.Set(ctx, &config)
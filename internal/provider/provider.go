@@ -2,9 +2,14 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
@@ -12,9 +17,9 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
 	"github.com/trueform/terraform-provider-trueform/internal/datasources"
 	"github.com/trueform/terraform-provider-trueform/internal/resources"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 // Ensure TrueformProvider satisfies various provider interfaces.
@@ -28,8 +33,40 @@ type TrueformProvider struct {
 // TrueformProviderModel describes the provider data model.
 type TrueformProviderModel struct {
 	Host      types.String `tfsdk:"host"`
+	Hosts     types.List   `tfsdk:"hosts"`
 	APIKey    types.String `tfsdk:"api_key"`
+	Username  types.String `tfsdk:"username"`
+	Password  types.String `tfsdk:"password"`
+	OTPToken  types.String `tfsdk:"otp_token"`
+	OTPSecret types.String `tfsdk:"otp_secret"`
 	VerifySSL types.Bool   `tfsdk:"verify_ssl"`
+
+	APIKeyFile    types.String `tfsdk:"api_key_file"`
+	APIKeyCommand types.String `tfsdk:"api_key_command"`
+
+	CACertFile     types.String `tfsdk:"ca_cert_file"`
+	CACertPEM      types.String `tfsdk:"ca_cert_pem"`
+	ClientCert     types.String `tfsdk:"client_cert"`
+	ClientKey      types.String `tfsdk:"client_key"`
+	TLSServerName  types.String `tfsdk:"tls_server_name"`
+	TLSFingerprint types.String `tfsdk:"tls_sha256_fingerprint"`
+
+	Port       types.Int64  `tfsdk:"port"`
+	Scheme     types.String `tfsdk:"scheme"`
+	APIPath    types.String `tfsdk:"api_path"`
+	APIVersion types.String `tfsdk:"api_version"`
+	ProxyURL   types.String `tfsdk:"proxy_url"`
+
+	Transport  types.String `tfsdk:"transport"`
+	SocketPath types.String `tfsdk:"socket_path"`
+
+	ConnectTimeout types.String `tfsdk:"connect_timeout"`
+	RequestTimeout types.String `tfsdk:"request_timeout"`
+
+	MaxConcurrentRequests types.Int64 `tfsdk:"max_concurrent_requests"`
+
+	TraceFile types.String `tfsdk:"trace_file"`
+	ReadOnly  types.Bool   `tfsdk:"read_only"`
 }
 
 func New(version string) func() provider.Provider {
@@ -50,11 +87,43 @@ func (p *TrueformProvider) Schema(ctx context.Context, req provider.SchemaReques
 		Description: "Terraform provider for managing TrueNAS Scale 25.04+ resources via the WebSocket JSON-RPC API.",
 		Attributes: map[string]schema.Attribute{
 			"host": schema.StringAttribute{
-				Description: "The hostname or IP address of the TrueNAS server. Can also be set via the TRUENAS_HOST environment variable.",
+				Description: "The hostname or IP address of the TrueNAS server. Required unless transport is unix or hosts is set. Can also be set via the TRUENAS_HOST environment variable.",
+				Optional:    true,
+			},
+			"hosts": schema.ListAttribute{
+				Description: "Hostnames or IP addresses of several endpoints of one TrueNAS system, such as both controllers and the VIP of an HA pair. They are tried in order; the provider stays on the first that accepts a connection and fails over to the next when the connection drops. Conflicts with host. Can also be set via the TRUENAS_HOSTS environment variable, separated by commas.",
 				Optional:    true,
+				ElementType: types.StringType,
 			},
 			"api_key": schema.StringAttribute{
-				Description: "The API key for authenticating with TrueNAS. Can also be set via the TRUENAS_API_KEY environment variable.",
+				Description: "The API key for authenticating with TrueNAS. Conflicts with username and password. Can also be set via the TRUENAS_API_KEY environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"api_key_file": schema.StringAttribute{
+				Description: "Path of a file holding the API key, such as one rendered by Vault Agent. Surrounding whitespace is trimmed and the file is read again on every reconnect, so a rotated key is picked up. Conflicts with api_key and api_key_command. Can also be set via the TRUENAS_API_KEY_FILE environment variable.",
+				Optional:    true,
+			},
+			"api_key_command": schema.StringAttribute{
+				Description: "Shell command that prints the API key, such as `pass show truenas/api-key`. Surrounding whitespace is trimmed and the command runs again on every reconnect. Conflicts with api_key and api_key_file. Can also be set via the TRUENAS_API_KEY_COMMAND environment variable.",
+				Optional:    true,
+			},
+			"username": schema.StringAttribute{
+				Description: "The username for password authentication, for use before an API key exists. Can also be set via the TRUENAS_USERNAME environment variable.",
+				Optional:    true,
+			},
+			"password": schema.StringAttribute{
+				Description: "The password for username. Can also be set via the TRUENAS_PASSWORD environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"otp_token": schema.StringAttribute{
+				Description: "A one-time password answering the two-factor challenge of a password login. It is only valid once, so prefer otp_secret for long runs that may reconnect. Can also be set via the TRUENAS_OTP_TOKEN environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"otp_secret": schema.StringAttribute{
+				Description: "The base32 two-factor secret of the user. A fresh TOTP code is generated for every login, including after reconnects. Can also be set via the TRUENAS_OTP_SECRET environment variable.",
 				Optional:    true,
 				Sensitive:   true,
 			},
@@ -62,6 +131,80 @@ func (p *TrueformProvider) Schema(ctx context.Context, req provider.SchemaReques
 				Description: "Whether to verify SSL certificates. Defaults to true. Can also be set via the TRUENAS_VERIFY_SSL environment variable.",
 				Optional:    true,
 			},
+			"ca_cert_file": schema.StringAttribute{
+				Description: "Path to a PEM bundle of CA certificates to trust instead of the system roots, for servers signed by an internal CA. Can also be set via the TRUENAS_CA_CERT_FILE environment variable.",
+				Optional:    true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				Description: "PEM-encoded CA certificates to trust instead of the system roots. Can be combined with ca_cert_file. Can also be set via the TRUENAS_CA_CERT_PEM environment variable.",
+				Optional:    true,
+			},
+			"client_cert": schema.StringAttribute{
+				Description: "Client certificate for mutual TLS, as PEM data or a file path. Requires client_key. Can also be set via the TRUENAS_CLIENT_CERT environment variable.",
+				Optional:    true,
+			},
+			"client_key": schema.StringAttribute{
+				Description: "Private key for client_cert, as PEM data or a file path. Can also be set via the TRUENAS_CLIENT_KEY environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"tls_server_name": schema.StringAttribute{
+				Description: "Server name to verify the TrueNAS certificate against when it differs from host, e.g. when connecting by IP address. Can also be set via the TRUENAS_TLS_SERVER_NAME environment variable.",
+				Optional:    true,
+			},
+			"tls_sha256_fingerprint": schema.StringAttribute{
+				Description: "SHA-256 fingerprint of the TrueNAS certificate, as hex with or without colons. When set, the connection is trusted only if the certificate matches, and the certificate chain is not checked. Can also be set via the TRUENAS_TLS_SHA256_FINGERPRINT environment variable.",
+				Optional:    true,
+			},
+			"port": schema.Int64Attribute{
+				Description: "The port of the TrueNAS API, for servers behind a reverse proxy or NAT. Defaults to the standard port of scheme. Conflicts with a port in host. Can also be set via the TRUENAS_PORT environment variable.",
+				Optional:    true,
+			},
+			"scheme": schema.StringAttribute{
+				Description: "The WebSocket scheme, wss or ws. Defaults to wss; use ws only on a trusted network, as it sends credentials in clear text. Can also be set via the TRUENAS_SCHEME environment variable.",
+				Optional:    true,
+			},
+			"api_path": schema.StringAttribute{
+				Description: "The path of the JSON-RPC endpoint. Defaults to /api/current. Can also be set via the TRUENAS_API_PATH environment variable.",
+				Optional:    true,
+			},
+			"api_version": schema.StringAttribute{
+				Description: "Pins the versioned JSON-RPC endpoint of a TrueNAS release, e.g. 25.04.2 for /api/v25.04.2, so that a NAS upgrade cannot change method signatures under the provider. Conflicts with api_path. Can also be set via the TRUENAS_API_VERSION environment variable.",
+				Optional:    true,
+			},
+			"proxy_url": schema.StringAttribute{
+				Description: "URL of an http, socks5 or socks5h proxy to connect through, e.g. socks5://bastion:1080. Defaults to the HTTPS_PROXY and ALL_PROXY environment variables, honouring NO_PROXY. Can also be set via the TRUENAS_PROXY_URL environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"transport": schema.StringAttribute{
+				Description: "How to reach the middleware: tcp (the default) connects to host over the network, unix connects to the local middleware socket when Terraform runs on the TrueNAS host itself, without TLS or an API key. Can also be set via the TRUENAS_TRANSPORT environment variable.",
+				Optional:    true,
+			},
+			"socket_path": schema.StringAttribute{
+				Description: "Path of the middleware socket for the unix transport. Defaults to " + truenas.DefaultSocketPath + ". Can also be set via the TRUENAS_SOCKET_PATH environment variable.",
+				Optional:    true,
+			},
+			"connect_timeout": schema.StringAttribute{
+				Description: "How long to wait for the connection to TrueNAS, as a duration such as 30s. Defaults to 10s. Can also be set via the TRUENAS_CONNECT_TIMEOUT environment variable.",
+				Optional:    true,
+			},
+			"request_timeout": schema.StringAttribute{
+				Description: "How long to wait for each API response, as a duration such as 1m. Defaults to 10s. Long-running operations such as pool exports and jobs have their own, longer timeouts. Can also be set via the TRUENAS_REQUEST_TIMEOUT environment variable.",
+				Optional:    true,
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				Description: "The maximum number of API requests in flight at once, to keep large applies from overloading the middleware. Defaults to 0, which means no limit. Can also be set via the TRUENAS_MAX_CONCURRENT_REQUESTS environment variable.",
+				Optional:    true,
+			},
+			"read_only": schema.BoolAttribute{
+				Description: "Reject every API call that may change TrueNAS, so that plans can run with confidence against production. Applies that would change anything fail with an error naming the resource. Defaults to false. Can also be set via the TRUENAS_READ_ONLY environment variable.",
+				Optional:    true,
+			},
+			"trace_file": schema.StringAttribute{
+				Description: "Path of a file to append every API request and response to as JSON lines, for debugging. Secrets such as passwords and private keys are redacted. Can also be set via the TRUEFORM_TRACE_FILE environment variable.",
+				Optional:    true,
+			},
 		},
 	}
 }
@@ -82,11 +225,40 @@ func (p *TrueformProvider) Configure(ctx context.Context, req provider.Configure
 		host = config.Host.ValueString()
 	}
 
+	// Configured hosts take precedence over TRUENAS_HOST, and a configured
+	// host over TRUENAS_HOSTS
+	var hosts []string
+	if !config.Hosts.IsNull() {
+		resp.Diagnostics.Append(config.Hosts.ElementsAs(ctx, &hosts, false)...)
+		if !config.Host.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("hosts"),
+				"Conflicting TrueNAS Hosts",
+				"Set either host for a single endpoint or hosts for several, not both.",
+			)
+		}
+		host = ""
+	} else if envVal := os.Getenv("TRUENAS_HOSTS"); envVal != "" && config.Host.IsNull() {
+		for _, h := range strings.Split(envVal, ",") {
+			hosts = append(hosts, strings.TrimSpace(h))
+		}
+		host = ""
+	}
+
 	apiKey := os.Getenv("TRUENAS_API_KEY")
 	if !config.APIKey.IsNull() {
 		apiKey = config.APIKey.ValueString()
 	}
 
+	apiKeyFile := stringValue(config.APIKeyFile, "TRUENAS_API_KEY_FILE")
+	apiKeyCommand := stringValue(config.APIKeyCommand, "TRUENAS_API_KEY_COMMAND")
+	hasAPIKey := apiKey != "" || apiKeyFile != "" || apiKeyCommand != ""
+
+	username := stringValue(config.Username, "TRUENAS_USERNAME")
+	password := stringValue(config.Password, "TRUENAS_PASSWORD")
+	otpToken := stringValue(config.OTPToken, "TRUENAS_OTP_TOKEN")
+	otpSecret := stringValue(config.OTPSecret, "TRUENAS_OTP_SECRET")
+
 	verifySSL := true
 	if envVal := os.Getenv("TRUENAS_VERIFY_SSL"); envVal == "false" {
 		verifySSL = false
@@ -95,22 +267,168 @@ func (p *TrueformProvider) Configure(ctx context.Context, req provider.Configure
 		verifySSL = config.VerifySSL.ValueBool()
 	}
 
+	readOnly := false
+	if envVal := os.Getenv("TRUENAS_READ_ONLY"); envVal != "" {
+		parsed, err := strconv.ParseBool(envVal)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("read_only"),
+				"Invalid Read-Only Setting",
+				"The TRUENAS_READ_ONLY environment variable must be true or false, got "+strconv.Quote(envVal)+".",
+			)
+		}
+		readOnly = parsed
+	}
+	if !config.ReadOnly.IsNull() {
+		readOnly = config.ReadOnly.ValueBool()
+	}
+
+	caCertFile := stringValue(config.CACertFile, "TRUENAS_CA_CERT_FILE")
+	caCertPEM := stringValue(config.CACertPEM, "TRUENAS_CA_CERT_PEM")
+	clientCert := stringValue(config.ClientCert, "TRUENAS_CLIENT_CERT")
+	clientKey := stringValue(config.ClientKey, "TRUENAS_CLIENT_KEY")
+	tlsServerName := stringValue(config.TLSServerName, "TRUENAS_TLS_SERVER_NAME")
+	tlsFingerprint := stringValue(config.TLSFingerprint, "TRUENAS_TLS_SHA256_FINGERPRINT")
+
+	scheme := stringValue(config.Scheme, "TRUENAS_SCHEME")
+	apiPath := stringValue(config.APIPath, "TRUENAS_API_PATH")
+	apiVersion := stringValue(config.APIVersion, "TRUENAS_API_VERSION")
+	proxyURL := stringValue(config.ProxyURL, "TRUENAS_PROXY_URL")
+	transport := stringValue(config.Transport, "TRUENAS_TRANSPORT")
+	socketPath := stringValue(config.SocketPath, "TRUENAS_SOCKET_PATH")
+	traceFile := stringValue(config.TraceFile, "TRUEFORM_TRACE_FILE")
+	local := transport == truenas.TransportUnix
+
+	connectTimeout := durationValue(&resp.Diagnostics, config.ConnectTimeout, "TRUENAS_CONNECT_TIMEOUT", "connect_timeout")
+	requestTimeout := durationValue(&resp.Diagnostics, config.RequestTimeout, "TRUENAS_REQUEST_TIMEOUT", "request_timeout")
+
+	port := int64Value(&resp.Diagnostics, config.Port, "TRUENAS_PORT", "port", "Invalid TrueNAS Port")
+	maxConcurrentRequests := int64Value(&resp.Diagnostics, config.MaxConcurrentRequests,
+		"TRUENAS_MAX_CONCURRENT_REQUESTS", "max_concurrent_requests", "Invalid Request Limit")
+	if maxConcurrentRequests < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_concurrent_requests"),
+			"Invalid Request Limit",
+			"The max_concurrent_requests value must be 0 for no limit or a positive number, got "+strconv.FormatInt(maxConcurrentRequests, 10)+".",
+		)
+	}
+
 	// Validate required configuration
-	if host == "" {
+	if host == "" && len(hosts) == 0 && !local {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("host"),
 			"Missing TrueNAS Host",
 			"The provider cannot create the TrueNAS API client without a host. "+
-				"Set the host value in the configuration or use the TRUENAS_HOST environment variable.",
+				"Set the host value in the configuration or use the TRUENAS_HOST environment variable, "+
+				"or list several endpoints in hosts (TRUENAS_HOSTS).",
 		)
 	}
+	for _, h := range hosts {
+		if h == "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("hosts"),
+				"Invalid TrueNAS Hosts",
+				"The hosts list must not contain empty entries.",
+			)
+			break
+		}
+	}
 
-	if apiKey == "" {
+	switch {
+	case (apiKey != "" && apiKeyFile != "") || (apiKey != "" && apiKeyCommand != "") || (apiKeyFile != "" && apiKeyCommand != ""):
+		resp.Diagnostics.AddAttributeError(
+			path.Root("api_key"),
+			"Conflicting TrueNAS API Key Sources",
+			"Set only one of api_key (TRUENAS_API_KEY), api_key_file (TRUENAS_API_KEY_FILE) and api_key_command (TRUENAS_API_KEY_COMMAND).",
+		)
+	case hasAPIKey && username != "":
+		resp.Diagnostics.AddAttributeError(
+			path.Root("api_key"),
+			"Conflicting TrueNAS Credentials",
+			"Both an API key and a username are set. "+
+				"Use either api_key (TRUENAS_API_KEY) or username and password (TRUENAS_USERNAME, TRUENAS_PASSWORD), not both.",
+		)
+	case !hasAPIKey && username == "" && !local:
 		resp.Diagnostics.AddAttributeError(
 			path.Root("api_key"),
 			"Missing TrueNAS API Key",
-			"The provider cannot create the TrueNAS API client without an API key. "+
-				"Set the api_key value in the configuration or use the TRUENAS_API_KEY environment variable.",
+			"The provider cannot create the TrueNAS API client without an API key or a username and password. "+
+				"Set the api_key value in the configuration or use the TRUENAS_API_KEY environment variable, "+
+				"read it from a file or command with api_key_file or api_key_command, "+
+				"or set username and password (TRUENAS_USERNAME, TRUENAS_PASSWORD).",
+		)
+	case username != "" && password == "":
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password"),
+			"Missing TrueNAS Password",
+			"The provider cannot log in as "+username+" without a password. "+
+				"Set the password value in the configuration or use the TRUENAS_PASSWORD environment variable.",
+		)
+	}
+
+	if otpToken != "" && otpSecret != "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("otp_secret"),
+			"Conflicting One-Time Password Settings",
+			"Set either otp_token or otp_secret, not both.",
+		)
+	} else if otpSecret != "" {
+		if err := truenas.ValidateOTPSecret(otpSecret); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("otp_secret"),
+				"Invalid One-Time Password Secret",
+				"The otp_secret value must be the base32 secret shown when two-factor authentication was enabled: "+err.Error(),
+			)
+		}
+	}
+
+	clientConfig := &truenas.Config{
+		Host:           host,
+		Hosts:          hosts,
+		APIKey:         apiKey,
+		APIKeyFile:     apiKeyFile,
+		APIKeyCommand:  apiKeyCommand,
+		Username:       username,
+		Password:       password,
+		OTPToken:       otpToken,
+		OTPSecret:      otpSecret,
+		VerifySSL:      verifySSL,
+		ConnectTimeout: connectTimeout,
+		Timeout:        requestTimeout,
+		CACertFile:     caCertFile,
+		CACertPEM:      caCertPEM,
+		ClientCert:     clientCert,
+		ClientKey:      clientKey,
+		TLSServerName:  tlsServerName,
+		TLSFingerprint: tlsFingerprint,
+		Port:           int(port),
+		Scheme:         scheme,
+		APIPath:        apiPath,
+		APIVersion:     apiVersion,
+		ProxyURL:       proxyURL,
+		Transport:      transport,
+		SocketPath:     socketPath,
+
+		MaxConcurrentRequests: int(maxConcurrentRequests),
+		TraceFile:             traceFile,
+		ReadOnly:              readOnly,
+
+		// Record or replay sessions for regression tests
+		CassetteFile: os.Getenv("TRUEFORM_CASSETTE"),
+		CassetteMode: truenas.CassetteMode(os.Getenv("TRUEFORM_CASSETTE_MODE")),
+	}
+
+	if port < 0 || port > 65535 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("port"),
+			"Invalid TrueNAS Port",
+			"The port value must be between 1 and 65535, got "+strconv.FormatInt(port, 10)+".",
+		)
+	} else if err := truenas.ValidateConfig(clientConfig); err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Connection Configuration",
+			"The provider cannot use the configured connection settings "+
+				"(transport, socket_path, host, hosts, port, scheme, api_path, api_version, proxy_url, ca_cert_file, ca_cert_pem, client_cert, client_key, tls_sha256_fingerprint): "+err.Error(),
 		)
 	}
 
@@ -118,17 +436,48 @@ func (p *TrueformProvider) Configure(ctx context.Context, req provider.Configure
 		return
 	}
 
+	// Check the key source now rather than on first connect, which names
+	// the attribute to fix
+	if apiKeyFile != "" || apiKeyCommand != "" {
+		if _, err := truenas.LoadAPIKey(ctx, clientConfig); err != nil {
+			attribute := "api_key_file"
+			if apiKeyCommand != "" {
+				attribute = "api_key_command"
+			}
+			resp.Diagnostics.AddAttributeError(
+				path.Root(attribute),
+				"Unable to Read TrueNAS API Key",
+				"The provider could not read the API key from "+attribute+": "+err.Error(),
+			)
+			return
+		}
+	}
+
 	// Create API client
 	tflog.Debug(ctx, "Creating TrueNAS API client", map[string]interface{}{
-		"host":       host,
-		"verify_ssl": verifySSL,
+		"host":            host,
+		"hosts":           hosts,
+		"username":        username,
+		"api_key_file":    apiKeyFile,
+		"api_key_command": apiKeyCommand != "",
+		"verify_ssl":      verifySSL,
+		"ca_cert":         caCertFile != "" || caCertPEM != "",
+		"mtls":            clientCert != "",
+		"pinned":          tlsFingerprint != "",
+		"port":            port,
+		"scheme":          scheme,
+		"api_path":        apiPath,
+		"api_version":     apiVersion,
+		"proxy":           proxyURL != "",
+		"transport":       transport,
+		"connect_timeout": connectTimeout.String(),
+		"request_timeout": requestTimeout.String(),
+		"max_requests":    maxConcurrentRequests,
+		"trace_file":      traceFile,
+		"read_only":       readOnly,
 	})
 
-	apiClient := client.NewClient(&client.Config{
-		Host:      host,
-		APIKey:    apiKey,
-		VerifySSL: verifySSL,
-	})
+	apiClient := truenas.NewClient(clientConfig)
 
 	// Test connection
 	if err := apiClient.Connect(ctx); err != nil {
@@ -140,7 +489,10 @@ func (p *TrueformProvider) Configure(ctx context.Context, req provider.Configure
 		return
 	}
 
-	tflog.Info(ctx, "Successfully connected to TrueNAS")
+	tflog.Info(ctx, "Successfully connected to TrueNAS", map[string]interface{}{
+		"host":    apiClient.Host(),
+		"version": apiClient.Version().String(),
+	})
 
 	// Make the client available to resources and data sources
 	resp.DataSourceData = apiClient
@@ -177,3 +529,55 @@ func (p *TrueformProvider) DataSources(ctx context.Context) []func() datasource.
 		datasources.NewVMDataSource,
 	}
 }
+
+// durationValue parses a duration attribute, falling back to the
+// environment variable when it is not set. It returns zero for the client
+// default when neither is set, and adds an attribute error when the value
+// is not a positive duration.
+func durationValue(diags *diag.Diagnostics, value types.String, envVar string, attribute string) time.Duration {
+	raw := stringValue(value, envVar)
+	if raw == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		diags.AddAttributeError(
+			path.Root(attribute),
+			"Invalid Timeout",
+			fmt.Sprintf("The %s value must be a positive duration such as 30s or 2m, got %q.", attribute, raw),
+		)
+		return 0
+	}
+	return d
+}
+
+// int64Value returns the configured value of a number attribute, falling
+// back to the environment variable when it is not set
+func int64Value(diags *diag.Diagnostics, value types.Int64, envVar string, attribute string, summary string) int64 {
+	if !value.IsNull() {
+		return value.ValueInt64()
+	}
+	raw := os.Getenv(envVar)
+	if raw == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root(attribute),
+			summary,
+			"The "+envVar+" environment variable must be a number, got "+strconv.Quote(raw)+".",
+		)
+		return 0
+	}
+	return n
+}
+
+// stringValue returns the configured value of a string attribute, falling
+// back to the environment variable when it is not set
+func stringValue(value types.String, envVar string) string {
+	if !value.IsNull() {
+		return value.ValueString()
+	}
+	return os.Getenv(envVar)
+}
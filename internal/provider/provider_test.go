@@ -2,9 +2,15 @@ package provider
 
 import (
 	"context"
+	"math/big"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/trueform/terraform-provider-trueform/internal/truenastest"
 )
 
 func TestProviderMetadata(t *testing.T) {
@@ -47,6 +53,22 @@ func TestProviderSchema(t *testing.T) {
 	if _, ok := schema.Attributes["verify_ssl"]; !ok {
 		t.Error("Schema missing 'verify_ssl' attribute")
 	}
+
+	for _, name := range []string{
+		"hosts", "api_key_file", "api_key_command", "username", "password", "otp_token", "otp_secret",
+		"ca_cert_file", "ca_cert_pem", "client_cert", "client_key", "tls_server_name", "tls_sha256_fingerprint",
+		"port", "scheme", "api_path", "api_version", "proxy_url", "transport", "socket_path",
+		"connect_timeout", "request_timeout", "max_concurrent_requests", "trace_file", "read_only",
+	} {
+		if _, ok := schema.Attributes[name]; !ok {
+			t.Errorf("Schema missing '%s' attribute", name)
+		}
+	}
+	for _, name := range []string{"api_key", "password", "otp_token", "otp_secret", "client_key", "proxy_url"} {
+		if !schema.Attributes[name].IsSensitive() {
+			t.Errorf("Attribute '%s' should be sensitive", name)
+		}
+	}
 }
 
 func TestProviderResources(t *testing.T) {
@@ -111,3 +133,234 @@ func TestProviderDataSources(t *testing.T) {
 		}
 	}
 }
+
+// testProvider serves the provider over the plugin protocol, configured
+// against a fake middleware, so tests exercise the same plan and apply path
+// Terraform uses
+type testProvider struct {
+	t       *testing.T
+	server  tfprotov6.ProviderServer
+	schemas map[string]*tfprotov6.Schema
+}
+
+func newTestProvider(t *testing.T, srv *truenastest.Server) *testProvider {
+	t.Helper()
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatalf("NewProtocol6WithError() error = %v", err)
+	}
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("GetProviderSchema() error = %v", err)
+	}
+	p := &testProvider{t: t, server: server, schemas: schemaResp.ResourceSchemas}
+	p.checkDiagnostics("GetProviderSchema", schemaResp.Diagnostics)
+
+	config := p.value(schemaResp.Provider, map[string]tftypes.Value{
+		"host":       tftypes.NewValue(tftypes.String, srv.Host()),
+		"api_key":    tftypes.NewValue(tftypes.String, srv.APIKey),
+		"verify_ssl": tftypes.NewValue(tftypes.Bool, false),
+	})
+	configResp, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{
+		TerraformVersion: "1.6.0",
+		Config:           p.dynamic(schemaResp.Provider, config),
+	})
+	if err != nil {
+		t.Fatalf("ConfigureProvider() error = %v", err)
+	}
+	p.checkDiagnostics("ConfigureProvider", configResp.Diagnostics)
+	return p
+}
+
+// value builds an object for schema, leaving attributes not in attrs null
+func (p *testProvider) value(schema *tfprotov6.Schema, attrs map[string]tftypes.Value) tftypes.Value {
+	typ := schema.ValueType().(tftypes.Object)
+	vals := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+	for name, attrType := range typ.AttributeTypes {
+		if v, ok := attrs[name]; ok {
+			vals[name] = v
+			continue
+		}
+		vals[name] = tftypes.NewValue(attrType, nil)
+	}
+	return tftypes.NewValue(typ, vals)
+}
+
+func (p *testProvider) dynamic(schema *tfprotov6.Schema, v tftypes.Value) *tfprotov6.DynamicValue {
+	p.t.Helper()
+	dv, err := tfprotov6.NewDynamicValue(schema.ValueType(), v)
+	if err != nil {
+		p.t.Fatalf("NewDynamicValue() error = %v", err)
+	}
+	return &dv
+}
+
+func (p *testProvider) checkDiagnostics(step string, diags []*tfprotov6.Diagnostic) {
+	p.t.Helper()
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			p.t.Fatalf("%s: %s: %s", step, d.Summary, d.Detail)
+		}
+	}
+}
+
+// apply plans and applies config against prior, like terraform apply. A
+// null config destroys the resource.
+func (p *testProvider) apply(typeName string, prior, config tftypes.Value) tftypes.Value {
+	p.t.Helper()
+	ctx := context.Background()
+	schema := p.schemas[typeName]
+
+	// Terraform proposes the config, keeping prior values for computed
+	// attributes left unset
+	proposed := config
+	if !config.IsNull() && !prior.IsNull() {
+		var priorAttrs, configAttrs map[string]tftypes.Value
+		_ = prior.As(&priorAttrs)
+		_ = config.As(&configAttrs)
+		for _, attr := range schema.Block.Attributes {
+			if attr.Computed && configAttrs[attr.Name].IsNull() {
+				configAttrs[attr.Name] = priorAttrs[attr.Name]
+			}
+		}
+		proposed = tftypes.NewValue(schema.ValueType(), configAttrs)
+	}
+
+	planResp, err := p.server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       p.dynamic(schema, prior),
+		ProposedNewState: p.dynamic(schema, proposed),
+		Config:           p.dynamic(schema, config),
+	})
+	if err != nil {
+		p.t.Fatalf("PlanResourceChange() error = %v", err)
+	}
+	p.checkDiagnostics("PlanResourceChange", planResp.Diagnostics)
+
+	applyResp, err := p.server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       typeName,
+		PriorState:     p.dynamic(schema, prior),
+		PlannedState:   planResp.PlannedState,
+		Config:         p.dynamic(schema, config),
+		PlannedPrivate: planResp.PlannedPrivate,
+	})
+	if err != nil {
+		p.t.Fatalf("ApplyResourceChange() error = %v", err)
+	}
+	p.checkDiagnostics("ApplyResourceChange", applyResp.Diagnostics)
+	return p.unmarshal(schema, applyResp.NewState)
+}
+
+// read refreshes state, like terraform refresh
+func (p *testProvider) read(typeName string, state tftypes.Value) tftypes.Value {
+	p.t.Helper()
+	schema := p.schemas[typeName]
+	resp, err := p.server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     typeName,
+		CurrentState: p.dynamic(schema, state),
+	})
+	if err != nil {
+		p.t.Fatalf("ReadResource() error = %v", err)
+	}
+	p.checkDiagnostics("ReadResource", resp.Diagnostics)
+	return p.unmarshal(schema, resp.NewState)
+}
+
+func (p *testProvider) unmarshal(schema *tfprotov6.Schema, dv *tfprotov6.DynamicValue) tftypes.Value {
+	p.t.Helper()
+	v, err := dv.Unmarshal(schema.ValueType())
+	if err != nil {
+		p.t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return v
+}
+
+// attribute returns the Go value of a top-level attribute of state
+func attribute(t *testing.T, state tftypes.Value, name string) interface{} {
+	t.Helper()
+	var attrs map[string]tftypes.Value
+	if err := state.As(&attrs); err != nil {
+		t.Fatalf("state is not an object: %v", err)
+	}
+	v := attrs[name]
+	switch {
+	case v.IsNull():
+		return nil
+	case v.Type().Is(tftypes.String):
+		var s string
+		_ = v.As(&s)
+		return s
+	case v.Type().Is(tftypes.Number):
+		n := new(big.Float)
+		_ = v.As(&n)
+		i, _ := n.Int64()
+		return i
+	case v.Type().Is(tftypes.Bool):
+		var b bool
+		_ = v.As(&b)
+		return b
+	}
+	t.Fatalf("attribute %s has unsupported type %s", name, v.Type())
+	return nil
+}
+
+func TestDatasetLifecycle(t *testing.T) {
+	srv := truenastest.NewServer()
+	defer srv.Close()
+	p := newTestProvider(t, srv)
+	schema := p.schemas["trueform_dataset"]
+
+	config := func(quota int64, comments string) tftypes.Value {
+		return p.value(schema, map[string]tftypes.Value{
+			"pool":     tftypes.NewValue(tftypes.String, "tank"),
+			"name":     tftypes.NewValue(tftypes.String, "media"),
+			"quota":    tftypes.NewValue(tftypes.Number, quota),
+			"comments": tftypes.NewValue(tftypes.String, comments),
+		})
+	}
+	null := tftypes.NewValue(schema.ValueType(), nil)
+
+	// Create
+	state := p.apply("trueform_dataset", null, config(1<<30, "Media library"))
+	if got := attribute(t, state, "id"); got != "tank/media" {
+		t.Errorf("id = %v, want tank/media", got)
+	}
+	if got := attribute(t, state, "mountpoint"); got != "/mnt/tank/media" {
+		t.Errorf("mountpoint = %v, want /mnt/tank/media", got)
+	}
+	if got := attribute(t, state, "compression"); got != "LZ4" {
+		t.Errorf("compression = %v, want LZ4", got)
+	}
+	if srv.Get("pool.dataset", "tank/media") == nil {
+		t.Fatal("dataset was not created on the server")
+	}
+
+	// Read
+	state = p.read("trueform_dataset", state)
+	if got := attribute(t, state, "quota"); got != int64(1<<30) {
+		t.Errorf("quota after refresh = %v, want %d", got, int64(1<<30))
+	}
+
+	// Update
+	state = p.apply("trueform_dataset", state, config(2<<30, "Media and photos"))
+	if got := attribute(t, state, "quota"); got != int64(2<<30) {
+		t.Errorf("quota after update = %v, want %d", got, int64(2<<30))
+	}
+	if got := attribute(t, state, "comments"); got != "Media and photos" {
+		t.Errorf("comments after update = %v, want Media and photos", got)
+	}
+	if n := srv.CallCount("pool.dataset.update"); n != 1 {
+		t.Errorf("pool.dataset.update called %d times, want 1", n)
+	}
+
+	// Delete
+	state = p.apply("trueform_dataset", state, null)
+	if !state.IsNull() {
+		t.Errorf("state after destroy = %v, want null", state)
+	}
+	if srv.Get("pool.dataset", "tank/media") != nil {
+		t.Error("dataset still exists on the server after destroy")
+	}
+}
@@ -0,0 +1,21 @@
+package resources
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+// withAddress tags ctx with the address of the resource it serves, for the
+// client's trace. Terraform does not tell providers the resource's name, so
+// the address is the type and, once known, the ID, as in
+// trueform_dataset["tank/media"].
+func withAddress(ctx context.Context, typeName string, id attr.Value) context.Context {
+	address := typeName
+	if id != nil && !id.IsNull() && !id.IsUnknown() {
+		address += "[" + id.String() + "]"
+	}
+	return truenas.WithResourceAddress(ctx, address)
+}
@@ -0,0 +1,81 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+// fakeAPI answers GetInstance with canned middleware payloads keyed by
+// resource and id. Calls it does not implement panic through the nil
+// embedded API.
+type fakeAPI struct {
+	truenas.API
+	instances map[string]string
+}
+
+// newFakeAPI returns a fakeAPI serving payload as resource's instance id.
+// An empty payload leaves the instance missing.
+func newFakeAPI(resource string, id interface{}, payload string) *fakeAPI {
+	f := &fakeAPI{instances: map[string]string{}}
+	if payload != "" {
+		f.instances[instanceKey(resource, id)] = payload
+	}
+	return f
+}
+
+func instanceKey(resource string, id interface{}) string {
+	return fmt.Sprintf("%s/%v", resource, id)
+}
+
+func (f *fakeAPI) GetInstance(ctx context.Context, resource string, id interface{}, result interface{}) error {
+	payload, ok := f.instances[instanceKey(resource, id)]
+	if !ok {
+		return &truenas.APIError{
+			Code:    truenas.ErrCodeInvalidParams,
+			Message: "Invalid params",
+			Details: fmt.Sprintf("[ENOENT] None: InstanceNotFound: %s %v does not exist", resource, id),
+		}
+	}
+	return json.Unmarshal([]byte(payload), result)
+}
+
+// assertModel compares each attribute of the model structs got and want.
+// Attributes left at their zero value in both are skipped.
+func assertModel(t *testing.T, got, want interface{}) {
+	t.Helper()
+	gv := reflect.ValueOf(got)
+	wv := reflect.ValueOf(want)
+	for i := 0; i < gv.NumField(); i++ {
+		name := gv.Type().Field(i).Tag.Get("tfsdk")
+		gf, wf := gv.Field(i), wv.Field(i)
+		switch {
+		case gf.IsZero() && wf.IsZero():
+			continue
+		case gf.IsZero() || wf.IsZero():
+			t.Errorf("%s = %v, want %v", name, gf.Interface(), wf.Interface())
+			continue
+		}
+		g := gf.Interface().(attr.Value)
+		w := wf.Interface().(attr.Value)
+		if !g.Equal(w) {
+			t.Errorf("%s = %v, want %v", name, g, w)
+		}
+	}
+}
+
+// stringList returns a known list of strings
+func stringList(values ...string) types.List {
+	elems := make([]attr.Value, len(values))
+	for i, v := range values {
+		elems[i] = types.StringValue(v)
+	}
+	return types.ListValueMust(types.StringType, elems)
+}
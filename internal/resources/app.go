@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -13,9 +14,16 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
+// appJobTimeout bounds app install, upgrade and removal jobs, which pull images
+const appJobTimeout = 10 * time.Minute
+
+// appMinimumVersion is the first release with the Docker-based app.* API;
+// earlier releases run apps on Kubernetes through chart.release
+const appMinimumVersion = "24.10"
+
 var (
 	_ resource.Resource                = &AppResource{}
 	_ resource.ResourceWithImportState = &AppResource{}
@@ -26,7 +34,7 @@ func NewAppResource() resource.Resource {
 }
 
 type AppResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type AppResourceModel struct {
@@ -40,6 +48,16 @@ type AppResourceModel struct {
 	Metadata    types.Map    `tfsdk:"metadata"`
 }
 
+// appFields maps the fields of app.create, app.update and app.upgrade to schema attributes
+var appFields = apiFields{
+	"app_name":    path.Root("name"),
+	"catalog_app": path.Root("catalog_app"),
+	"train":       path.Root("train"),
+	"version":     path.Root("version"),
+	"app_version": path.Root("version"),
+	"values":      path.Root("values"),
+}
+
 func (r *AppResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_app"
 }
@@ -100,15 +118,24 @@ func (r *AppResource) Configure(ctx context.Context, req resource.ConfigureReque
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_app", nil)
+
+	// Checked here rather than in Configure, which runs for every plan and
+	// refresh, so an unknown version is reported once per change
+	requireVersion(&resp.Diagnostics, r.client, "trueform_app", appMinimumVersion)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan AppResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -121,16 +148,11 @@ func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, re
 		"catalog_app": plan.CatalogApp.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"app_name":    plan.Name.ValueString(),
-		"catalog_app": plan.CatalogApp.ValueString(),
-	}
-
-	if !plan.Train.IsNull() {
-		createData["train"] = plan.Train.ValueString()
-	}
-	if !plan.Version.IsNull() {
-		createData["version"] = plan.Version.ValueString()
+	createData := truenas.AppCreateRequest{
+		AppName:    plan.Name.ValueString(),
+		CatalogApp: plan.CatalogApp.ValueString(),
+		Train:      plan.Train.ValueStringPointer(),
+		Version:    plan.Version.ValueStringPointer(),
 	}
 
 	if !plan.Values.IsNull() && plan.Values.ValueString() != "" {
@@ -139,18 +161,18 @@ func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, re
 			resp.Diagnostics.AddError("Invalid Values JSON", "Could not parse values JSON: "+err.Error())
 			return
 		}
-		createData["values"] = values
+		createData.Values = values
 	}
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "app", createData, &result)
+	// App installs are long-running jobs, wait for them to complete
+	err := r.client.Create(ctx, "app", createData, nil, truenas.AsJob(), truenas.WithTimeout(appJobTimeout))
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating App", "Could not create app: "+err.Error())
+		addClientError(&resp.Diagnostics, appFields, "Error Creating App", "Could not create app", err)
 		return
 	}
 
 	if err := r.readApp(ctx, plan.Name.ValueString(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading App", "Could not read app after creation: "+err.Error())
+		addClientError(&resp.Diagnostics, appFields, "Error Reading App", "Could not read app after creation", err)
 		return
 	}
 
@@ -166,12 +188,14 @@ func (r *AppResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_app", state.ID)
+
 	if err := r.readApp(ctx, state.ID.ValueString(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading App", "Could not read app: "+err.Error())
+		addClientError(&resp.Diagnostics, appFields, "Error Reading App", "Could not read app", err)
 		return
 	}
 
@@ -180,6 +204,11 @@ func (r *AppResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 }
 
 func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	requireVersion(&resp.Diagnostics, r.client, "trueform_app", appMinimumVersion)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan AppResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -194,11 +223,13 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_app", state.ID)
+
 	tflog.Debug(ctx, "Updating app", map[string]interface{}{
 		"name": state.ID.ValueString(),
 	})
 
-	updateData := map[string]interface{}{}
+	var updateData truenas.AppUpdateRequest
 
 	if !plan.Values.Equal(state.Values) && !plan.Values.IsNull() {
 		var values map[string]interface{}
@@ -206,14 +237,13 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 			resp.Diagnostics.AddError("Invalid Values JSON", "Could not parse values JSON: "+err.Error())
 			return
 		}
-		updateData["values"] = values
+		updateData.Values = values
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "app", state.ID.ValueString(), updateData, &result)
+	if updateData.Values != nil {
+		err := r.client.Update(ctx, "app", state.ID.ValueString(), updateData, nil, truenas.AsJob(), truenas.WithTimeout(appJobTimeout))
 		if err != nil {
-			resp.Diagnostics.AddError("Error Updating App", "Could not update app: "+err.Error())
+			addClientError(&resp.Diagnostics, appFields, "Error Updating App", "Could not update app", err)
 			return
 		}
 	}
@@ -222,18 +252,16 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 	if !plan.Version.Equal(state.Version) && !plan.Version.IsNull() {
 		err := r.client.Call(ctx, "app.upgrade", []interface{}{
 			state.ID.ValueString(),
-			map[string]interface{}{
-				"app_version": plan.Version.ValueString(),
-			},
-		}, nil)
+			truenas.AppUpgradeOptions{AppVersion: plan.Version.ValueString()},
+		}, nil, truenas.AsJob(), truenas.WithTimeout(appJobTimeout))
 		if err != nil {
-			resp.Diagnostics.AddError("Error Upgrading App", "Could not upgrade app: "+err.Error())
+			addClientError(&resp.Diagnostics, appFields, "Error Upgrading App", "Could not upgrade app", err)
 			return
 		}
 	}
 
 	if err := r.readApp(ctx, state.ID.ValueString(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading App", "Could not read app after update: "+err.Error())
+		addClientError(&resp.Diagnostics, appFields, "Error Reading App", "Could not read app after update", err)
 		return
 	}
 
@@ -249,13 +277,15 @@ func (r *AppResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_app", state.ID)
+
 	tflog.Debug(ctx, "Deleting app", map[string]interface{}{
 		"name": state.ID.ValueString(),
 	})
 
-	err := r.client.Delete(ctx, "app", state.ID.ValueString())
+	err := r.client.Call(ctx, "app.delete", []interface{}{state.ID.ValueString()}, nil, truenas.AsJob(), truenas.WithTimeout(appJobTimeout))
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting App", "Could not delete app: "+err.Error())
+		addClientError(&resp.Diagnostics, appFields, "Error Deleting App", "Could not delete app", err)
 		return
 	}
 }
@@ -265,26 +295,26 @@ func (r *AppResource) ImportState(ctx context.Context, req resource.ImportStateR
 }
 
 func (r *AppResource) readApp(ctx context.Context, name string, model *AppResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "app", name, &result)
+	var app truenas.App
+	err := r.client.GetInstance(ctx, "app", name, &app)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.StringValue(result["id"].(string))
-	model.Name = types.StringValue(result["name"].(string))
+	model.ID = types.StringValue(app.ID)
+	model.Name = types.StringValue(app.Name)
 
-	if metadata, ok := result["metadata"].(map[string]interface{}); ok {
-		if appVersion, ok := metadata["app_version"].(string); ok {
-			model.Version = types.StringValue(appVersion)
+	if metadata := app.Metadata; metadata != nil {
+		if metadata.AppVersion != nil {
+			model.Version = types.StringValue(*metadata.AppVersion)
 		}
-		if train, ok := metadata["train"].(string); ok {
-			model.Train = types.StringValue(train)
+		if metadata.Train != nil {
+			model.Train = types.StringValue(*metadata.Train)
 		}
 	}
 
-	if state, ok := result["state"].(string); ok {
-		model.State = types.StringValue(state)
+	if app.State != nil {
+		model.State = types.StringValue(*app.State)
 	}
 
 	return nil
@@ -0,0 +1,101 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadApp(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    AppResourceModel
+		wantErr bool
+	}{
+		{
+			name: "running app",
+			payload: `{
+				"id": "jellyfin", "name": "jellyfin", "state": "RUNNING", "upgrade_available": false,
+				"human_version": "10.10.7_1.1.12", "version": "1.1.12", "custom_app": false,
+				"metadata": {"name": "jellyfin", "app_version": "10.10.7", "train": "community", "title": "Jellyfin"},
+				"active_workloads": {"containers": 1}
+			}`,
+			want: AppResourceModel{
+				ID:      types.StringValue("jellyfin"),
+				Name:    types.StringValue("jellyfin"),
+				Version: types.StringValue("10.10.7"),
+				Train:   types.StringValue("community"),
+				State:   types.StringValue("RUNNING"),
+			},
+		},
+		{
+			name:    "deploying app without metadata",
+			payload: `{"id": "jellyfin", "name": "jellyfin", "state": "DEPLOYING", "metadata": null}`,
+			want: AppResourceModel{
+				ID:    types.StringValue("jellyfin"),
+				Name:  types.StringValue("jellyfin"),
+				State: types.StringValue("DEPLOYING"),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed metadata",
+			payload: `{"id": "jellyfin", "name": "jellyfin", "metadata": "community"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &AppResource{client: newFakeAPI("app", "jellyfin", tt.payload)}
+
+			var model AppResourceModel
+			err := r.readApp(context.Background(), "jellyfin", &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readApp() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readApp() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readApp() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
+
+func TestAppVersionCheck(t *testing.T) {
+	// Configure runs before every plan and refresh, so it must not repeat
+	// the unknown version warning
+	r := &AppResource{}
+	var configure resource.ConfigureResponse
+	r.Configure(context.Background(), resource.ConfigureRequest{ProviderData: &versionAPI{}}, &configure)
+	if len(configure.Diagnostics) != 0 {
+		t.Errorf("Configure() diagnostics = %v, want none", configure.Diagnostics)
+	}
+
+	r = &AppResource{client: &versionAPI{version: "24.04.2"}}
+	var create resource.CreateResponse
+	r.Create(context.Background(), resource.CreateRequest{}, &create)
+	if got := create.Diagnostics.ErrorsCount(); got != 1 {
+		t.Errorf("Create() on 24.04 errors = %d, want 1: %v", got, create.Diagnostics)
+	}
+
+	var update resource.UpdateResponse
+	r.Update(context.Background(), resource.UpdateRequest{}, &update)
+	if got := update.Diagnostics.ErrorsCount(); got != 1 {
+		t.Errorf("Update() on 24.04 errors = %d, want 1: %v", got, update.Diagnostics)
+	}
+}
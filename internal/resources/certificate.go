@@ -14,7 +14,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -27,7 +27,7 @@ func NewCertificateResource() resource.Resource {
 }
 
 type CertificateResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type CertificateResourceModel struct {
@@ -56,6 +56,27 @@ type CertificateResourceModel struct {
 	NotAfter         types.String `tfsdk:"not_after"`
 }
 
+// certificateFields maps the fields of certificate.create and certificate.update to schema attributes
+var certificateFields = apiFields{
+	"name":                path.Root("name"),
+	"create_type":         path.Root("type"),
+	"certificate":         path.Root("certificate"),
+	"privatekey":          path.Root("privatekey"),
+	"signedby":            path.Root("signedby"),
+	"key_length":          path.Root("key_length"),
+	"key_type":            path.Root("key_type"),
+	"digest_algorithm":    path.Root("digest_algorithm"),
+	"lifetime":            path.Root("lifetime"),
+	"country":             path.Root("country"),
+	"state":               path.Root("state"),
+	"city":                path.Root("city"),
+	"organization":        path.Root("organization"),
+	"organizational_unit": path.Root("organizational_unit"),
+	"email":               path.Root("email"),
+	"common_name":         path.Root("common_name"),
+	"san":                 path.Root("san"),
+}
+
 func (r *CertificateResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_certificate"
 }
@@ -177,15 +198,17 @@ func (r *CertificateResource) Configure(ctx context.Context, req resource.Config
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *CertificateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_certificate", nil)
+
 	var plan CertificateResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -198,72 +221,43 @@ func (r *CertificateResource) Create(ctx context.Context, req resource.CreateReq
 		"type": plan.Type.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"name":       plan.Name.ValueString(),
-		"create_type": plan.Type.ValueString(),
+	createData := truenas.CertificateCreateRequest{
+		Name:               plan.Name.ValueString(),
+		CreateType:         plan.Type.ValueString(),
+		Certificate:        plan.Certificate.ValueStringPointer(),
+		Privatekey:         plan.PrivateKey.ValueStringPointer(),
+		SignedBy:           plan.SignedBy.ValueInt64Pointer(),
+		KeyLength:          plan.KeyLength.ValueInt64Pointer(),
+		KeyType:            plan.KeyType.ValueStringPointer(),
+		DigestAlgorithm:    plan.DigestAlgorithm.ValueStringPointer(),
+		Lifetime:           plan.Lifetime.ValueInt64Pointer(),
+		Country:            plan.Country.ValueStringPointer(),
+		State:              plan.State.ValueStringPointer(),
+		City:               plan.City.ValueStringPointer(),
+		Organization:       plan.Organization.ValueStringPointer(),
+		OrganizationalUnit: plan.OrganizationalUnit.ValueStringPointer(),
+		Email:              plan.Email.ValueStringPointer(),
+		CommonName:         plan.CommonName.ValueStringPointer(),
 	}
 
-	if !plan.Certificate.IsNull() {
-		createData["certificate"] = plan.Certificate.ValueString()
-	}
-	if !plan.PrivateKey.IsNull() {
-		createData["privatekey"] = plan.PrivateKey.ValueString()
-	}
-	if !plan.SignedBy.IsNull() {
-		createData["signedby"] = plan.SignedBy.ValueInt64()
-	}
-	if !plan.KeyLength.IsNull() {
-		createData["key_length"] = plan.KeyLength.ValueInt64()
-	}
-	if !plan.KeyType.IsNull() {
-		createData["key_type"] = plan.KeyType.ValueString()
-	}
-	if !plan.DigestAlgorithm.IsNull() {
-		createData["digest_algorithm"] = plan.DigestAlgorithm.ValueString()
-	}
-	if !plan.Lifetime.IsNull() {
-		createData["lifetime"] = plan.Lifetime.ValueInt64()
-	}
-	if !plan.Country.IsNull() {
-		createData["country"] = plan.Country.ValueString()
-	}
-	if !plan.State.IsNull() {
-		createData["state"] = plan.State.ValueString()
-	}
-	if !plan.City.IsNull() {
-		createData["city"] = plan.City.ValueString()
-	}
-	if !plan.Organization.IsNull() {
-		createData["organization"] = plan.Organization.ValueString()
-	}
-	if !plan.OrganizationalUnit.IsNull() {
-		createData["organizational_unit"] = plan.OrganizationalUnit.ValueString()
-	}
-	if !plan.Email.IsNull() {
-		createData["email"] = plan.Email.ValueString()
-	}
-	if !plan.CommonName.IsNull() {
-		createData["common_name"] = plan.CommonName.ValueString()
-	}
 	if !plan.San.IsNull() {
 		var san []string
 		diags = plan.San.ElementsAs(ctx, &san, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["san"] = san
+			createData.SAN = san
 		}
 	}
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "certificate", createData, &result)
+	var cert truenas.Certificate
+	err := r.client.Create(ctx, "certificate", createData, &cert)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating Certificate", "Could not create certificate: "+err.Error())
+		addClientError(&resp.Diagnostics, certificateFields, "Error Creating Certificate", "Could not create certificate", err)
 		return
 	}
 
-	certID := int64(result["id"].(float64))
-	if err := r.readCertificate(ctx, certID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading Certificate", "Could not read certificate after creation: "+err.Error())
+	if err := r.readCertificate(ctx, cert.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, certificateFields, "Error Reading Certificate", "Could not read certificate after creation", err)
 		return
 	}
 
@@ -279,12 +273,14 @@ func (r *CertificateResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_certificate", state.ID)
+
 	if err := r.readCertificate(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading Certificate", "Could not read certificate: "+err.Error())
+		addClientError(&resp.Diagnostics, certificateFields, "Error Reading Certificate", "Could not read certificate", err)
 		return
 	}
 
@@ -307,21 +303,13 @@ func (r *CertificateResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_certificate", state.ID)
+
 	// Certificates have very limited update capability
-	// Most fields require recreation
-	updateData := map[string]interface{}{}
-
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "certificate", state.ID.ValueInt64(), updateData, &result)
-		if err != nil {
-			resp.Diagnostics.AddError("Error Updating Certificate", "Could not update certificate: "+err.Error())
-			return
-		}
-	}
+	// Most fields require recreation, so there is nothing to send
 
 	if err := r.readCertificate(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading Certificate", "Could not read certificate after update: "+err.Error())
+		addClientError(&resp.Diagnostics, certificateFields, "Error Reading Certificate", "Could not read certificate after update", err)
 		return
 	}
 
@@ -337,9 +325,11 @@ func (r *CertificateResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_certificate", state.ID)
+
 	err := r.client.Delete(ctx, "certificate", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting Certificate", "Could not delete certificate: "+err.Error())
+		addClientError(&resp.Diagnostics, certificateFields, "Error Deleting Certificate", "Could not delete certificate", err)
 		return
 	}
 }
@@ -357,76 +347,72 @@ func (r *CertificateResource) ImportState(ctx context.Context, req resource.Impo
 }
 
 func (r *CertificateResource) readCertificate(ctx context.Context, id int64, model *CertificateResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "certificate", id, &result)
+	var cert truenas.Certificate
+	err := r.client.GetInstance(ctx, "certificate", id, &cert)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Name = types.StringValue(result["name"].(string))
+	model.ID = types.Int64Value(cert.ID)
+	model.Name = types.StringValue(cert.Name)
 
-	if certType, ok := result["type"].(float64); ok {
+	if cert.Type != nil {
 		// Map numeric type back to string
-		model.Type = types.StringValue(fmt.Sprintf("%d", int(certType)))
+		model.Type = types.StringValue(fmt.Sprintf("%d", *cert.Type))
 	}
-	if csr, ok := result["CSR"].(string); ok {
-		model.CSR = types.StringValue(csr)
+	if cert.CSR != nil {
+		model.CSR = types.StringValue(*cert.CSR)
 	}
-	if signedBy, ok := result["signedby"].(float64); ok {
-		model.SignedBy = types.Int64Value(int64(signedBy))
+	if cert.SignedBy != nil {
+		model.SignedBy = types.Int64Value(cert.SignedBy.ID)
 	}
-	if keyLength, ok := result["key_length"].(float64); ok {
-		model.KeyLength = types.Int64Value(int64(keyLength))
+	if cert.KeyLength != nil {
+		model.KeyLength = types.Int64Value(int64(*cert.KeyLength))
 	}
-	if keyType, ok := result["key_type"].(string); ok {
-		model.KeyType = types.StringValue(keyType)
+	if cert.KeyType != nil {
+		model.KeyType = types.StringValue(*cert.KeyType)
 	}
-	if digestAlgorithm, ok := result["digest_algorithm"].(string); ok {
-		model.DigestAlgorithm = types.StringValue(digestAlgorithm)
+	if cert.DigestAlgorithm != nil {
+		model.DigestAlgorithm = types.StringValue(*cert.DigestAlgorithm)
 	}
-	if lifetime, ok := result["lifetime"].(float64); ok {
-		model.Lifetime = types.Int64Value(int64(lifetime))
+	if cert.Lifetime != nil {
+		model.Lifetime = types.Int64Value(int64(*cert.Lifetime))
 	}
-	if country, ok := result["country"].(string); ok {
-		model.Country = types.StringValue(country)
+	if cert.Country != nil {
+		model.Country = types.StringValue(*cert.Country)
 	}
-	if state, ok := result["state"].(string); ok {
-		model.State = types.StringValue(state)
+	if cert.State != nil {
+		model.State = types.StringValue(*cert.State)
 	}
-	if city, ok := result["city"].(string); ok {
-		model.City = types.StringValue(city)
+	if cert.City != nil {
+		model.City = types.StringValue(*cert.City)
 	}
-	if organization, ok := result["organization"].(string); ok {
-		model.Organization = types.StringValue(organization)
+	if cert.Organization != nil {
+		model.Organization = types.StringValue(*cert.Organization)
 	}
-	if ou, ok := result["organizational_unit"].(string); ok {
-		model.OrganizationalUnit = types.StringValue(ou)
+	if cert.OrganizationalUnit != nil {
+		model.OrganizationalUnit = types.StringValue(*cert.OrganizationalUnit)
 	}
-	if email, ok := result["email"].(string); ok {
-		model.Email = types.StringValue(email)
+	if cert.Email != nil {
+		model.Email = types.StringValue(*cert.Email)
 	}
-	if commonName, ok := result["common"].(string); ok {
-		model.CommonName = types.StringValue(commonName)
+	if cert.Common != nil {
+		model.CommonName = types.StringValue(*cert.Common)
 	}
-	if san, ok := result["san"].([]interface{}); ok {
-		sanList := make([]string, len(san))
-		for i, s := range san {
-			sanList[i] = s.(string)
-		}
-		sanValues, diags := types.ListValueFrom(ctx, types.StringType, sanList)
+	if cert.SAN != nil {
+		sanValues, diags := types.ListValueFrom(ctx, types.StringType, cert.SAN)
 		if !diags.HasError() {
 			model.San = sanValues
 		}
 	}
-	if fingerprint, ok := result["fingerprint"].(string); ok {
-		model.Fingerprint = types.StringValue(fingerprint)
+	if cert.Fingerprint != nil {
+		model.Fingerprint = types.StringValue(*cert.Fingerprint)
 	}
-	if notBefore, ok := result["from"].(string); ok {
-		model.NotBefore = types.StringValue(notBefore)
+	if cert.From != nil {
+		model.NotBefore = types.StringValue(*cert.From)
 	}
-	if notAfter, ok := result["until"].(string); ok {
-		model.NotAfter = types.StringValue(notAfter)
+	if cert.Until != nil {
+		model.NotAfter = types.StringValue(*cert.Until)
 	}
 
 	return nil
@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -28,7 +28,7 @@ func NewCronjobResource() resource.Resource {
 }
 
 type CronjobResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type CronjobResourceModel struct {
@@ -42,6 +42,22 @@ type CronjobResourceModel struct {
 	Schedule    types.Object `tfsdk:"schedule"`
 }
 
+// cronjobFields maps the fields of cronjob.create and cronjob.update to schema attributes
+var cronjobFields = apiFields{
+	"user":            path.Root("user"),
+	"command":         path.Root("command"),
+	"description":     path.Root("description"),
+	"enabled":         path.Root("enabled"),
+	"stdout":          path.Root("stdout"),
+	"stderr":          path.Root("stderr"),
+	"schedule":        path.Root("schedule"),
+	"schedule.minute": path.Root("schedule").AtName("minute"),
+	"schedule.hour":   path.Root("schedule").AtName("hour"),
+	"schedule.dom":    path.Root("schedule").AtName("dom"),
+	"schedule.month":  path.Root("schedule").AtName("month"),
+	"schedule.dow":    path.Root("schedule").AtName("dow"),
+}
+
 type CronSchedule struct {
 	Minute  types.String `tfsdk:"minute"`
 	Hour    types.String `tfsdk:"hour"`
@@ -136,15 +152,17 @@ func (r *CronjobResource) Configure(ctx context.Context, req resource.ConfigureR
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *CronjobResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_cronjob", nil)
+
 	var plan CronjobResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -164,35 +182,31 @@ func (r *CronjobResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
-	createData := map[string]interface{}{
-		"user":    plan.User.ValueString(),
-		"command": plan.Command.ValueString(),
-		"enabled": plan.Enabled.ValueBool(),
-		"stdout":  plan.StdOut.ValueBool(),
-		"stderr":  plan.StdErr.ValueBool(),
-		"schedule": map[string]interface{}{
-			"minute": schedule.Minute.ValueString(),
-			"hour":   schedule.Hour.ValueString(),
-			"dom":    schedule.Dom.ValueString(),
-			"month":  schedule.Month.ValueString(),
-			"dow":    schedule.Dow.ValueString(),
+	createData := truenas.CronJobRequest{
+		User:        plan.User.ValueString(),
+		Command:     plan.Command.ValueString(),
+		Description: plan.Description.ValueStringPointer(),
+		Enabled:     plan.Enabled.ValueBool(),
+		Stdout:      plan.StdOut.ValueBool(),
+		Stderr:      plan.StdErr.ValueBool(),
+		Schedule: truenas.Schedule{
+			Minute: schedule.Minute.ValueString(),
+			Hour:   schedule.Hour.ValueString(),
+			Dom:    schedule.Dom.ValueString(),
+			Month:  schedule.Month.ValueString(),
+			Dow:    schedule.Dow.ValueString(),
 		},
 	}
 
-	if !plan.Description.IsNull() {
-		createData["description"] = plan.Description.ValueString()
-	}
-
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "cronjob", createData, &result)
+	var job truenas.CronJob
+	err := r.client.Create(ctx, "cronjob", createData, &job)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating Cron Job", "Could not create cron job: "+err.Error())
+		addClientError(&resp.Diagnostics, cronjobFields, "Error Creating Cron Job", "Could not create cron job", err)
 		return
 	}
 
-	jobID := int64(result["id"].(float64))
-	if err := r.readCronjob(ctx, jobID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading Cron Job", "Could not read cron job after creation: "+err.Error())
+	if err := r.readCronjob(ctx, job.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, cronjobFields, "Error Reading Cron Job", "Could not read cron job after creation", err)
 		return
 	}
 
@@ -208,12 +222,14 @@ func (r *CronjobResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_cronjob", state.ID)
+
 	if err := r.readCronjob(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading Cron Job", "Could not read cron job: "+err.Error())
+		addClientError(&resp.Diagnostics, cronjobFields, "Error Reading Cron Job", "Could not read cron job", err)
 		return
 	}
 
@@ -236,6 +252,8 @@ func (r *CronjobResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_cronjob", state.ID)
+
 	var schedule CronSchedule
 	diags = plan.Schedule.As(ctx, &schedule, basetypes.ObjectAsOptions{})
 	resp.Diagnostics.Append(diags...)
@@ -243,34 +261,30 @@ func (r *CronjobResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
-	updateData := map[string]interface{}{
-		"user":    plan.User.ValueString(),
-		"command": plan.Command.ValueString(),
-		"enabled": plan.Enabled.ValueBool(),
-		"stdout":  plan.StdOut.ValueBool(),
-		"stderr":  plan.StdErr.ValueBool(),
-		"schedule": map[string]interface{}{
-			"minute": schedule.Minute.ValueString(),
-			"hour":   schedule.Hour.ValueString(),
-			"dom":    schedule.Dom.ValueString(),
-			"month":  schedule.Month.ValueString(),
-			"dow":    schedule.Dow.ValueString(),
+	updateData := truenas.CronJobRequest{
+		User:        plan.User.ValueString(),
+		Command:     plan.Command.ValueString(),
+		Description: plan.Description.ValueStringPointer(),
+		Enabled:     plan.Enabled.ValueBool(),
+		Stdout:      plan.StdOut.ValueBool(),
+		Stderr:      plan.StdErr.ValueBool(),
+		Schedule: truenas.Schedule{
+			Minute: schedule.Minute.ValueString(),
+			Hour:   schedule.Hour.ValueString(),
+			Dom:    schedule.Dom.ValueString(),
+			Month:  schedule.Month.ValueString(),
+			Dow:    schedule.Dow.ValueString(),
 		},
 	}
 
-	if !plan.Description.IsNull() {
-		updateData["description"] = plan.Description.ValueString()
-	}
-
-	var result map[string]interface{}
-	err := r.client.Update(ctx, "cronjob", state.ID.ValueInt64(), updateData, &result)
+	err := r.client.Update(ctx, "cronjob", state.ID.ValueInt64(), updateData, nil)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Updating Cron Job", "Could not update cron job: "+err.Error())
+		addClientError(&resp.Diagnostics, cronjobFields, "Error Updating Cron Job", "Could not update cron job", err)
 		return
 	}
 
 	if err := r.readCronjob(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading Cron Job", "Could not read cron job after update: "+err.Error())
+		addClientError(&resp.Diagnostics, cronjobFields, "Error Reading Cron Job", "Could not read cron job after update", err)
 		return
 	}
 
@@ -286,9 +300,11 @@ func (r *CronjobResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_cronjob", state.ID)
+
 	err := r.client.Delete(ctx, "cronjob", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting Cron Job", "Could not delete cron job: "+err.Error())
+		addClientError(&resp.Diagnostics, cronjobFields, "Error Deleting Cron Job", "Could not delete cron job", err)
 		return
 	}
 }
@@ -306,30 +322,29 @@ func (r *CronjobResource) ImportState(ctx context.Context, req resource.ImportSt
 }
 
 func (r *CronjobResource) readCronjob(ctx context.Context, id int64, model *CronjobResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "cronjob", id, &result)
+	var job truenas.CronJob
+	err := r.client.GetInstance(ctx, "cronjob", id, &job)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.User = types.StringValue(result["user"].(string))
-	model.Command = types.StringValue(result["command"].(string))
+	model.ID = types.Int64Value(job.ID)
+	model.User = types.StringValue(job.User)
+	model.Command = types.StringValue(job.Command)
 
-	if description, ok := result["description"].(string); ok {
-		model.Description = types.StringValue(description)
+	if job.Description != nil {
+		model.Description = types.StringValue(*job.Description)
 	}
-	if enabled, ok := result["enabled"].(bool); ok {
-		model.Enabled = types.BoolValue(enabled)
+	if job.Enabled != nil {
+		model.Enabled = types.BoolValue(*job.Enabled)
 	}
-	if stdout, ok := result["stdout"].(bool); ok {
-		model.StdOut = types.BoolValue(stdout)
+	if job.Stdout != nil {
+		model.StdOut = types.BoolValue(*job.Stdout)
 	}
-	if stderr, ok := result["stderr"].(bool); ok {
-		model.StdErr = types.BoolValue(stderr)
+	if job.Stderr != nil {
+		model.StdErr = types.BoolValue(*job.Stderr)
 	}
-
-	if sched, ok := result["schedule"].(map[string]interface{}); ok {
+	if sched := job.Schedule; sched != nil {
 		scheduleObj, d := types.ObjectValue(
 			map[string]attr.Type{
 				"minute": types.StringType,
@@ -339,11 +354,11 @@ func (r *CronjobResource) readCronjob(ctx context.Context, id int64, model *Cron
 				"dow":    types.StringType,
 			},
 			map[string]attr.Value{
-				"minute": types.StringValue(sched["minute"].(string)),
-				"hour":   types.StringValue(sched["hour"].(string)),
-				"dom":    types.StringValue(sched["dom"].(string)),
-				"month":  types.StringValue(sched["month"].(string)),
-				"dow":    types.StringValue(sched["dow"].(string)),
+				"minute": types.StringValue(sched.Minute),
+				"hour":   types.StringValue(sched.Hour),
+				"dom":    types.StringValue(sched.Dom),
+				"month":  types.StringValue(sched.Month),
+				"dow":    types.StringValue(sched.Dow),
 			},
 		)
 		if !d.HasError() {
@@ -0,0 +1,94 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadCronjob(t *testing.T) {
+	scheduleTypes := map[string]attr.Type{
+		"minute": types.StringType,
+		"hour":   types.StringType,
+		"dom":    types.StringType,
+		"month":  types.StringType,
+		"dow":    types.StringType,
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    CronjobResourceModel
+		wantErr bool
+	}{
+		{
+			name: "nightly scrub",
+			payload: `{
+				"id": 4, "user": "root", "command": "zpool scrub tank", "description": "Nightly scrub",
+				"enabled": true, "stdout": false, "stderr": true,
+				"schedule": {"minute": "30", "hour": "2", "dom": "*", "month": "*", "dow": "1-5"}
+			}`,
+			want: CronjobResourceModel{
+				ID:          types.Int64Value(4),
+				User:        types.StringValue("root"),
+				Command:     types.StringValue("zpool scrub tank"),
+				Description: types.StringValue("Nightly scrub"),
+				Enabled:     types.BoolValue(true),
+				StdOut:      types.BoolValue(false),
+				StdErr:      types.BoolValue(true),
+				Schedule: types.ObjectValueMust(scheduleTypes, map[string]attr.Value{
+					"minute": types.StringValue("30"),
+					"hour":   types.StringValue("2"),
+					"dom":    types.StringValue("*"),
+					"month":  types.StringValue("*"),
+					"dow":    types.StringValue("1-5"),
+				}),
+			},
+		},
+		{
+			name:    "no schedule",
+			payload: `{"id": 4, "user": "root", "command": "zpool scrub tank", "enabled": false}`,
+			want: CronjobResourceModel{
+				ID:      types.Int64Value(4),
+				User:    types.StringValue("root"),
+				Command: types.StringValue("zpool scrub tank"),
+				Enabled: types.BoolValue(false),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed schedule",
+			payload: `{"id": 4, "user": "root", "command": "zpool scrub tank", "schedule": "30 2 * * 1-5"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &CronjobResource{client: newFakeAPI("cronjob", 4, tt.payload)}
+
+			var model CronjobResourceModel
+			err := r.readCronjob(context.Background(), 4, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readCronjob() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readCronjob() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readCronjob() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
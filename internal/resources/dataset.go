@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -28,7 +28,7 @@ func NewDatasetResource() resource.Resource {
 }
 
 type DatasetResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type DatasetResourceModel struct {
@@ -63,6 +63,31 @@ type DatasetResourceModel struct {
 	Available       types.Int64  `tfsdk:"available"`
 }
 
+// datasetFields maps the fields of pool.dataset.create and pool.dataset.update to schema attributes
+var datasetFields = apiFields{
+	"name":            path.Root("name"),
+	"type":            path.Root("type"),
+	"comments":        path.Root("comments"),
+	"compression":     path.Root("compression"),
+	"atime":           path.Root("atime"),
+	"deduplication":   path.Root("deduplication"),
+	"quota":           path.Root("quota"),
+	"quota_warning":   path.Root("quota_warning"),
+	"quota_critical":  path.Root("quota_critical"),
+	"refquota":        path.Root("refquota"),
+	"reservation":     path.Root("reservation"),
+	"refreservation":  path.Root("refreservation"),
+	"copies":          path.Root("copies"),
+	"snapdir":         path.Root("snapdir"),
+	"readonly":        path.Root("readonly"),
+	"recordsize":      path.Root("recordsize"),
+	"casesensitivity": path.Root("casesensitivity"),
+	"aclmode":         path.Root("aclmode"),
+	"acltype":         path.Root("acltype"),
+	"share_type":      path.Root("share_type"),
+	"managedby":       path.Root("managed_by"),
+}
+
 func (r *DatasetResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_dataset"
 }
@@ -231,11 +256,11 @@ func (r *DatasetResource) Configure(ctx context.Context, req resource.ConfigureR
 		return
 	}
 
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData),
+			fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData),
 		)
 		return
 	}
@@ -244,6 +269,8 @@ func (r *DatasetResource) Configure(ctx context.Context, req resource.ConfigureR
 }
 
 func (r *DatasetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_dataset", nil)
+
 	var plan DatasetResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -258,82 +285,46 @@ func (r *DatasetResource) Create(ctx context.Context, req resource.CreateRequest
 		"path": datasetPath,
 	})
 
-	createData := map[string]interface{}{
-		"name": datasetPath,
-		"type": plan.Type.ValueString(),
+	createData := truenas.DatasetCreateRequest{
+		Name:            datasetPath,
+		Type:            plan.Type.ValueString(),
+		Casesensitivity: knownString(plan.Casesensitivity),
+		ShareType:       knownString(plan.ShareType),
+		DatasetUpdateRequest: truenas.DatasetUpdateRequest{
+			Comments:       plan.Comments.ValueStringPointer(),
+			Compression:    plan.Compression.ValueStringPointer(),
+			Atime:          plan.Atime.ValueStringPointer(),
+			Deduplication:  plan.Deduplication.ValueStringPointer(),
+			Quota:          plan.Quota.ValueInt64Pointer(),
+			QuotaWarning:   plan.QuotaWarning.ValueInt64Pointer(),
+			QuotaCritical:  plan.QuotaCritical.ValueInt64Pointer(),
+			Refquota:       plan.Refquota.ValueInt64Pointer(),
+			Reservation:    plan.Reservation.ValueInt64Pointer(),
+			Refreservation: plan.Refreservation.ValueInt64Pointer(),
+			Copies:         plan.Copies.ValueInt64Pointer(),
+			Snapdir:        knownString(plan.Snapdir),
+			Readonly:       knownString(plan.Readonly),
+			Recordsize:     knownString(plan.Recordsize),
+			Aclmode:        knownString(plan.Aclmode),
+			Acltype:        knownString(plan.Acltype),
+		},
 	}
 
-	if !plan.Comments.IsNull() {
-		createData["comments"] = plan.Comments.ValueString()
-	}
-	if !plan.Compression.IsNull() {
-		createData["compression"] = plan.Compression.ValueString()
-	}
-	if !plan.Atime.IsNull() {
-		createData["atime"] = plan.Atime.ValueString()
-	}
-	if !plan.Deduplication.IsNull() {
-		createData["deduplication"] = plan.Deduplication.ValueString()
-	}
-	if !plan.Quota.IsNull() {
-		createData["quota"] = plan.Quota.ValueInt64()
-	}
-	if !plan.QuotaWarning.IsNull() {
-		createData["quota_warning"] = plan.QuotaWarning.ValueInt64()
-	}
-	if !plan.QuotaCritical.IsNull() {
-		createData["quota_critical"] = plan.QuotaCritical.ValueInt64()
-	}
-	if !plan.Refquota.IsNull() {
-		createData["refquota"] = plan.Refquota.ValueInt64()
-	}
-	if !plan.Reservation.IsNull() {
-		createData["reservation"] = plan.Reservation.ValueInt64()
-	}
-	if !plan.Refreservation.IsNull() {
-		createData["refreservation"] = plan.Refreservation.ValueInt64()
-	}
-	if !plan.Copies.IsNull() {
-		createData["copies"] = plan.Copies.ValueInt64()
-	}
-	if !plan.Snapdir.IsNull() && !plan.Snapdir.IsUnknown() {
-		createData["snapdir"] = plan.Snapdir.ValueString()
-	}
-	if !plan.Readonly.IsNull() && !plan.Readonly.IsUnknown() {
-		createData["readonly"] = plan.Readonly.ValueString()
-	}
-	if !plan.Recordsize.IsNull() && !plan.Recordsize.IsUnknown() {
-		createData["recordsize"] = plan.Recordsize.ValueString()
-	}
-	if !plan.Casesensitivity.IsNull() && !plan.Casesensitivity.IsUnknown() {
-		createData["casesensitivity"] = plan.Casesensitivity.ValueString()
-	}
-	if !plan.Aclmode.IsNull() && !plan.Aclmode.IsUnknown() {
-		createData["aclmode"] = plan.Aclmode.ValueString()
-	}
-	if !plan.Acltype.IsNull() && !plan.Acltype.IsUnknown() {
-		createData["acltype"] = plan.Acltype.ValueString()
-	}
-	if !plan.ShareType.IsNull() && !plan.ShareType.IsUnknown() {
-		createData["share_type"] = plan.ShareType.ValueString()
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(datasetPath))
+	if unlock == nil {
+		return
 	}
+	defer unlock()
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "pool.dataset", createData, &result)
+	err := r.client.Create(ctx, "pool.dataset", createData, nil)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Creating Dataset",
-			"Could not create dataset: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, datasetFields, "Error Creating Dataset", "Could not create dataset", err)
 		return
 	}
 
 	// Read the created dataset
 	if err := r.readDataset(ctx, datasetPath, &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading Dataset",
-			"Could not read dataset after creation: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, datasetFields, "Error Reading Dataset", "Could not read dataset after creation", err)
 		return
 	}
 
@@ -349,15 +340,14 @@ func (r *DatasetResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_dataset", state.ID)
+
 	if err := r.readDataset(ctx, state.ID.ValueString(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
-			"Error Reading Dataset",
-			"Could not read dataset: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, datasetFields, "Error Reading Dataset", "Could not read dataset", err)
 		return
 	}
 
@@ -380,64 +370,62 @@ func (r *DatasetResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_dataset", state.ID)
+
 	tflog.Debug(ctx, "Updating dataset", map[string]interface{}{
 		"id": state.ID.ValueString(),
 	})
 
-	updateData := map[string]interface{}{}
+	var updateData truenas.DatasetUpdateRequest
 
 	if !plan.Comments.Equal(state.Comments) {
-		if plan.Comments.IsNull() {
-			updateData["comments"] = ""
-		} else {
-			updateData["comments"] = plan.Comments.ValueString()
-		}
+		updateData.Comments = stringOrEmpty(plan.Comments)
 	}
 	if !plan.Compression.Equal(state.Compression) {
-		updateData["compression"] = plan.Compression.ValueString()
+		updateData.Compression = truenas.Ptr(plan.Compression.ValueString())
 	}
 	if !plan.Atime.Equal(state.Atime) {
-		updateData["atime"] = plan.Atime.ValueString()
+		updateData.Atime = truenas.Ptr(plan.Atime.ValueString())
 	}
 	if !plan.Deduplication.Equal(state.Deduplication) {
-		updateData["deduplication"] = plan.Deduplication.ValueString()
+		updateData.Deduplication = truenas.Ptr(plan.Deduplication.ValueString())
 	}
 	if !plan.Quota.Equal(state.Quota) {
-		updateData["quota"] = plan.Quota.ValueInt64()
+		updateData.Quota = truenas.Ptr(plan.Quota.ValueInt64())
 	}
 	if !plan.QuotaWarning.Equal(state.QuotaWarning) {
-		updateData["quota_warning"] = plan.QuotaWarning.ValueInt64()
+		updateData.QuotaWarning = truenas.Ptr(plan.QuotaWarning.ValueInt64())
 	}
 	if !plan.QuotaCritical.Equal(state.QuotaCritical) {
-		updateData["quota_critical"] = plan.QuotaCritical.ValueInt64()
+		updateData.QuotaCritical = truenas.Ptr(plan.QuotaCritical.ValueInt64())
 	}
 	if !plan.Refquota.Equal(state.Refquota) {
-		updateData["refquota"] = plan.Refquota.ValueInt64()
+		updateData.Refquota = truenas.Ptr(plan.Refquota.ValueInt64())
 	}
 	if !plan.Reservation.Equal(state.Reservation) {
-		updateData["reservation"] = plan.Reservation.ValueInt64()
+		updateData.Reservation = truenas.Ptr(plan.Reservation.ValueInt64())
 	}
 	if !plan.Refreservation.Equal(state.Refreservation) {
-		updateData["refreservation"] = plan.Refreservation.ValueInt64()
+		updateData.Refreservation = truenas.Ptr(plan.Refreservation.ValueInt64())
 	}
 	if !plan.Copies.Equal(state.Copies) {
-		updateData["copies"] = plan.Copies.ValueInt64()
+		updateData.Copies = truenas.Ptr(plan.Copies.ValueInt64())
 	}
 	if !plan.Snapdir.Equal(state.Snapdir) {
-		updateData["snapdir"] = plan.Snapdir.ValueString()
+		updateData.Snapdir = truenas.Ptr(plan.Snapdir.ValueString())
 	}
 	if !plan.Readonly.Equal(state.Readonly) {
-		updateData["readonly"] = plan.Readonly.ValueString()
+		updateData.Readonly = truenas.Ptr(plan.Readonly.ValueString())
 	}
 	// Only update computed fields if explicitly configured with valid values
 	if !plan.Recordsize.Equal(state.Recordsize) && !plan.Recordsize.IsNull() && !plan.Recordsize.IsUnknown() && plan.Recordsize.ValueString() != "" {
-		updateData["recordsize"] = plan.Recordsize.ValueString()
+		updateData.Recordsize = truenas.Ptr(plan.Recordsize.ValueString())
 	}
 	if !plan.Aclmode.Equal(state.Aclmode) && !plan.Aclmode.IsNull() && !plan.Aclmode.IsUnknown() && plan.Aclmode.ValueString() != "" {
-		updateData["aclmode"] = plan.Aclmode.ValueString()
+		updateData.Aclmode = truenas.Ptr(plan.Aclmode.ValueString())
 	}
 	if !plan.Acltype.Equal(state.Acltype) && !plan.Acltype.IsNull() && !plan.Acltype.IsUnknown() && plan.Acltype.ValueString() != "" {
-		updateData["acltype"] = plan.Acltype.ValueString()
+		updateData.Acltype = truenas.Ptr(plan.Acltype.ValueString())
 	}
 	// share_type cannot be updated after dataset creation
 	if !plan.ShareType.Equal(state.ShareType) && !plan.ShareType.IsNull() && !plan.ShareType.IsUnknown() && plan.ShareType.ValueString() != "" {
@@ -447,24 +435,23 @@ func (r *DatasetResource) Update(ctx context.Context, req resource.UpdateRequest
 		)
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "pool.dataset", state.ID.ValueString(), updateData, &result)
+	if updateData != (truenas.DatasetUpdateRequest{}) {
+		unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(state.ID.ValueString()))
+		if unlock == nil {
+			return
+		}
+		defer unlock()
+
+		err := r.client.Update(ctx, "pool.dataset", state.ID.ValueString(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Updating Dataset",
-				"Could not update dataset: "+err.Error(),
-			)
+			addClientError(&resp.Diagnostics, datasetFields, "Error Updating Dataset", "Could not update dataset", err)
 			return
 		}
 	}
 
 	// Read the updated dataset
 	if err := r.readDataset(ctx, state.ID.ValueString(), &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading Dataset",
-			"Could not read dataset after update: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, datasetFields, "Error Reading Dataset", "Could not read dataset after update", err)
 		return
 	}
 
@@ -480,16 +467,21 @@ func (r *DatasetResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_dataset", state.ID)
+
 	tflog.Debug(ctx, "Deleting dataset", map[string]interface{}{
 		"id": state.ID.ValueString(),
 	})
 
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(state.ID.ValueString()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
 	err := r.client.Delete(ctx, "pool.dataset", state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Deleting Dataset",
-			"Could not delete dataset: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, datasetFields, "Error Deleting Dataset", "Could not delete dataset", err)
 		return
 	}
 }
@@ -499,17 +491,16 @@ func (r *DatasetResource) ImportState(ctx context.Context, req resource.ImportSt
 }
 
 func (r *DatasetResource) readDataset(ctx context.Context, id string, model *DatasetResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "pool.dataset", id, &result)
+	var dataset truenas.Dataset
+	err := r.client.GetInstance(ctx, "pool.dataset", id, &dataset)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.StringValue(result["id"].(string))
+	model.ID = types.StringValue(dataset.ID)
 
 	// Extract pool and name from the full path
-	fullName := result["name"].(string)
-	parts := strings.SplitN(fullName, "/", 2)
+	parts := strings.SplitN(dataset.Name, "/", 2)
 	model.Pool = types.StringValue(parts[0])
 	if len(parts) > 1 {
 		model.Name = types.StringValue(parts[1])
@@ -517,116 +508,80 @@ func (r *DatasetResource) readDataset(ctx context.Context, id string, model *Dat
 		model.Name = types.StringValue("")
 	}
 
-	model.Type = types.StringValue(result["type"].(string))
+	model.Type = types.StringValue(dataset.Type)
 
-	if comments, ok := result["comments"].(string); ok {
+	if comments, ok := dataset.Comments.StringValue(); ok {
 		model.Comments = types.StringValue(comments)
 	}
-	if compression, ok := result["compression"].(map[string]interface{}); ok {
-		if value, ok := compression["value"].(string); ok {
-			model.Compression = types.StringValue(value)
-		}
+	if compression, ok := dataset.Compression.StringValue(); ok {
+		model.Compression = types.StringValue(compression)
 	}
-	if atime, ok := result["atime"].(map[string]interface{}); ok {
-		if value, ok := atime["value"].(string); ok {
-			model.Atime = types.StringValue(value)
-		}
+	if atime, ok := dataset.Atime.StringValue(); ok {
+		model.Atime = types.StringValue(atime)
 	}
-	if dedup, ok := result["deduplication"].(map[string]interface{}); ok {
-		if value, ok := dedup["value"].(string); ok {
-			model.Deduplication = types.StringValue(value)
-		}
+	if dedup, ok := dataset.Deduplication.StringValue(); ok {
+		model.Deduplication = types.StringValue(dedup)
 	}
-	if quota, ok := result["quota"].(map[string]interface{}); ok {
-		if parsed, ok := quota["parsed"].(float64); ok {
-			model.Quota = types.Int64Value(int64(parsed))
-		}
+	if quota, ok := dataset.Quota.Int64Value(); ok {
+		model.Quota = types.Int64Value(quota)
 	}
-	if refquota, ok := result["refquota"].(map[string]interface{}); ok {
-		if parsed, ok := refquota["parsed"].(float64); ok {
-			model.Refquota = types.Int64Value(int64(parsed))
-		}
+	if refquota, ok := dataset.Refquota.Int64Value(); ok {
+		model.Refquota = types.Int64Value(refquota)
 	}
-	if reservation, ok := result["reservation"].(map[string]interface{}); ok {
-		if parsed, ok := reservation["parsed"].(float64); ok {
-			model.Reservation = types.Int64Value(int64(parsed))
-		}
+	if reservation, ok := dataset.Reservation.Int64Value(); ok {
+		model.Reservation = types.Int64Value(reservation)
 	}
-	if refreservation, ok := result["refreservation"].(map[string]interface{}); ok {
-		if parsed, ok := refreservation["parsed"].(float64); ok {
-			model.Refreservation = types.Int64Value(int64(parsed))
-		}
+	if refreservation, ok := dataset.Refreservation.Int64Value(); ok {
+		model.Refreservation = types.Int64Value(refreservation)
 	}
-	if copies, ok := result["copies"].(map[string]interface{}); ok {
-		if value, ok := copies["value"].(string); ok {
-			// Parse string to int
-			var c int64
-			_, _ = fmt.Sscanf(value, "%d", &c)
-			model.Copies = types.Int64Value(c)
-		}
+	if copies, ok := dataset.Copies.StringValue(); ok {
+		// Parse string to int
+		var c int64
+		_, _ = fmt.Sscanf(copies, "%d", &c)
+		model.Copies = types.Int64Value(c)
 	}
-	if snapdir, ok := result["snapdir"].(map[string]interface{}); ok {
-		if value, ok := snapdir["value"].(string); ok {
-			model.Snapdir = types.StringValue(value)
-		}
+	if snapdir, ok := dataset.Snapdir.StringValue(); ok {
+		model.Snapdir = types.StringValue(snapdir)
 	}
-	if readonly, ok := result["readonly"].(map[string]interface{}); ok {
-		if value, ok := readonly["value"].(string); ok {
-			model.Readonly = types.StringValue(value)
-		}
+	if readonly, ok := dataset.Readonly.StringValue(); ok {
+		model.Readonly = types.StringValue(readonly)
 	}
-	if recordsize, ok := result["recordsize"].(map[string]interface{}); ok {
-		if value, ok := recordsize["value"].(string); ok {
-			model.Recordsize = types.StringValue(value)
-		}
+	if recordsize, ok := dataset.Recordsize.StringValue(); ok {
+		model.Recordsize = types.StringValue(recordsize)
 	}
-	if casesens, ok := result["casesensitivity"].(map[string]interface{}); ok {
-		if value, ok := casesens["value"].(string); ok {
-			model.Casesensitivity = types.StringValue(value)
-		}
+	if casesens, ok := dataset.Casesensitivity.StringValue(); ok {
+		model.Casesensitivity = types.StringValue(casesens)
 	}
-	if aclmode, ok := result["aclmode"].(map[string]interface{}); ok {
-		if value, ok := aclmode["value"].(string); ok {
-			model.Aclmode = types.StringValue(value)
-		}
+	if aclmode, ok := dataset.Aclmode.StringValue(); ok {
+		model.Aclmode = types.StringValue(aclmode)
 	}
-	if acltype, ok := result["acltype"].(map[string]interface{}); ok {
-		if value, ok := acltype["value"].(string); ok {
-			model.Acltype = types.StringValue(value)
-		}
+	if acltype, ok := dataset.Acltype.StringValue(); ok {
+		model.Acltype = types.StringValue(acltype)
 	}
-	if managedBy, ok := result["managedby"].(map[string]interface{}); ok {
-		if value, ok := managedBy["value"].(string); ok && value != "" {
-			model.ManagedBy = types.StringValue(value)
-		} else {
-			model.ManagedBy = types.StringNull()
-		}
+	if managedBy, ok := dataset.Managedby.StringValue(); ok && managedBy != "" {
+		model.ManagedBy = types.StringValue(managedBy)
 	} else {
 		model.ManagedBy = types.StringNull()
 	}
-	if mountpoint, ok := result["mountpoint"].(string); ok {
-		model.Mountpoint = types.StringValue(mountpoint)
+	if dataset.Mountpoint != nil {
+		model.Mountpoint = types.StringValue(*dataset.Mountpoint)
 	}
-	if encrypted, ok := result["encrypted"].(bool); ok {
-		model.Encrypted = types.BoolValue(encrypted)
+	if dataset.Encrypted != nil {
+		model.Encrypted = types.BoolValue(*dataset.Encrypted)
 	}
-	if encryptionRoot, ok := result["encryption_root"].(string); ok && encryptionRoot != "" {
-		model.EncryptionRoot = types.StringValue(encryptionRoot)
+	if dataset.EncryptionRoot != nil && *dataset.EncryptionRoot != "" {
+		model.EncryptionRoot = types.StringValue(*dataset.EncryptionRoot)
 	} else {
 		model.EncryptionRoot = types.StringNull()
 	}
-	if keyLoaded, ok := result["key_loaded"].(bool); ok {
-		model.KeyLoaded = types.BoolValue(keyLoaded)
+	if dataset.KeyLoaded != nil {
+		model.KeyLoaded = types.BoolValue(*dataset.KeyLoaded)
 	}
-	if used, ok := result["used"].(map[string]interface{}); ok {
-		if parsed, ok := used["parsed"].(float64); ok {
-			model.Used = types.Int64Value(int64(parsed))
-		}
+	if used, ok := dataset.Used.Int64Value(); ok {
+		model.Used = types.Int64Value(used)
 	}
-	if available, ok := result["available"].(map[string]interface{}); ok {
-		if parsed, ok := available["parsed"].(float64); ok {
-			model.Available = types.Int64Value(int64(parsed))
-		}
+	if available, ok := dataset.Available.Int64Value(); ok {
+		model.Available = types.Int64Value(available)
 	}
 
 	return nil
@@ -0,0 +1,133 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadDataset(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		payload string
+		want    DatasetResourceModel
+		wantErr bool
+	}{
+		{
+			name: "child dataset",
+			id:   "tank/media",
+			payload: `{
+				"id": "tank/media", "name": "tank/media", "pool": "tank", "type": "FILESYSTEM",
+				"comments": {"value": "Media library", "rawvalue": "Media library", "parsed": "Media library", "source": "LOCAL"},
+				"compression": {"value": "LZ4", "rawvalue": "lz4", "parsed": "lz4", "source": "LOCAL"},
+				"atime": {"value": "OFF", "rawvalue": "off", "parsed": false, "source": "LOCAL"},
+				"deduplication": {"value": "OFF", "rawvalue": "off", "parsed": "off", "source": "DEFAULT"},
+				"quota": {"value": "100G", "rawvalue": "107374182400", "parsed": 107374182400, "source": "LOCAL"},
+				"refquota": {"value": null, "rawvalue": "0", "parsed": null, "source": "DEFAULT"},
+				"reservation": {"value": "0", "rawvalue": "0", "parsed": 0, "source": "DEFAULT"},
+				"refreservation": {"value": "0", "rawvalue": "0", "parsed": 0, "source": "DEFAULT"},
+				"copies": {"value": "2", "rawvalue": "2", "parsed": 2, "source": "LOCAL"},
+				"snapdir": {"value": "HIDDEN", "rawvalue": "hidden", "parsed": "hidden", "source": "DEFAULT"},
+				"readonly": {"value": "OFF", "rawvalue": "off", "parsed": false, "source": "DEFAULT"},
+				"recordsize": {"value": "1M", "rawvalue": "1048576", "parsed": 1048576, "source": "LOCAL"},
+				"casesensitivity": {"value": "SENSITIVE", "rawvalue": "sensitive", "parsed": "sensitive", "source": "NONE"},
+				"aclmode": {"value": "DISCARD", "rawvalue": "discard", "parsed": "discard", "source": "LOCAL"},
+				"acltype": {"value": "POSIX", "rawvalue": "posix", "parsed": "posix", "source": "LOCAL"},
+				"managedby": {"value": "", "rawvalue": "", "parsed": "", "source": "DEFAULT"},
+				"used": {"value": "1.5G", "rawvalue": "1610612736", "parsed": 1610612736, "source": "NONE"},
+				"available": {"value": "98.5G", "rawvalue": "105763569664", "parsed": 105763569664, "source": "NONE"},
+				"mountpoint": "/mnt/tank/media",
+				"encrypted": false,
+				"encryption_root": null,
+				"key_loaded": false
+			}`,
+			want: DatasetResourceModel{
+				ID:              types.StringValue("tank/media"),
+				Name:            types.StringValue("media"),
+				Pool:            types.StringValue("tank"),
+				Type:            types.StringValue("FILESYSTEM"),
+				Comments:        types.StringValue("Media library"),
+				Compression:     types.StringValue("LZ4"),
+				Atime:           types.StringValue("OFF"),
+				Deduplication:   types.StringValue("OFF"),
+				Quota:           types.Int64Value(107374182400),
+				Reservation:     types.Int64Value(0),
+				Refreservation:  types.Int64Value(0),
+				Copies:          types.Int64Value(2),
+				Snapdir:         types.StringValue("HIDDEN"),
+				Readonly:        types.StringValue("OFF"),
+				Recordsize:      types.StringValue("1M"),
+				Casesensitivity: types.StringValue("SENSITIVE"),
+				Aclmode:         types.StringValue("DISCARD"),
+				Acltype:         types.StringValue("POSIX"),
+				Mountpoint:      types.StringValue("/mnt/tank/media"),
+				Encrypted:       types.BoolValue(false),
+				KeyLoaded:       types.BoolValue(false),
+				Used:            types.Int64Value(1610612736),
+				Available:       types.Int64Value(105763569664),
+			},
+		},
+		{
+			name: "encrypted pool root with plain comments",
+			id:   "tank",
+			payload: `{
+				"id": "tank", "name": "tank", "pool": "tank", "type": "FILESYSTEM",
+				"comments": "Primary pool",
+				"managedby": {"value": "truecommand", "rawvalue": "truecommand", "parsed": "truecommand", "source": "LOCAL"},
+				"mountpoint": "/mnt/tank",
+				"encrypted": true,
+				"encryption_root": "tank",
+				"key_loaded": true
+			}`,
+			want: DatasetResourceModel{
+				ID:             types.StringValue("tank"),
+				Name:           types.StringValue(""),
+				Pool:           types.StringValue("tank"),
+				Type:           types.StringValue("FILESYSTEM"),
+				Comments:       types.StringValue("Primary pool"),
+				ManagedBy:      types.StringValue("truecommand"),
+				Mountpoint:     types.StringValue("/mnt/tank"),
+				Encrypted:      types.BoolValue(true),
+				EncryptionRoot: types.StringValue("tank"),
+				KeyLoaded:      types.BoolValue(true),
+			},
+		},
+		{
+			name:    "not found",
+			id:      "tank/missing",
+			wantErr: true,
+		},
+		{
+			name:    "malformed property",
+			id:      "tank/media",
+			payload: `{"id": "tank/media", "name": "tank/media", "quota": {"value": 5}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &DatasetResource{client: newFakeAPI("pool.dataset", tt.id, tt.payload)}
+
+			var model DatasetResourceModel
+			err := r.readDataset(context.Background(), tt.id, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readDataset() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readDataset() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readDataset() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
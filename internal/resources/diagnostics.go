@@ -0,0 +1,111 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+// apiFields maps middleware field names, as validation errors report them
+// without the method prefix, to the schema attributes they are set from.
+// Nested fields are dotted, such as "schedule.minute".
+type apiFields map[string]path.Path
+
+// attribute returns the schema attribute for a validation error on field. It
+// tries the whole field and then each parent, so an error inside a list
+// element lands on the list attribute; exact reports whether the whole field
+// matched. ok is false when no part of the field is mapped.
+func (f apiFields) attribute(field []string) (attr path.Path, exact bool, ok bool) {
+	for n := len(field); n > 0; n-- {
+		if attr, ok := f[strings.Join(field[:n], ".")]; ok {
+			return attr, n == len(field), true
+		}
+	}
+	return path.Empty(), false, false
+}
+
+// addClientError adds err to diags. Middleware validation failures become one
+// error per entry, on the schema attribute fields maps the entry to or on the
+// resource when it maps none, and calls blocked by read-only mode name the
+// resource; other errors are added as a single error.
+func addClientError(diags *diag.Diagnostics, fields apiFields, summary string, detail string, err error) {
+	var roErr *truenas.ReadOnlyError
+	if errors.As(err, &roErr) {
+		resource := roErr.Address
+		if resource == "" {
+			resource = "A resource"
+		}
+		diags.AddError(
+			"Provider Is Read-Only",
+			fmt.Sprintf("%s would call %s, which may change TrueNAS, but the provider is configured with read_only (TRUENAS_READ_ONLY). "+
+				"Plans work in read-only mode; apply with a provider configuration that is not read-only.", resource, roErr.Method),
+		)
+		return
+	}
+	if verrs, ok := truenas.AsValidationErrors(err); ok {
+		for _, v := range verrs {
+			field := v.Field()
+			if len(field) == 0 {
+				diags.AddError(summary, detail+": "+v.Message)
+				continue
+			}
+			attr, exact, ok := fields.attribute(field)
+			msg := v.Message
+			if !exact {
+				// Keep the middleware's field name when it does not name the
+				// attribute the error is reported on
+				msg = strings.Join(field, ".") + ": " + msg
+			}
+			if !ok {
+				diags.AddError(summary, detail+": "+msg)
+				continue
+			}
+			diags.AddAttributeError(attr, summary, detail+": "+msg)
+		}
+		return
+	}
+	diags.AddError(summary, errorDetail(detail, err))
+}
+
+// requireVersion adds an error to diags when the connected TrueNAS is older
+// than minimum, the first release that supports resourceType, and a warning
+// when the server version is unknown and so could not be checked
+func requireVersion(diags *diag.Diagnostics, c truenas.API, resourceType string, minimum string) {
+	err := c.RequireVersion(minimum)
+	if err == nil {
+		return
+	}
+	var versionErr *truenas.VersionError
+	if errors.As(err, &versionErr) && versionErr.Actual.IsZero() {
+		diags.AddWarning(
+			"Unknown TrueNAS Version",
+			fmt.Sprintf("%s requires TrueNAS %s or later, but the server did not report its version, so this could not be checked. Calls will fail if the server is older.",
+				resourceType, versionErr.Required),
+		)
+		return
+	}
+	if errors.As(err, &versionErr) {
+		diags.AddError(
+			"Unsupported TrueNAS Version",
+			fmt.Sprintf("%s requires TrueNAS %s or later, but the server runs %s. Upgrade TrueNAS or remove the %s resources from the configuration.",
+				resourceType, versionErr.Required, versionErr.Actual, resourceType),
+		)
+		return
+	}
+	diags.AddError("Unsupported TrueNAS Version", errorDetail("Could not check the TrueNAS version for "+resourceType, err))
+}
+
+// errorDetail formats err for a diagnostic detail. Failed jobs are expanded
+// into their method, error class, traceback and log excerpt.
+func errorDetail(summary string, err error) string {
+	var jobErr *truenas.JobError
+	if errors.As(err, &jobErr) {
+		return summary + ":\n\n" + jobErr.Detail()
+	}
+	return summary + ": " + err.Error()
+}
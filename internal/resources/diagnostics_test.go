@@ -0,0 +1,140 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+// versionAPI answers RequireVersion as a server running version would
+type versionAPI struct {
+	truenas.API
+	version string
+}
+
+func (v *versionAPI) RequireVersion(minimum string) error {
+	required, err := truenas.ParseVersion(minimum)
+	if err != nil {
+		return err
+	}
+	var actual truenas.Version
+	if v.version != "" {
+		actual, _ = truenas.ParseVersion(v.version)
+		if actual.AtLeast(required) {
+			return nil
+		}
+	}
+	return &truenas.VersionError{Required: required, Actual: actual}
+}
+
+func TestRequireVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      string
+		wantErrors   int
+		wantWarnings int
+	}{
+		{name: "supported", version: "25.04.2"},
+		{name: "too old", version: "24.04.2", wantErrors: 1},
+		{name: "unknown", version: "", wantWarnings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			requireVersion(&diags, &versionAPI{version: tt.version}, "trueform_app", "24.10")
+			if got := diags.ErrorsCount(); got != tt.wantErrors {
+				t.Errorf("errors = %d, want %d: %v", got, tt.wantErrors, diags)
+			}
+			if got := diags.WarningsCount(); got != tt.wantWarnings {
+				t.Errorf("warnings = %d, want %d: %v", got, tt.wantWarnings, diags)
+			}
+		})
+	}
+}
+
+func TestAddClientErrorValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    apiFields
+		attribute string
+		wantPath  path.Path
+		wantInMsg string
+		// onResource expects an error on the resource, not an attribute
+		onResource bool
+	}{
+		{
+			name:      "same name",
+			fields:    datasetFields,
+			attribute: "pool_dataset_create.quota",
+			wantPath:  path.Root("quota"),
+		},
+		{
+			name:      "renamed attribute",
+			fields:    datasetFields,
+			attribute: "pool_dataset_update.managedby",
+			wantPath:  path.Root("managed_by"),
+		},
+		{
+			name:      "nested attribute",
+			fields:    cronjobFields,
+			attribute: "cronjob_create.schedule.minute",
+			wantPath:  path.Root("schedule").AtName("minute"),
+		},
+		{
+			name:      "list element",
+			fields:    iscsiPortalFields,
+			attribute: "iscsiportal_create.listen.0.ip",
+			wantPath:  path.Root("listen"),
+			wantInMsg: "listen.0.ip",
+		},
+		{
+			name:      "flattened device attribute",
+			fields:    vmDeviceFields,
+			attribute: "vm_device_create.attributes.mac",
+			wantPath:  path.Root("nic_mac"),
+		},
+		{
+			name:       "unmapped field",
+			fields:     vmDeviceFields,
+			attribute:  "vm_device_create.attributes.path",
+			wantInMsg:  "attributes.path",
+			onResource: true,
+		},
+		{
+			name:       "no field",
+			fields:     datasetFields,
+			attribute:  "",
+			onResource: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &truenas.APIError{Validation: truenas.ValidationErrors{{Attribute: tt.attribute, Message: "Invalid value"}}}
+
+			var diags diag.Diagnostics
+			addClientError(&diags, tt.fields, "Error", "Could not apply", err)
+			if len(diags) != 1 {
+				t.Fatalf("diagnostics = %v, want 1", diags)
+			}
+
+			withPath, ok := diags[0].(diag.DiagnosticWithPath)
+			switch {
+			case tt.onResource && ok:
+				t.Errorf("path = %v, want an error on the resource", withPath.Path())
+			case !tt.onResource && !ok:
+				t.Errorf("error on the resource, want path %v", tt.wantPath)
+			case ok && !withPath.Path().Equal(tt.wantPath):
+				t.Errorf("path = %v, want %v", withPath.Path(), tt.wantPath)
+			}
+			if tt.wantInMsg != "" && !strings.Contains(diags[0].Detail(), tt.wantInMsg) {
+				t.Errorf("detail = %q, want it to mention %s", diags[0].Detail(), tt.wantInMsg)
+			}
+		})
+	}
+}
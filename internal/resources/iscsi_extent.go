@@ -16,7 +16,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -29,7 +29,7 @@ func NewISCSIExtentResource() resource.Resource {
 }
 
 type ISCSIExtentResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type ISCSIExtentResourceModel struct {
@@ -53,6 +53,24 @@ type ISCSIExtentResourceModel struct {
 	Locked       types.Bool   `tfsdk:"locked"`
 }
 
+// iscsiExtentFields maps the fields of iscsi.extent.create and iscsi.extent.update to schema attributes
+var iscsiExtentFields = apiFields{
+	"name":            path.Root("name"),
+	"type":            path.Root("type"),
+	"disk":            path.Root("disk"),
+	"path":            path.Root("path"),
+	"filesize":        path.Root("filesize"),
+	"blocksize":       path.Root("blocksize"),
+	"pblocksize":      path.Root("pblocksize"),
+	"avail_threshold": path.Root("avail_threshold"),
+	"comment":         path.Root("comment"),
+	"insecure_tpc":    path.Root("insecure_tpc"),
+	"xen":             path.Root("xen"),
+	"rpm":             path.Root("rpm"),
+	"ro":              path.Root("ro"),
+	"enabled":         path.Root("enabled"),
+}
+
 func (r *ISCSIExtentResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_iscsi_extent"
 }
@@ -161,15 +179,17 @@ func (r *ISCSIExtentResource) Configure(ctx context.Context, req resource.Config
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *ISCSIExtentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_iscsi_extent", nil)
+
 	var plan ISCSIExtentResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -181,56 +201,32 @@ func (r *ISCSIExtentResource) Create(ctx context.Context, req resource.CreateReq
 		"name": plan.Name.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"name":    plan.Name.ValueString(),
-		"type":    plan.Type.ValueString(),
-		"enabled": plan.Enabled.ValueBool(),
-	}
-
-	if !plan.Disk.IsNull() {
-		createData["disk"] = plan.Disk.ValueString()
-	}
-	if !plan.Path.IsNull() {
-		createData["path"] = plan.Path.ValueString()
-	}
-	if !plan.Filesize.IsNull() {
-		createData["filesize"] = plan.Filesize.ValueInt64()
-	}
-	if !plan.Blocksize.IsNull() {
-		createData["blocksize"] = plan.Blocksize.ValueInt64()
-	}
-	if !plan.Pblocksize.IsNull() {
-		createData["pblocksize"] = plan.Pblocksize.ValueBool()
-	}
-	if !plan.AvailThreshold.IsNull() {
-		createData["avail_threshold"] = plan.AvailThreshold.ValueInt64()
-	}
-	if !plan.Comment.IsNull() {
-		createData["comment"] = plan.Comment.ValueString()
-	}
-	if !plan.InsecureTPC.IsNull() {
-		createData["insecure_tpc"] = plan.InsecureTPC.ValueBool()
-	}
-	if !plan.Xen.IsNull() {
-		createData["xen"] = plan.Xen.ValueBool()
-	}
-	if !plan.RPM.IsNull() {
-		createData["rpm"] = plan.RPM.ValueString()
-	}
-	if !plan.Ro.IsNull() {
-		createData["ro"] = plan.Ro.ValueBool()
-	}
-
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "iscsi.extent", createData, &result)
+	createData := truenas.ISCSIExtentRequest{
+		Name:           truenas.Ptr(plan.Name.ValueString()),
+		Type:           truenas.Ptr(plan.Type.ValueString()),
+		Enabled:        truenas.Ptr(plan.Enabled.ValueBool()),
+		Disk:           plan.Disk.ValueStringPointer(),
+		Path:           plan.Path.ValueStringPointer(),
+		Filesize:       plan.Filesize.ValueInt64Pointer(),
+		Blocksize:      plan.Blocksize.ValueInt64Pointer(),
+		Pblocksize:     plan.Pblocksize.ValueBoolPointer(),
+		AvailThreshold: plan.AvailThreshold.ValueInt64Pointer(),
+		Comment:        plan.Comment.ValueStringPointer(),
+		InsecureTPC:    plan.InsecureTPC.ValueBoolPointer(),
+		Xen:            plan.Xen.ValueBoolPointer(),
+		RPM:            plan.RPM.ValueStringPointer(),
+		Ro:             plan.Ro.ValueBoolPointer(),
+	}
+
+	var extent truenas.ISCSIExtent
+	err := r.client.Create(ctx, "iscsi.extent", createData, &extent)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating iSCSI Extent", "Could not create iSCSI extent: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiExtentFields, "Error Creating iSCSI Extent", "Could not create iSCSI extent", err)
 		return
 	}
 
-	extentID := int64(result["id"].(float64))
-	if err := r.readExtent(ctx, extentID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Extent", "Could not read iSCSI extent after creation: "+err.Error())
+	if err := r.readExtent(ctx, extent.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, iscsiExtentFields, "Error Reading iSCSI Extent", "Could not read iSCSI extent after creation", err)
 		return
 	}
 
@@ -246,12 +242,14 @@ func (r *ISCSIExtentResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_extent", state.ID)
+
 	if err := r.readExtent(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading iSCSI Extent", "Could not read iSCSI extent: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiExtentFields, "Error Reading iSCSI Extent", "Could not read iSCSI extent", err)
 		return
 	}
 
@@ -274,60 +272,57 @@ func (r *ISCSIExtentResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
-	updateData := map[string]interface{}{}
+	ctx = withAddress(ctx, "trueform_iscsi_extent", state.ID)
+
+	var updateData truenas.ISCSIExtentRequest
 
 	if !plan.Disk.Equal(state.Disk) {
-		updateData["disk"] = plan.Disk.ValueString()
+		updateData.Disk = truenas.Ptr(plan.Disk.ValueString())
 	}
 	if !plan.Path.Equal(state.Path) {
-		updateData["path"] = plan.Path.ValueString()
+		updateData.Path = truenas.Ptr(plan.Path.ValueString())
 	}
 	if !plan.Filesize.Equal(state.Filesize) {
-		updateData["filesize"] = plan.Filesize.ValueInt64()
+		updateData.Filesize = truenas.Ptr(plan.Filesize.ValueInt64())
 	}
 	if !plan.Blocksize.Equal(state.Blocksize) {
-		updateData["blocksize"] = plan.Blocksize.ValueInt64()
+		updateData.Blocksize = truenas.Ptr(plan.Blocksize.ValueInt64())
 	}
 	if !plan.Pblocksize.Equal(state.Pblocksize) {
-		updateData["pblocksize"] = plan.Pblocksize.ValueBool()
+		updateData.Pblocksize = truenas.Ptr(plan.Pblocksize.ValueBool())
 	}
 	if !plan.AvailThreshold.Equal(state.AvailThreshold) {
-		updateData["avail_threshold"] = plan.AvailThreshold.ValueInt64()
+		updateData.AvailThreshold = truenas.Ptr(plan.AvailThreshold.ValueInt64())
 	}
 	if !plan.Comment.Equal(state.Comment) {
-		if plan.Comment.IsNull() {
-			updateData["comment"] = ""
-		} else {
-			updateData["comment"] = plan.Comment.ValueString()
-		}
+		updateData.Comment = stringOrEmpty(plan.Comment)
 	}
 	if !plan.InsecureTPC.Equal(state.InsecureTPC) {
-		updateData["insecure_tpc"] = plan.InsecureTPC.ValueBool()
+		updateData.InsecureTPC = truenas.Ptr(plan.InsecureTPC.ValueBool())
 	}
 	if !plan.Xen.Equal(state.Xen) {
-		updateData["xen"] = plan.Xen.ValueBool()
+		updateData.Xen = truenas.Ptr(plan.Xen.ValueBool())
 	}
 	if !plan.RPM.Equal(state.RPM) {
-		updateData["rpm"] = plan.RPM.ValueString()
+		updateData.RPM = truenas.Ptr(plan.RPM.ValueString())
 	}
 	if !plan.Ro.Equal(state.Ro) {
-		updateData["ro"] = plan.Ro.ValueBool()
+		updateData.Ro = truenas.Ptr(plan.Ro.ValueBool())
 	}
 	if !plan.Enabled.Equal(state.Enabled) {
-		updateData["enabled"] = plan.Enabled.ValueBool()
+		updateData.Enabled = truenas.Ptr(plan.Enabled.ValueBool())
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "iscsi.extent", state.ID.ValueInt64(), updateData, &result)
+	if updateData != (truenas.ISCSIExtentRequest{}) {
+		err := r.client.Update(ctx, "iscsi.extent", state.ID.ValueInt64(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError("Error Updating iSCSI Extent", "Could not update iSCSI extent: "+err.Error())
+			addClientError(&resp.Diagnostics, iscsiExtentFields, "Error Updating iSCSI Extent", "Could not update iSCSI extent", err)
 			return
 		}
 	}
 
 	if err := r.readExtent(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Extent", "Could not read iSCSI extent after update: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiExtentFields, "Error Reading iSCSI Extent", "Could not read iSCSI extent after update", err)
 		return
 	}
 
@@ -343,9 +338,11 @@ func (r *ISCSIExtentResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_extent", state.ID)
+
 	err := r.client.Delete(ctx, "iscsi.extent", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting iSCSI Extent", "Could not delete iSCSI extent: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiExtentFields, "Error Deleting iSCSI Extent", "Could not delete iSCSI extent", err)
 		return
 	}
 }
@@ -363,60 +360,60 @@ func (r *ISCSIExtentResource) ImportState(ctx context.Context, req resource.Impo
 }
 
 func (r *ISCSIExtentResource) readExtent(ctx context.Context, id int64, model *ISCSIExtentResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "iscsi.extent", id, &result)
+	var extent truenas.ISCSIExtent
+	err := r.client.GetInstance(ctx, "iscsi.extent", id, &extent)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Name = types.StringValue(result["name"].(string))
-	model.Type = types.StringValue(result["type"].(string))
+	model.ID = types.Int64Value(extent.ID)
+	model.Name = types.StringValue(extent.Name)
+	model.Type = types.StringValue(extent.Type)
 
-	if disk, ok := result["disk"].(string); ok {
-		model.Disk = types.StringValue(disk)
+	if extent.Disk != nil {
+		model.Disk = types.StringValue(*extent.Disk)
 	}
-	if path, ok := result["path"].(string); ok {
-		model.Path = types.StringValue(path)
+	if extent.Path != nil {
+		model.Path = types.StringValue(*extent.Path)
 	}
-	if filesize, ok := result["filesize"].(float64); ok {
-		model.Filesize = types.Int64Value(int64(filesize))
+	if extent.Filesize != nil {
+		model.Filesize = types.Int64Value(int64(*extent.Filesize))
 	}
-	if blocksize, ok := result["blocksize"].(float64); ok {
-		model.Blocksize = types.Int64Value(int64(blocksize))
+	if extent.Blocksize != nil {
+		model.Blocksize = types.Int64Value(int64(*extent.Blocksize))
 	}
-	if pblocksize, ok := result["pblocksize"].(bool); ok {
-		model.Pblocksize = types.BoolValue(pblocksize)
+	if extent.Pblocksize != nil {
+		model.Pblocksize = types.BoolValue(*extent.Pblocksize)
 	}
-	if availThreshold, ok := result["avail_threshold"].(float64); ok {
-		model.AvailThreshold = types.Int64Value(int64(availThreshold))
+	if extent.AvailThreshold != nil {
+		model.AvailThreshold = types.Int64Value(int64(*extent.AvailThreshold))
 	}
-	if comment, ok := result["comment"].(string); ok {
-		model.Comment = types.StringValue(comment)
+	if extent.Comment != nil {
+		model.Comment = types.StringValue(*extent.Comment)
 	}
-	if insecureTPC, ok := result["insecure_tpc"].(bool); ok {
-		model.InsecureTPC = types.BoolValue(insecureTPC)
+	if extent.InsecureTPC != nil {
+		model.InsecureTPC = types.BoolValue(*extent.InsecureTPC)
 	}
-	if xen, ok := result["xen"].(bool); ok {
-		model.Xen = types.BoolValue(xen)
+	if extent.Xen != nil {
+		model.Xen = types.BoolValue(*extent.Xen)
 	}
-	if rpm, ok := result["rpm"].(string); ok {
-		model.RPM = types.StringValue(rpm)
+	if extent.RPM != nil {
+		model.RPM = types.StringValue(*extent.RPM)
 	}
-	if ro, ok := result["ro"].(bool); ok {
-		model.Ro = types.BoolValue(ro)
+	if extent.Ro != nil {
+		model.Ro = types.BoolValue(*extent.Ro)
 	}
-	if enabled, ok := result["enabled"].(bool); ok {
-		model.Enabled = types.BoolValue(enabled)
+	if extent.Enabled != nil {
+		model.Enabled = types.BoolValue(*extent.Enabled)
 	}
-	if serial, ok := result["serial"].(string); ok {
-		model.Serial = types.StringValue(serial)
+	if extent.Serial != nil {
+		model.Serial = types.StringValue(*extent.Serial)
 	}
-	if naa, ok := result["naa"].(string); ok {
-		model.NAA = types.StringValue(naa)
+	if extent.NAA != nil {
+		model.NAA = types.StringValue(*extent.NAA)
 	}
-	if locked, ok := result["locked"].(bool); ok {
-		model.Locked = types.BoolValue(locked)
+	if extent.Locked != nil {
+		model.Locked = types.BoolValue(*extent.Locked)
 	}
 
 	return nil
@@ -0,0 +1,91 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadExtent(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    ISCSIExtentResourceModel
+		wantErr bool
+	}{
+		{
+			name: "zvol extent",
+			payload: `{
+				"id": 1, "name": "vmstore", "serial": "ac1f6b2f4d1e", "type": "DISK", "path": "zvol/tank/iscsi/vmstore",
+				"filesize": "0", "blocksize": 512, "pblocksize": false, "avail_threshold": null, "comment": "ESXi datastore",
+				"naa": "0x6589cfc000000a1b2c3d4e5f60718293", "insecure_tpc": true, "xen": false, "rpm": "SSD",
+				"ro": false, "enabled": true, "vendor": "TrueNAS", "disk": "zvol/tank/iscsi/vmstore", "locked": false
+			}`,
+			want: ISCSIExtentResourceModel{
+				ID:          types.Int64Value(1),
+				Name:        types.StringValue("vmstore"),
+				Type:        types.StringValue("DISK"),
+				Disk:        types.StringValue("zvol/tank/iscsi/vmstore"),
+				Path:        types.StringValue("zvol/tank/iscsi/vmstore"),
+				Filesize:    types.Int64Value(0),
+				Blocksize:   types.Int64Value(512),
+				Pblocksize:  types.BoolValue(false),
+				Comment:     types.StringValue("ESXi datastore"),
+				InsecureTPC: types.BoolValue(true),
+				Xen:         types.BoolValue(false),
+				RPM:         types.StringValue("SSD"),
+				Ro:          types.BoolValue(false),
+				Enabled:     types.BoolValue(true),
+				Serial:      types.StringValue("ac1f6b2f4d1e"),
+				NAA:         types.StringValue("0x6589cfc000000a1b2c3d4e5f60718293"),
+				Locked:      types.BoolValue(false),
+			},
+		},
+		{
+			name:    "file extent",
+			payload: `{"id": 1, "name": "scratch", "type": "FILE", "path": "/mnt/tank/iscsi/scratch.img", "filesize": 10737418240, "disk": null, "avail_threshold": 80}`,
+			want: ISCSIExtentResourceModel{
+				ID:             types.Int64Value(1),
+				Name:           types.StringValue("scratch"),
+				Type:           types.StringValue("FILE"),
+				Path:           types.StringValue("/mnt/tank/iscsi/scratch.img"),
+				Filesize:       types.Int64Value(10737418240),
+				AvailThreshold: types.Int64Value(80),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed blocksize",
+			payload: `{"id": 1, "name": "vmstore", "type": "DISK", "blocksize": true}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ISCSIExtentResource{client: newFakeAPI("iscsi.extent", 1, tt.payload)}
+
+			var model ISCSIExtentResourceModel
+			err := r.readExtent(context.Background(), 1, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readExtent() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readExtent() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readExtent() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
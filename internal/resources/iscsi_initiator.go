@@ -11,7 +11,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -24,7 +24,7 @@ func NewISCSIInitiatorResource() resource.Resource {
 }
 
 type ISCSIInitiatorResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type ISCSIInitiatorResourceModel struct {
@@ -34,6 +34,13 @@ type ISCSIInitiatorResourceModel struct {
 	AuthNetwork types.List  `tfsdk:"auth_network"`
 }
 
+// iscsiInitiatorFields maps the fields of iscsi.initiator.create and iscsi.initiator.update to schema attributes
+var iscsiInitiatorFields = apiFields{
+	"comment":      path.Root("comment"),
+	"initiators":   path.Root("initiators"),
+	"auth_network": path.Root("auth_network"),
+}
+
 func (r *ISCSIInitiatorResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_iscsi_initiator"
 }
@@ -68,15 +75,17 @@ func (r *ISCSIInitiatorResource) Configure(ctx context.Context, req resource.Con
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *ISCSIInitiatorResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_iscsi_initiator", nil)
+
 	var plan ISCSIInitiatorResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -86,17 +95,16 @@ func (r *ISCSIInitiatorResource) Create(ctx context.Context, req resource.Create
 
 	tflog.Debug(ctx, "Creating iSCSI initiator group")
 
-	createData := map[string]interface{}{}
-
-	if !plan.Comment.IsNull() {
-		createData["comment"] = plan.Comment.ValueString()
+	createData := truenas.ISCSIInitiatorRequest{
+		Comment: plan.Comment.ValueStringPointer(),
 	}
+
 	if !plan.Initiators.IsNull() {
 		var initiators []string
 		diags = plan.Initiators.ElementsAs(ctx, &initiators, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["initiators"] = initiators
+			createData.Initiators = &initiators
 		}
 	}
 	if !plan.AuthNetwork.IsNull() {
@@ -104,20 +112,19 @@ func (r *ISCSIInitiatorResource) Create(ctx context.Context, req resource.Create
 		diags = plan.AuthNetwork.ElementsAs(ctx, &authNetwork, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["auth_network"] = authNetwork
+			createData.AuthNetwork = &authNetwork
 		}
 	}
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "iscsi.initiator", createData, &result)
+	var initiator truenas.ISCSIInitiator
+	err := r.client.Create(ctx, "iscsi.initiator", createData, &initiator)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating iSCSI Initiator", "Could not create iSCSI initiator: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiInitiatorFields, "Error Creating iSCSI Initiator", "Could not create iSCSI initiator", err)
 		return
 	}
 
-	initiatorID := int64(result["id"].(float64))
-	if err := r.readInitiator(ctx, initiatorID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Initiator", "Could not read iSCSI initiator after creation: "+err.Error())
+	if err := r.readInitiator(ctx, initiator.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, iscsiInitiatorFields, "Error Reading iSCSI Initiator", "Could not read iSCSI initiator after creation", err)
 		return
 	}
 
@@ -133,12 +140,14 @@ func (r *ISCSIInitiatorResource) Read(ctx context.Context, req resource.ReadRequ
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_initiator", state.ID)
+
 	if err := r.readInitiator(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading iSCSI Initiator", "Could not read iSCSI initiator: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiInitiatorFields, "Error Reading iSCSI Initiator", "Could not read iSCSI initiator", err)
 		return
 	}
 
@@ -161,14 +170,12 @@ func (r *ISCSIInitiatorResource) Update(ctx context.Context, req resource.Update
 		return
 	}
 
-	updateData := map[string]interface{}{}
+	ctx = withAddress(ctx, "trueform_iscsi_initiator", state.ID)
+
+	var updateData truenas.ISCSIInitiatorRequest
 
 	if !plan.Comment.Equal(state.Comment) {
-		if plan.Comment.IsNull() {
-			updateData["comment"] = ""
-		} else {
-			updateData["comment"] = plan.Comment.ValueString()
-		}
+		updateData.Comment = stringOrEmpty(plan.Comment)
 	}
 	if !plan.Initiators.Equal(state.Initiators) {
 		var initiators []string
@@ -176,7 +183,7 @@ func (r *ISCSIInitiatorResource) Update(ctx context.Context, req resource.Update
 			diags = plan.Initiators.ElementsAs(ctx, &initiators, false)
 			resp.Diagnostics.Append(diags...)
 		}
-		updateData["initiators"] = initiators
+		updateData.Initiators = &initiators
 	}
 	if !plan.AuthNetwork.Equal(state.AuthNetwork) {
 		var authNetwork []string
@@ -184,20 +191,19 @@ func (r *ISCSIInitiatorResource) Update(ctx context.Context, req resource.Update
 			diags = plan.AuthNetwork.ElementsAs(ctx, &authNetwork, false)
 			resp.Diagnostics.Append(diags...)
 		}
-		updateData["auth_network"] = authNetwork
+		updateData.AuthNetwork = &authNetwork
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "iscsi.initiator", state.ID.ValueInt64(), updateData, &result)
+	if updateData != (truenas.ISCSIInitiatorRequest{}) {
+		err := r.client.Update(ctx, "iscsi.initiator", state.ID.ValueInt64(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError("Error Updating iSCSI Initiator", "Could not update iSCSI initiator: "+err.Error())
+			addClientError(&resp.Diagnostics, iscsiInitiatorFields, "Error Updating iSCSI Initiator", "Could not update iSCSI initiator", err)
 			return
 		}
 	}
 
 	if err := r.readInitiator(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Initiator", "Could not read iSCSI initiator after update: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiInitiatorFields, "Error Reading iSCSI Initiator", "Could not read iSCSI initiator after update", err)
 		return
 	}
 
@@ -213,9 +219,11 @@ func (r *ISCSIInitiatorResource) Delete(ctx context.Context, req resource.Delete
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_initiator", state.ID)
+
 	err := r.client.Delete(ctx, "iscsi.initiator", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting iSCSI Initiator", "Could not delete iSCSI initiator: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiInitiatorFields, "Error Deleting iSCSI Initiator", "Could not delete iSCSI initiator", err)
 		return
 	}
 }
@@ -233,33 +241,25 @@ func (r *ISCSIInitiatorResource) ImportState(ctx context.Context, req resource.I
 }
 
 func (r *ISCSIInitiatorResource) readInitiator(ctx context.Context, id int64, model *ISCSIInitiatorResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "iscsi.initiator", id, &result)
+	var initiator truenas.ISCSIInitiator
+	err := r.client.GetInstance(ctx, "iscsi.initiator", id, &initiator)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
+	model.ID = types.Int64Value(initiator.ID)
 
-	if comment, ok := result["comment"].(string); ok {
-		model.Comment = types.StringValue(comment)
+	if initiator.Comment != nil {
+		model.Comment = types.StringValue(*initiator.Comment)
 	}
-	if initiators, ok := result["initiators"].([]interface{}); ok {
-		initiatorList := make([]string, len(initiators))
-		for i, init := range initiators {
-			initiatorList[i] = init.(string)
-		}
-		initiatorValues, diags := types.ListValueFrom(ctx, types.StringType, initiatorList)
+	if initiator.Initiators != nil {
+		initiatorValues, diags := types.ListValueFrom(ctx, types.StringType, initiator.Initiators)
 		if !diags.HasError() {
 			model.Initiators = initiatorValues
 		}
 	}
-	if authNetwork, ok := result["auth_network"].([]interface{}); ok {
-		networkList := make([]string, len(authNetwork))
-		for i, net := range authNetwork {
-			networkList[i] = net.(string)
-		}
-		networkValues, diags := types.ListValueFrom(ctx, types.StringType, networkList)
+	if initiator.AuthNetwork != nil {
+		networkValues, diags := types.ListValueFrom(ctx, types.StringType, initiator.AuthNetwork)
 		if !diags.HasError() {
 			model.AuthNetwork = networkValues
 		}
@@ -0,0 +1,70 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadInitiator(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    ISCSIInitiatorResourceModel
+		wantErr bool
+	}{
+		{
+			name:    "restricted group",
+			payload: `{"id": 2, "initiators": ["iqn.1998-01.com.vmware:esxi01"], "auth_network": ["192.168.10.0/24"], "comment": "ESXi hosts"}`,
+			want: ISCSIInitiatorResourceModel{
+				ID:          types.Int64Value(2),
+				Comment:     types.StringValue("ESXi hosts"),
+				Initiators:  stringList("iqn.1998-01.com.vmware:esxi01"),
+				AuthNetwork: stringList("192.168.10.0/24"),
+			},
+		},
+		{
+			name:    "allow all",
+			payload: `{"id": 2, "initiators": [], "comment": ""}`,
+			want: ISCSIInitiatorResourceModel{
+				ID:         types.Int64Value(2),
+				Comment:    types.StringValue(""),
+				Initiators: stringList(),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed initiators",
+			payload: `{"id": 2, "initiators": "iqn.1998-01.com.vmware:esxi01"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ISCSIInitiatorResource{client: newFakeAPI("iscsi.initiator", 2, tt.payload)}
+
+			var model ISCSIInitiatorResourceModel
+			err := r.readInitiator(context.Background(), 2, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readInitiator() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readInitiator() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readInitiator() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
@@ -13,7 +13,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -26,7 +26,7 @@ func NewISCSIPortalResource() resource.Resource {
 }
 
 type ISCSIPortalResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type ISCSIPortalResourceModel struct {
@@ -37,6 +37,14 @@ type ISCSIPortalResourceModel struct {
 	Listen        types.List   `tfsdk:"listen"`
 }
 
+// iscsiPortalFields maps the fields of iscsi.portal.create and iscsi.portal.update to schema attributes
+var iscsiPortalFields = apiFields{
+	"listen":               path.Root("listen"),
+	"comment":              path.Root("comment"),
+	"discovery_authmethod": path.Root("discovery_authmethod"),
+	"discovery_authgroup":  path.Root("discovery_authgroup"),
+}
+
 type PortalListen struct {
 	IP   types.String `tfsdk:"ip"`
 	Port types.Int64  `tfsdk:"port"`
@@ -92,15 +100,17 @@ func (r *ISCSIPortalResource) Configure(ctx context.Context, req resource.Config
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *ISCSIPortalResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_iscsi_portal", nil)
+
 	var plan ISCSIPortalResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -118,37 +128,27 @@ func (r *ISCSIPortalResource) Create(ctx context.Context, req resource.CreateReq
 	}
 
 	// TrueNAS Scale 25 only accepts IP in listen configuration, port is implicit (3260)
-	listen := make([]map[string]interface{}, len(listenItems))
+	listen := make([]truenas.ISCSIPortalListen, len(listenItems))
 	for i, item := range listenItems {
-		listen[i] = map[string]interface{}{
-			"ip": item.IP.ValueString(),
-		}
+		listen[i] = truenas.ISCSIPortalListen{IP: item.IP.ValueString()}
 	}
 
-	createData := map[string]interface{}{
-		"listen": listen,
+	createData := truenas.ISCSIPortalRequest{
+		Listen:              listen,
+		Comment:             plan.Comment.ValueStringPointer(),
+		DiscoveryAuthmethod: plan.DiscoveryAuth.ValueStringPointer(),
+		DiscoveryAuthgroup:  plan.DiscoveryGroup.ValueInt64Pointer(),
 	}
 
-	if !plan.Comment.IsNull() {
-		createData["comment"] = plan.Comment.ValueString()
-	}
-	if !plan.DiscoveryAuth.IsNull() {
-		createData["discovery_authmethod"] = plan.DiscoveryAuth.ValueString()
-	}
-	if !plan.DiscoveryGroup.IsNull() {
-		createData["discovery_authgroup"] = plan.DiscoveryGroup.ValueInt64()
-	}
-
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "iscsi.portal", createData, &result)
+	var portal truenas.ISCSIPortal
+	err := r.client.Create(ctx, "iscsi.portal", createData, &portal)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating iSCSI Portal", "Could not create iSCSI portal: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiPortalFields, "Error Creating iSCSI Portal", "Could not create iSCSI portal", err)
 		return
 	}
 
-	portalID := int64(result["id"].(float64))
-	if err := r.readPortal(ctx, portalID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Portal", "Could not read iSCSI portal after creation: "+err.Error())
+	if err := r.readPortal(ctx, portal.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, iscsiPortalFields, "Error Reading iSCSI Portal", "Could not read iSCSI portal after creation", err)
 		return
 	}
 
@@ -164,12 +164,14 @@ func (r *ISCSIPortalResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_portal", state.ID)
+
 	if err := r.readPortal(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading iSCSI Portal", "Could not read iSCSI portal: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiPortalFields, "Error Reading iSCSI Portal", "Could not read iSCSI portal", err)
 		return
 	}
 
@@ -192,6 +194,8 @@ func (r *ISCSIPortalResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_portal", state.ID)
+
 	var listenItems []PortalListen
 	diags = plan.Listen.ElementsAs(ctx, &listenItems, false)
 	resp.Diagnostics.Append(diags...)
@@ -200,36 +204,26 @@ func (r *ISCSIPortalResource) Update(ctx context.Context, req resource.UpdateReq
 	}
 
 	// TrueNAS Scale 25 only accepts IP in listen configuration, port is implicit (3260)
-	listen := make([]map[string]interface{}, len(listenItems))
+	listen := make([]truenas.ISCSIPortalListen, len(listenItems))
 	for i, item := range listenItems {
-		listen[i] = map[string]interface{}{
-			"ip": item.IP.ValueString(),
-		}
-	}
-
-	updateData := map[string]interface{}{
-		"listen": listen,
+		listen[i] = truenas.ISCSIPortalListen{IP: item.IP.ValueString()}
 	}
 
-	if !plan.Comment.IsNull() {
-		updateData["comment"] = plan.Comment.ValueString()
-	}
-	if !plan.DiscoveryAuth.IsNull() {
-		updateData["discovery_authmethod"] = plan.DiscoveryAuth.ValueString()
-	}
-	if !plan.DiscoveryGroup.IsNull() {
-		updateData["discovery_authgroup"] = plan.DiscoveryGroup.ValueInt64()
+	updateData := truenas.ISCSIPortalRequest{
+		Listen:              listen,
+		Comment:             plan.Comment.ValueStringPointer(),
+		DiscoveryAuthmethod: plan.DiscoveryAuth.ValueStringPointer(),
+		DiscoveryAuthgroup:  plan.DiscoveryGroup.ValueInt64Pointer(),
 	}
 
-	var result map[string]interface{}
-	err := r.client.Update(ctx, "iscsi.portal", state.ID.ValueInt64(), updateData, &result)
+	err := r.client.Update(ctx, "iscsi.portal", state.ID.ValueInt64(), updateData, nil)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Updating iSCSI Portal", "Could not update iSCSI portal: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiPortalFields, "Error Updating iSCSI Portal", "Could not update iSCSI portal", err)
 		return
 	}
 
 	if err := r.readPortal(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Portal", "Could not read iSCSI portal after update: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiPortalFields, "Error Reading iSCSI Portal", "Could not read iSCSI portal after update", err)
 		return
 	}
 
@@ -245,9 +239,11 @@ func (r *ISCSIPortalResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_portal", state.ID)
+
 	err := r.client.Delete(ctx, "iscsi.portal", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting iSCSI Portal", "Could not delete iSCSI portal: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiPortalFields, "Error Deleting iSCSI Portal", "Could not delete iSCSI portal", err)
 		return
 	}
 }
@@ -265,31 +261,34 @@ func (r *ISCSIPortalResource) ImportState(ctx context.Context, req resource.Impo
 }
 
 func (r *ISCSIPortalResource) readPortal(ctx context.Context, id int64, model *ISCSIPortalResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "iscsi.portal", id, &result)
+	var portal truenas.ISCSIPortal
+	err := r.client.GetInstance(ctx, "iscsi.portal", id, &portal)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	if comment, ok := result["comment"].(string); ok {
-		model.Comment = types.StringValue(comment)
+	model.ID = types.Int64Value(portal.ID)
+	if portal.Comment != nil {
+		model.Comment = types.StringValue(*portal.Comment)
 	}
-	if discoveryAuth, ok := result["discovery_authmethod"].(string); ok {
-		model.DiscoveryAuth = types.StringValue(discoveryAuth)
+	if portal.DiscoveryAuthmethod != nil {
+		model.DiscoveryAuth = types.StringValue(*portal.DiscoveryAuthmethod)
 	}
-	if discoveryGroup, ok := result["discovery_authgroup"].(float64); ok {
-		model.DiscoveryGroup = types.Int64Value(int64(discoveryGroup))
+	if portal.DiscoveryAuthgroup != nil {
+		model.DiscoveryGroup = types.Int64Value(int64(*portal.DiscoveryAuthgroup))
 	}
 
-	if listenList, ok := result["listen"].([]interface{}); ok {
-		listenItems := make([]PortalListen, len(listenList))
-		for i, item := range listenList {
-			if listenMap, ok := item.(map[string]interface{}); ok {
-				listenItems[i] = PortalListen{
-					IP:   types.StringValue(listenMap["ip"].(string)),
-					Port: types.Int64Value(int64(listenMap["port"].(float64))),
-				}
+	if portal.Listen != nil {
+		listenItems := make([]PortalListen, len(portal.Listen))
+		for i, item := range portal.Listen {
+			port := item.Port
+			if port == 0 {
+				// Releases that only take the IP leave the port out
+				port = 3260
+			}
+			listenItems[i] = PortalListen{
+				IP:   types.StringValue(item.IP),
+				Port: types.Int64Value(port),
 			}
 		}
 		listenValue, d := types.ListValueFrom(ctx, types.ObjectType{
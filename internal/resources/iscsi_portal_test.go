@@ -0,0 +1,83 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadPortal(t *testing.T) {
+	listenType := types.ObjectType{AttrTypes: map[string]attr.Type{
+		"ip":   types.StringType,
+		"port": types.Int64Type,
+	}}
+	listen := func(ip string, port int64) attr.Value {
+		return types.ObjectValueMust(listenType.AttrTypes, map[string]attr.Value{
+			"ip":   types.StringValue(ip),
+			"port": types.Int64Value(port),
+		})
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    ISCSIPortalResourceModel
+		wantErr bool
+	}{
+		{
+			name:    "portal with port",
+			payload: `{"id": 1, "tag": 1, "comment": "Storage network", "discovery_authmethod": "CHAP", "discovery_authgroup": 3, "listen": [{"ip": "10.0.0.5", "port": 3261}]}`,
+			want: ISCSIPortalResourceModel{
+				ID:             types.Int64Value(1),
+				Comment:        types.StringValue("Storage network"),
+				DiscoveryAuth:  types.StringValue("CHAP"),
+				DiscoveryGroup: types.Int64Value(3),
+				Listen:         types.ListValueMust(listenType, []attr.Value{listen("10.0.0.5", 3261)}),
+			},
+		},
+		{
+			name:    "ip only listen",
+			payload: `{"id": 1, "tag": 1, "comment": "", "listen": [{"ip": "0.0.0.0"}, {"ip": "::"}]}`,
+			want: ISCSIPortalResourceModel{
+				ID:      types.Int64Value(1),
+				Comment: types.StringValue(""),
+				Listen:  types.ListValueMust(listenType, []attr.Value{listen("0.0.0.0", 3260), listen("::", 3260)}),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed listen",
+			payload: `{"id": 1, "listen": ["0.0.0.0"]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ISCSIPortalResource{client: newFakeAPI("iscsi.portal", 1, tt.payload)}
+
+			var model ISCSIPortalResourceModel
+			err := r.readPortal(context.Background(), 1, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readPortal() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readPortal() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPortal() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
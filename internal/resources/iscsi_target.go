@@ -14,7 +14,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -27,7 +27,7 @@ func NewISCSITargetResource() resource.Resource {
 }
 
 type ISCSITargetResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type ISCSITargetResourceModel struct {
@@ -38,6 +38,14 @@ type ISCSITargetResourceModel struct {
 	Groups types.List   `tfsdk:"groups"`
 }
 
+// iscsiTargetFields maps the fields of iscsi.target.create and iscsi.target.update to schema attributes
+var iscsiTargetFields = apiFields{
+	"name":   path.Root("name"),
+	"alias":  path.Root("alias"),
+	"mode":   path.Root("mode"),
+	"groups": path.Root("groups"),
+}
+
 type TargetGroup struct {
 	Portal         types.Int64  `tfsdk:"portal"`
 	Initiator      types.Int64  `tfsdk:"initiator"`
@@ -106,15 +114,17 @@ func (r *ISCSITargetResource) Configure(ctx context.Context, req resource.Config
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *ISCSITargetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_iscsi_target", nil)
+
 	var plan ISCSITargetResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -126,13 +136,10 @@ func (r *ISCSITargetResource) Create(ctx context.Context, req resource.CreateReq
 		"name": plan.Name.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"mode": plan.Mode.ValueString(),
-	}
-
-	if !plan.Alias.IsNull() {
-		createData["alias"] = plan.Alias.ValueString()
+	createData := truenas.ISCSITargetRequest{
+		Name:  truenas.Ptr(plan.Name.ValueString()),
+		Mode:  truenas.Ptr(plan.Mode.ValueString()),
+		Alias: plan.Alias.ValueStringPointer(),
 	}
 
 	if !plan.Groups.IsNull() {
@@ -143,34 +150,27 @@ func (r *ISCSITargetResource) Create(ctx context.Context, req resource.CreateReq
 			return
 		}
 
-		groups := make([]map[string]interface{}, len(groupItems))
+		groups := make([]truenas.ISCSITargetGroup, len(groupItems))
 		for i, item := range groupItems {
-			groups[i] = map[string]interface{}{
-				"portal": item.Portal.ValueInt64(),
-			}
-			if !item.Initiator.IsNull() {
-				groups[i]["initiator"] = item.Initiator.ValueInt64()
-			}
-			if !item.AuthMethod.IsNull() {
-				groups[i]["authmethod"] = item.AuthMethod.ValueString()
-			}
-			if !item.Auth.IsNull() {
-				groups[i]["auth"] = item.Auth.ValueInt64()
+			groups[i] = truenas.ISCSITargetGroup{
+				Portal:     item.Portal.ValueInt64(),
+				Initiator:  item.Initiator.ValueInt64Pointer(),
+				Authmethod: item.AuthMethod.ValueStringPointer(),
+				Auth:       item.Auth.ValueInt64Pointer(),
 			}
 		}
-		createData["groups"] = groups
+		createData.Groups = &groups
 	}
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "iscsi.target", createData, &result)
+	var target truenas.ISCSITarget
+	err := r.client.Create(ctx, "iscsi.target", createData, &target)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating iSCSI Target", "Could not create iSCSI target: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiTargetFields, "Error Creating iSCSI Target", "Could not create iSCSI target", err)
 		return
 	}
 
-	targetID := int64(result["id"].(float64))
-	if err := r.readTarget(ctx, targetID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Target", "Could not read iSCSI target after creation: "+err.Error())
+	if err := r.readTarget(ctx, target.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, iscsiTargetFields, "Error Reading iSCSI Target", "Could not read iSCSI target after creation", err)
 		return
 	}
 
@@ -186,12 +186,14 @@ func (r *ISCSITargetResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_target", state.ID)
+
 	if err := r.readTarget(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading iSCSI Target", "Could not read iSCSI target: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiTargetFields, "Error Reading iSCSI Target", "Could not read iSCSI target", err)
 		return
 	}
 
@@ -214,17 +216,15 @@ func (r *ISCSITargetResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
-	updateData := map[string]interface{}{}
+	ctx = withAddress(ctx, "trueform_iscsi_target", state.ID)
+
+	var updateData truenas.ISCSITargetRequest
 
 	if !plan.Alias.Equal(state.Alias) {
-		if plan.Alias.IsNull() {
-			updateData["alias"] = ""
-		} else {
-			updateData["alias"] = plan.Alias.ValueString()
-		}
+		updateData.Alias = stringOrEmpty(plan.Alias)
 	}
 	if !plan.Mode.Equal(state.Mode) {
-		updateData["mode"] = plan.Mode.ValueString()
+		updateData.Mode = truenas.Ptr(plan.Mode.ValueString())
 	}
 
 	if !plan.Groups.Equal(state.Groups) {
@@ -234,35 +234,28 @@ func (r *ISCSITargetResource) Update(ctx context.Context, req resource.UpdateReq
 			resp.Diagnostics.Append(diags...)
 		}
 
-		groups := make([]map[string]interface{}, len(groupItems))
+		groups := make([]truenas.ISCSITargetGroup, len(groupItems))
 		for i, item := range groupItems {
-			groups[i] = map[string]interface{}{
-				"portal": item.Portal.ValueInt64(),
-			}
-			if !item.Initiator.IsNull() {
-				groups[i]["initiator"] = item.Initiator.ValueInt64()
-			}
-			if !item.AuthMethod.IsNull() {
-				groups[i]["authmethod"] = item.AuthMethod.ValueString()
-			}
-			if !item.Auth.IsNull() {
-				groups[i]["auth"] = item.Auth.ValueInt64()
+			groups[i] = truenas.ISCSITargetGroup{
+				Portal:     item.Portal.ValueInt64(),
+				Initiator:  item.Initiator.ValueInt64Pointer(),
+				Authmethod: item.AuthMethod.ValueStringPointer(),
+				Auth:       item.Auth.ValueInt64Pointer(),
 			}
 		}
-		updateData["groups"] = groups
+		updateData.Groups = &groups
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "iscsi.target", state.ID.ValueInt64(), updateData, &result)
+	if updateData != (truenas.ISCSITargetRequest{}) {
+		err := r.client.Update(ctx, "iscsi.target", state.ID.ValueInt64(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError("Error Updating iSCSI Target", "Could not update iSCSI target: "+err.Error())
+			addClientError(&resp.Diagnostics, iscsiTargetFields, "Error Updating iSCSI Target", "Could not update iSCSI target", err)
 			return
 		}
 	}
 
 	if err := r.readTarget(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Target", "Could not read iSCSI target after update: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiTargetFields, "Error Reading iSCSI Target", "Could not read iSCSI target after update", err)
 		return
 	}
 
@@ -278,9 +271,11 @@ func (r *ISCSITargetResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_target", state.ID)
+
 	err := r.client.Delete(ctx, "iscsi.target", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting iSCSI Target", "Could not delete iSCSI target: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiTargetFields, "Error Deleting iSCSI Target", "Could not delete iSCSI target", err)
 		return
 	}
 }
@@ -298,20 +293,20 @@ func (r *ISCSITargetResource) ImportState(ctx context.Context, req resource.Impo
 }
 
 func (r *ISCSITargetResource) readTarget(ctx context.Context, id int64, model *ISCSITargetResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "iscsi.target", id, &result)
+	var target truenas.ISCSITarget
+	err := r.client.GetInstance(ctx, "iscsi.target", id, &target)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Name = types.StringValue(result["name"].(string))
+	model.ID = types.Int64Value(target.ID)
+	model.Name = types.StringValue(target.Name)
 
-	if alias, ok := result["alias"].(string); ok {
-		model.Alias = types.StringValue(alias)
+	if target.Alias != nil {
+		model.Alias = types.StringValue(*target.Alias)
 	}
-	if mode, ok := result["mode"].(string); ok {
-		model.Mode = types.StringValue(mode)
+	if target.Mode != nil {
+		model.Mode = types.StringValue(*target.Mode)
 	}
 
 	return nil
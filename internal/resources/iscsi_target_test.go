@@ -0,0 +1,70 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    ISCSITargetResourceModel
+		wantErr bool
+	}{
+		{
+			name:    "target with alias",
+			payload: `{"id": 3, "name": "vmstore", "alias": "ESXi datastore", "mode": "ISCSI", "groups": [{"portal": 1, "initiator": 2, "authmethod": "NONE", "auth": null}], "auth_networks": []}`,
+			want: ISCSITargetResourceModel{
+				ID:    types.Int64Value(3),
+				Name:  types.StringValue("vmstore"),
+				Alias: types.StringValue("ESXi datastore"),
+				Mode:  types.StringValue("ISCSI"),
+			},
+		},
+		{
+			name:    "target without alias",
+			payload: `{"id": 3, "name": "vmstore", "alias": null, "mode": "ISCSI", "groups": []}`,
+			want: ISCSITargetResourceModel{
+				ID:   types.Int64Value(3),
+				Name: types.StringValue("vmstore"),
+				Mode: types.StringValue("ISCSI"),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed groups",
+			payload: `{"id": 3, "name": "vmstore", "groups": [{"portal": "1"}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ISCSITargetResource{client: newFakeAPI("iscsi.target", 3, tt.payload)}
+
+			var model ISCSITargetResourceModel
+			err := r.readTarget(context.Background(), 3, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readTarget() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readTarget() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readTarget() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
@@ -12,7 +12,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -25,7 +25,7 @@ func NewISCSITargetExtentResource() resource.Resource {
 }
 
 type ISCSITargetExtentResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type ISCSITargetExtentResourceModel struct {
@@ -35,6 +35,13 @@ type ISCSITargetExtentResourceModel struct {
 	LunID  types.Int64 `tfsdk:"lunid"`
 }
 
+// iscsiTargetExtentFields maps the fields of iscsi.targetextent.create and iscsi.targetextent.update to schema attributes
+var iscsiTargetExtentFields = apiFields{
+	"target": path.Root("target"),
+	"extent": path.Root("extent"),
+	"lunid":  path.Root("lunid"),
+}
+
 func (r *ISCSITargetExtentResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_iscsi_targetextent"
 }
@@ -69,15 +76,17 @@ func (r *ISCSITargetExtentResource) Configure(ctx context.Context, req resource.
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *ISCSITargetExtentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_iscsi_targetextent", nil)
+
 	var plan ISCSITargetExtentResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -90,22 +99,27 @@ func (r *ISCSITargetExtentResource) Create(ctx context.Context, req resource.Cre
 		"extent": plan.Extent.ValueInt64(),
 	})
 
-	createData := map[string]interface{}{
-		"target": plan.Target.ValueInt64(),
-		"extent": plan.Extent.ValueInt64(),
-		"lunid":  plan.LunID.ValueInt64(),
+	createData := truenas.ISCSITargetExtentRequest{
+		Target: truenas.Ptr(plan.Target.ValueInt64()),
+		Extent: truenas.Ptr(plan.Extent.ValueInt64()),
+		LunID:  truenas.Ptr(plan.LunID.ValueInt64()),
 	}
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "iscsi.targetextent", createData, &result)
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, iscsiTargetLockKey(plan.Target.ValueInt64()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
+	var mapping truenas.ISCSITargetExtent
+	err := r.client.Create(ctx, "iscsi.targetextent", createData, &mapping)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating iSCSI Target-Extent", "Could not create iSCSI target-extent mapping: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiTargetExtentFields, "Error Creating iSCSI Target-Extent", "Could not create iSCSI target-extent mapping", err)
 		return
 	}
 
-	mappingID := int64(result["id"].(float64))
-	if err := r.readTargetExtent(ctx, mappingID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Target-Extent", "Could not read iSCSI target-extent mapping after creation: "+err.Error())
+	if err := r.readTargetExtent(ctx, mapping.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, iscsiTargetExtentFields, "Error Reading iSCSI Target-Extent", "Could not read iSCSI target-extent mapping after creation", err)
 		return
 	}
 
@@ -121,12 +135,14 @@ func (r *ISCSITargetExtentResource) Read(ctx context.Context, req resource.ReadR
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_targetextent", state.ID)
+
 	if err := r.readTargetExtent(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading iSCSI Target-Extent", "Could not read iSCSI target-extent mapping: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiTargetExtentFields, "Error Reading iSCSI Target-Extent", "Could not read iSCSI target-extent mapping", err)
 		return
 	}
 
@@ -149,29 +165,36 @@ func (r *ISCSITargetExtentResource) Update(ctx context.Context, req resource.Upd
 		return
 	}
 
-	updateData := map[string]interface{}{}
+	ctx = withAddress(ctx, "trueform_iscsi_targetextent", state.ID)
+
+	var updateData truenas.ISCSITargetExtentRequest
 
 	if !plan.Target.Equal(state.Target) {
-		updateData["target"] = plan.Target.ValueInt64()
+		updateData.Target = truenas.Ptr(plan.Target.ValueInt64())
 	}
 	if !plan.Extent.Equal(state.Extent) {
-		updateData["extent"] = plan.Extent.ValueInt64()
+		updateData.Extent = truenas.Ptr(plan.Extent.ValueInt64())
 	}
 	if !plan.LunID.Equal(state.LunID) {
-		updateData["lunid"] = plan.LunID.ValueInt64()
+		updateData.LunID = truenas.Ptr(plan.LunID.ValueInt64())
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "iscsi.targetextent", state.ID.ValueInt64(), updateData, &result)
+	if updateData != (truenas.ISCSITargetExtentRequest{}) {
+		unlock := lockParent(ctx, r.client, &resp.Diagnostics, iscsiTargetLockKey(state.Target.ValueInt64()))
+		if unlock == nil {
+			return
+		}
+		defer unlock()
+
+		err := r.client.Update(ctx, "iscsi.targetextent", state.ID.ValueInt64(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError("Error Updating iSCSI Target-Extent", "Could not update iSCSI target-extent mapping: "+err.Error())
+			addClientError(&resp.Diagnostics, iscsiTargetExtentFields, "Error Updating iSCSI Target-Extent", "Could not update iSCSI target-extent mapping", err)
 			return
 		}
 	}
 
 	if err := r.readTargetExtent(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading iSCSI Target-Extent", "Could not read iSCSI target-extent mapping after update: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiTargetExtentFields, "Error Reading iSCSI Target-Extent", "Could not read iSCSI target-extent mapping after update", err)
 		return
 	}
 
@@ -187,9 +210,17 @@ func (r *ISCSITargetExtentResource) Delete(ctx context.Context, req resource.Del
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_iscsi_targetextent", state.ID)
+
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, iscsiTargetLockKey(state.Target.ValueInt64()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
 	err := r.client.Delete(ctx, "iscsi.targetextent", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting iSCSI Target-Extent", "Could not delete iSCSI target-extent mapping: "+err.Error())
+		addClientError(&resp.Diagnostics, iscsiTargetExtentFields, "Error Deleting iSCSI Target-Extent", "Could not delete iSCSI target-extent mapping", err)
 		return
 	}
 }
@@ -207,16 +238,16 @@ func (r *ISCSITargetExtentResource) ImportState(ctx context.Context, req resourc
 }
 
 func (r *ISCSITargetExtentResource) readTargetExtent(ctx context.Context, id int64, model *ISCSITargetExtentResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "iscsi.targetextent", id, &result)
+	var mapping truenas.ISCSITargetExtent
+	err := r.client.GetInstance(ctx, "iscsi.targetextent", id, &mapping)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Target = types.Int64Value(int64(result["target"].(float64)))
-	model.Extent = types.Int64Value(int64(result["extent"].(float64)))
-	model.LunID = types.Int64Value(int64(result["lunid"].(float64)))
+	model.ID = types.Int64Value(mapping.ID)
+	model.Target = types.Int64Value(mapping.Target)
+	model.Extent = types.Int64Value(mapping.Extent)
+	model.LunID = types.Int64Value(mapping.LunID)
 
 	return nil
 }
@@ -0,0 +1,61 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadTargetExtent(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    ISCSITargetExtentResourceModel
+		wantErr bool
+	}{
+		{
+			name:    "mapping",
+			payload: `{"id": 4, "lunid": 0, "extent": 1, "target": 3}`,
+			want: ISCSITargetExtentResourceModel{
+				ID:     types.Int64Value(4),
+				Target: types.Int64Value(3),
+				Extent: types.Int64Value(1),
+				LunID:  types.Int64Value(0),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed lun",
+			payload: `{"id": 4, "lunid": null, "extent": "1", "target": 3}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ISCSITargetExtentResource{client: newFakeAPI("iscsi.targetextent", 4, tt.payload)}
+
+			var model ISCSITargetExtentResourceModel
+			err := r.readTargetExtent(context.Background(), 4, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readTargetExtent() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readTargetExtent() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readTargetExtent() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package resources
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+// lockParent serializes a mutation with other mutations of the same parent
+// object. It returns the unlock function, or nil after adding an error to
+// diags when ctx ends while waiting.
+func lockParent(ctx context.Context, c truenas.API, diags *diag.Diagnostics, key string) func() {
+	unlock, err := c.Lock(ctx, key)
+	if err != nil {
+		diags.AddError("Error Waiting for Lock", "Could not wait for other changes to "+key+": "+err.Error())
+		return nil
+	}
+	return unlock
+}
+
+// lockParents locks several parent objects for one mutation, such as a
+// share moving between pools. Keys are taken in sorted order so that two
+// mutations locking the same keys cannot deadlock, and duplicates are locked
+// once. It returns the unlock function, or nil after adding an error to diags
+// when ctx ends while waiting.
+func lockParents(ctx context.Context, c truenas.API, diags *diag.Diagnostics, keys ...string) func() {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	var unlocks []func()
+	unlockAll := func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		unlock := lockParent(ctx, c, diags, key)
+		if unlock == nil {
+			unlockAll()
+			return nil
+		}
+		unlocks = append(unlocks, unlock)
+	}
+	return unlockAll
+}
+
+// poolLockKey returns the lock key of the pool holding name, which may be a
+// pool, a dataset such as "tank/media" or a mount path such as
+// "/mnt/tank/media"
+func poolLockKey(name string) string {
+	name = strings.TrimPrefix(name, "/mnt/")
+	name = strings.TrimPrefix(name, "/")
+	if i := strings.IndexAny(name, "/@"); i >= 0 {
+		name = name[:i]
+	}
+	return "pool/" + name
+}
+
+// vmLockKey returns the lock key of a VM
+func vmLockKey(id int64) string {
+	return "vm/" + strconv.FormatInt(id, 10)
+}
+
+// iscsiTargetLockKey returns the lock key of an iSCSI target
+func iscsiTargetLockKey(id int64) string {
+	return "iscsi.target/" + strconv.FormatInt(id, 10)
+}
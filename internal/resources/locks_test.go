@@ -0,0 +1,69 @@
+package resources
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+// lockAPI implements Lock with a real keyed mutex and records the order
+// keys are taken in
+type lockAPI struct {
+	truenas.API
+	mu     truenas.KeyedMutex
+	locked []string
+}
+
+func (l *lockAPI) Lock(ctx context.Context, key string) (func(), error) {
+	unlock, err := l.mu.Lock(ctx, key)
+	if err == nil {
+		l.locked = append(l.locked, key)
+	}
+	return unlock, err
+}
+
+func TestLockParents(t *testing.T) {
+	t.Run("sorted and deduplicated", func(t *testing.T) {
+		api := &lockAPI{}
+		var diags diag.Diagnostics
+		unlock := lockParents(context.Background(), api, &diags, "pool/tank", "pool/archive", "pool/tank")
+		if unlock == nil {
+			t.Fatalf("lockParents() failed: %v", diags)
+		}
+		unlock()
+
+		if want := []string{"pool/archive", "pool/tank"}; !reflect.DeepEqual(api.locked, want) {
+			t.Errorf("locked %v, want %v", api.locked, want)
+		}
+	})
+
+	t.Run("releases taken locks on failure", func(t *testing.T) {
+		api := &lockAPI{}
+		held, _ := api.Lock(context.Background(), "pool/tank")
+		defer held()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		var diags diag.Diagnostics
+		if unlock := lockParents(ctx, api, &diags, "pool/tank", "pool/archive"); unlock != nil {
+			t.Fatal("lockParents() succeeded while pool/tank was held")
+		}
+		if !diags.HasError() {
+			t.Error("lockParents() added no error")
+		}
+
+		// pool/archive must have been released again
+		ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		unlock, err := api.Lock(ctx, "pool/archive")
+		if err != nil {
+			t.Fatalf("pool/archive still locked: %v", err)
+		}
+		unlock()
+	})
+}
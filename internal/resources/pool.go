@@ -16,9 +16,12 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
+// poolJobTimeout bounds pool creation and export jobs
+const poolJobTimeout = 10 * time.Minute
+
 var (
 	_ resource.Resource                = &PoolResource{}
 	_ resource.ResourceWithImportState = &PoolResource{}
@@ -29,7 +32,7 @@ func NewPoolResource() resource.Resource {
 }
 
 type PoolResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type PoolResourceModel struct {
@@ -48,6 +51,14 @@ type PoolResourceModel struct {
 	Allocated         types.Int64  `tfsdk:"allocated"`
 }
 
+// poolFields maps the fields of pool.create to schema attributes
+var poolFields = apiFields{
+	"name":          path.Root("name"),
+	"topology":      path.Root("topology"),
+	"encryption":    path.Root("encryption"),
+	"deduplication": path.Root("deduplication"),
+}
+
 type TopologyVDev struct {
 	Type  types.String `tfsdk:"type"`
 	Disks types.List   `tfsdk:"disks"`
@@ -155,11 +166,11 @@ func (r *PoolResource) Configure(ctx context.Context, req resource.ConfigureRequ
 		return
 	}
 
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData),
+			fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData),
 		)
 		return
 	}
@@ -168,6 +179,8 @@ func (r *PoolResource) Configure(ctx context.Context, req resource.ConfigureRequ
 }
 
 func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_pool", nil)
+
 	var plan PoolResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -187,7 +200,7 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	topology := make(map[string]interface{})
+	topology := make(map[string][]truenas.PoolVDev)
 	for _, vdev := range topologyVDevs {
 		var disks []string
 		diags = vdev.Disks.ElementsAs(ctx, &disks, false)
@@ -196,71 +209,42 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 			return
 		}
 
-		vdevType := vdev.Type.ValueString()
-		if topology[vdevType] == nil {
-			topology[vdevType] = []map[string]interface{}{}
-		}
-
-		vdevData := map[string]interface{}{
-			"type":  "STRIPE", // Default, will be determined by number of disks
-			"disks": disks,
-		}
-
-		// Determine vdev type based on disk count
+		// Determine vdev layout based on disk count
+		layout := "RAIDZ1"
 		switch len(disks) {
 		case 1:
-			vdevData["type"] = "STRIPE"
+			layout = "STRIPE"
 		case 2:
-			vdevData["type"] = "MIRROR"
-		default:
-			vdevData["type"] = "RAIDZ1"
+			layout = "MIRROR"
 		}
 
-		topology[vdevType] = append(topology[vdevType].([]map[string]interface{}), vdevData)
+		vdevType := vdev.Type.ValueString()
+		topology[vdevType] = append(topology[vdevType], truenas.PoolVDev{Type: layout, Disks: disks})
 	}
 
-	createData := map[string]interface{}{
-		"name":     plan.Name.ValueString(),
-		"topology": topology,
+	createData := truenas.PoolCreateRequest{
+		Name:     plan.Name.ValueString(),
+		Topology: topology,
 		// Allow duplicate serials for VMs with virtual disks that don't have unique serials
-		"allow_duplicate_serials": true,
-	}
-
-	if !plan.Encryption.IsNull() && plan.Encryption.ValueBool() {
-		createData["encryption"] = true
-		// Add encryption options if specified
-	}
-
-	if !plan.Deduplication.IsNull() {
-		createData["deduplication"] = plan.Deduplication.ValueString()
+		AllowDuplicateSerials: true,
+		Encryption:            plan.Encryption.ValueBool(),
+		Deduplication:         plan.Deduplication.ValueStringPointer(),
 	}
 
 	// Pool creation is a long-running job, wait for it to complete
-	result, err := r.client.CreateWithJob(ctx, "pool", createData, 10*time.Minute)
+	var pool truenas.Pool
+	err := r.client.Create(ctx, "pool", createData, &pool, truenas.AsJob(), truenas.WithTimeout(poolJobTimeout))
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Creating Pool",
-			"Could not create pool: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, poolFields, "Error Creating Pool", "Could not create pool", err)
 		return
 	}
 
-	// Get the pool ID from the result
-	var poolID int64
-	if id, ok := result["id"].(float64); ok {
-		poolID = int64(id)
-	} else if id, ok := result["result"].(map[string]interface{}); ok {
-		if pid, ok := id["id"].(float64); ok {
-			poolID = int64(pid)
-		}
-	}
-
+	poolID := pool.ID
 	if poolID == 0 {
-		// If we can't get the ID from result, query by name
-		var pools []map[string]interface{}
-		queryErr := r.client.Call(ctx, "pool.query", []interface{}{
-			[][]interface{}{{"name", "=", plan.Name.ValueString()}},
-		}, &pools)
+		// If the job did not return the pool, query by name
+		var pools []truenas.Pool
+		params := truenas.NewQueryParams().WithFilter("name", "=", plan.Name.ValueString())
+		queryErr := r.client.Query(ctx, "pool", params, &pools)
 		if queryErr != nil || len(pools) == 0 {
 			resp.Diagnostics.AddError(
 				"Error Creating Pool",
@@ -268,13 +252,10 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 			)
 			return
 		}
-		poolID = int64(pools[0]["id"].(float64))
+		poolID = pools[0].ID
 	}
 	if err := r.readPool(ctx, poolID, &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading Pool",
-			"Could not read pool after creation: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, poolFields, "Error Reading Pool", "Could not read pool after creation", err)
 		return
 	}
 
@@ -290,15 +271,14 @@ func (r *PoolResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_pool", state.ID)
+
 	if err := r.readPool(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
-			"Error Reading Pool",
-			"Could not read pool: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, poolFields, "Error Reading Pool", "Could not read pool", err)
 		return
 	}
 
@@ -321,6 +301,8 @@ func (r *PoolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_pool", state.ID)
+
 	tflog.Debug(ctx, "Updating pool", map[string]interface{}{
 		"id": state.ID.ValueInt64(),
 	})
@@ -335,10 +317,7 @@ func (r *PoolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 
 	// Read the updated pool
 	if err := r.readPool(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading Pool",
-			"Could not read pool after update: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, poolFields, "Error Reading Pool", "Could not read pool after update", err)
 		return
 	}
 
@@ -354,22 +333,19 @@ func (r *PoolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_pool", state.ID)
+
 	tflog.Debug(ctx, "Deleting pool", map[string]interface{}{
 		"id": state.ID.ValueInt64(),
 	})
 
-	// Export and destroy the pool
+	// Export and destroy the pool, waiting for the export job
 	err := r.client.Call(ctx, "pool.export", []interface{}{
 		state.ID.ValueInt64(),
-		map[string]interface{}{
-			"destroy": true,
-		},
-	}, nil)
+		truenas.PoolExportOptions{Destroy: true},
+	}, nil, truenas.AsJob(), truenas.WithTimeout(poolJobTimeout))
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Deleting Pool",
-			"Could not delete pool: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, poolFields, "Error Deleting Pool", "Could not delete pool", err)
 		return
 	}
 }
@@ -387,26 +363,26 @@ func (r *PoolResource) ImportState(ctx context.Context, req resource.ImportState
 }
 
 func (r *PoolResource) readPool(ctx context.Context, id int64, model *PoolResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "pool", id, &result)
+	var pool truenas.Pool
+	err := r.client.GetInstance(ctx, "pool", id, &pool)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Name = types.StringValue(result["name"].(string))
-	model.Status = types.StringValue(result["status"].(string))
-	model.Healthy = types.BoolValue(result["healthy"].(bool))
-	model.Path = types.StringValue(result["path"].(string))
+	model.ID = types.Int64Value(pool.ID)
+	model.Name = types.StringValue(pool.Name)
+	model.Status = types.StringValue(pool.Status)
+	model.Healthy = types.BoolValue(pool.Healthy)
+	model.Path = types.StringValue(pool.Path)
 
-	if size, ok := result["size"].(float64); ok {
-		model.Size = types.Int64Value(int64(size))
+	if pool.Size != nil {
+		model.Size = types.Int64Value(int64(*pool.Size))
 	}
-	if free, ok := result["free"].(float64); ok {
-		model.Free = types.Int64Value(int64(free))
+	if pool.Free != nil {
+		model.Free = types.Int64Value(int64(*pool.Free))
 	}
-	if allocated, ok := result["allocated"].(float64); ok {
-		model.Allocated = types.Int64Value(int64(allocated))
+	if pool.Allocated != nil {
+		model.Allocated = types.Int64Value(int64(*pool.Allocated))
 	}
 
 	return nil
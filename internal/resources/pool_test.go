@@ -0,0 +1,80 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadPool(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    PoolResourceModel
+		wantErr bool
+	}{
+		{
+			name: "online pool",
+			payload: `{
+				"id": 1, "name": "tank", "guid": "1234567890", "status": "ONLINE", "healthy": true,
+				"path": "/mnt/tank", "size": 3985729650688, "allocated": 1125899906842, "free": 2859829743846,
+				"fragmentation": "3", "topology": {"data": [], "log": [], "cache": [], "spare": []}
+			}`,
+			want: PoolResourceModel{
+				ID:        types.Int64Value(1),
+				Name:      types.StringValue("tank"),
+				Status:    types.StringValue("ONLINE"),
+				Healthy:   types.BoolValue(true),
+				Path:      types.StringValue("/mnt/tank"),
+				Size:      types.Int64Value(3985729650688),
+				Allocated: types.Int64Value(1125899906842),
+				Free:      types.Int64Value(2859829743846),
+			},
+		},
+		{
+			name:    "offline pool without sizes",
+			payload: `{"id": 1, "name": "tank", "status": "OFFLINE", "healthy": false, "path": "/mnt/tank", "size": null, "allocated": null, "free": null}`,
+			want: PoolResourceModel{
+				ID:      types.Int64Value(1),
+				Name:    types.StringValue("tank"),
+				Status:  types.StringValue("OFFLINE"),
+				Healthy: types.BoolValue(false),
+				Path:    types.StringValue("/mnt/tank"),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed id",
+			payload: `{"id": "tank", "name": "tank"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &PoolResource{client: newFakeAPI("pool", 1, tt.payload)}
+
+			var model PoolResourceModel
+			err := r.readPool(context.Background(), 1, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readPool() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readPool() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPool() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
@@ -16,7 +16,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -29,7 +29,7 @@ func NewShareNFSResource() resource.Resource {
 }
 
 type ShareNFSResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type ShareNFSResourceModel struct {
@@ -49,6 +49,22 @@ type ShareNFSResourceModel struct {
 	Locked        types.Bool   `tfsdk:"locked"`
 }
 
+// nfsShareFields maps the fields of sharing.nfs.create and sharing.nfs.update to schema attributes
+var nfsShareFields = apiFields{
+	"path":          path.Root("path"),
+	"aliases":       path.Root("aliases"),
+	"comment":       path.Root("comment"),
+	"enabled":       path.Root("enabled"),
+	"networks":      path.Root("networks"),
+	"hosts":         path.Root("hosts"),
+	"maproot_user":  path.Root("maproot_user"),
+	"maproot_group": path.Root("maproot_group"),
+	"mapall_user":   path.Root("mapall_user"),
+	"mapall_group":  path.Root("mapall_group"),
+	"security":      path.Root("security"),
+	"ro":            path.Root("ro"),
+}
+
 func (r *ShareNFSResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_share_nfs"
 }
@@ -144,11 +160,11 @@ func (r *ShareNFSResource) Configure(ctx context.Context, req resource.Configure
 		return
 	}
 
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData),
+			fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData),
 		)
 		return
 	}
@@ -157,6 +173,8 @@ func (r *ShareNFSResource) Configure(ctx context.Context, req resource.Configure
 }
 
 func (r *ShareNFSResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_share_nfs", nil)
+
 	var plan ShareNFSResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -168,9 +186,11 @@ func (r *ShareNFSResource) Create(ctx context.Context, req resource.CreateReques
 		"path": plan.Path.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"path":    plan.Path.ValueString(),
-		"enabled": plan.Enabled.ValueBool(),
+	createData := truenas.NFSShareRequest{
+		Path:    truenas.Ptr(plan.Path.ValueString()),
+		Enabled: truenas.Ptr(plan.Enabled.ValueBool()),
+		Comment: plan.Comment.ValueStringPointer(),
+		Ro:      plan.Ro.ValueBoolPointer(),
 	}
 
 	if !plan.Aliases.IsNull() {
@@ -178,18 +198,15 @@ func (r *ShareNFSResource) Create(ctx context.Context, req resource.CreateReques
 		diags = plan.Aliases.ElementsAs(ctx, &aliases, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["aliases"] = aliases
+			createData.Aliases = &aliases
 		}
 	}
-	if !plan.Comment.IsNull() {
-		createData["comment"] = plan.Comment.ValueString()
-	}
 	if !plan.Networks.IsNull() {
 		var networks []string
 		diags = plan.Networks.ElementsAs(ctx, &networks, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["networks"] = networks
+			createData.Networks = &networks
 		}
 	}
 	if !plan.Hosts.IsNull() {
@@ -197,20 +214,20 @@ func (r *ShareNFSResource) Create(ctx context.Context, req resource.CreateReques
 		diags = plan.Hosts.ElementsAs(ctx, &hosts, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["hosts"] = hosts
+			createData.Hosts = &hosts
 		}
 	}
-	if !plan.MaprootUser.IsNull() && plan.MaprootUser.ValueString() != "" {
-		createData["maproot_user"] = plan.MaprootUser.ValueString()
+	if plan.MaprootUser.ValueString() != "" {
+		createData.MaprootUser = truenas.Ptr(plan.MaprootUser.ValueString())
 	}
-	if !plan.MaprootGroup.IsNull() && plan.MaprootGroup.ValueString() != "" {
-		createData["maproot_group"] = plan.MaprootGroup.ValueString()
+	if plan.MaprootGroup.ValueString() != "" {
+		createData.MaprootGroup = truenas.Ptr(plan.MaprootGroup.ValueString())
 	}
-	if !plan.MapallUser.IsNull() && plan.MapallUser.ValueString() != "" {
-		createData["mapall_user"] = plan.MapallUser.ValueString()
+	if plan.MapallUser.ValueString() != "" {
+		createData.MapallUser = truenas.Ptr(plan.MapallUser.ValueString())
 	}
-	if !plan.MapallGroup.IsNull() && plan.MapallGroup.ValueString() != "" {
-		createData["mapall_group"] = plan.MapallGroup.ValueString()
+	if plan.MapallGroup.ValueString() != "" {
+		createData.MapallGroup = truenas.Ptr(plan.MapallGroup.ValueString())
 	}
 	if !plan.Security.IsNull() {
 		var security []string
@@ -221,29 +238,25 @@ func (r *ShareNFSResource) Create(ctx context.Context, req resource.CreateReques
 			for i, s := range security {
 				security[i] = strings.ToUpper(s)
 			}
-			createData["security"] = security
+			createData.Security = &security
 		}
 	}
-	if !plan.Ro.IsNull() {
-		createData["ro"] = plan.Ro.ValueBool()
+
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(plan.Path.ValueString()))
+	if unlock == nil {
+		return
 	}
+	defer unlock()
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "sharing.nfs", createData, &result)
+	var share truenas.NFSShare
+	err := r.client.Create(ctx, "sharing.nfs", createData, &share)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Creating NFS Share",
-			"Could not create NFS share: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, nfsShareFields, "Error Creating NFS Share", "Could not create NFS share", err)
 		return
 	}
 
-	shareID := int64(result["id"].(float64))
-	if err := r.readShare(ctx, shareID, &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading NFS Share",
-			"Could not read NFS share after creation: "+err.Error(),
-		)
+	if err := r.readShare(ctx, share.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, nfsShareFields, "Error Reading NFS Share", "Could not read NFS share after creation", err)
 		return
 	}
 
@@ -259,15 +272,14 @@ func (r *ShareNFSResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_share_nfs", state.ID)
+
 	if err := r.readShare(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
-			"Error Reading NFS Share",
-			"Could not read NFS share: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, nfsShareFields, "Error Reading NFS Share", "Could not read NFS share", err)
 		return
 	}
 
@@ -290,17 +302,19 @@ func (r *ShareNFSResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_share_nfs", state.ID)
+
 	tflog.Debug(ctx, "Updating NFS share", map[string]interface{}{
 		"id": state.ID.ValueInt64(),
 	})
 
-	updateData := map[string]interface{}{
+	updateData := truenas.NFSShareRequest{
 		// Always include enabled to prevent TrueNAS from resetting it during updates
-		"enabled": plan.Enabled.ValueBool(),
+		Enabled: truenas.Ptr(plan.Enabled.ValueBool()),
 	}
 
 	if !plan.Path.Equal(state.Path) {
-		updateData["path"] = plan.Path.ValueString()
+		updateData.Path = truenas.Ptr(plan.Path.ValueString())
 	}
 	if !plan.Aliases.Equal(state.Aliases) {
 		var aliases []string
@@ -308,14 +322,10 @@ func (r *ShareNFSResource) Update(ctx context.Context, req resource.UpdateReques
 			diags = plan.Aliases.ElementsAs(ctx, &aliases, false)
 			resp.Diagnostics.Append(diags...)
 		}
-		updateData["aliases"] = aliases
+		updateData.Aliases = &aliases
 	}
 	if !plan.Comment.Equal(state.Comment) {
-		if plan.Comment.IsNull() {
-			updateData["comment"] = ""
-		} else {
-			updateData["comment"] = plan.Comment.ValueString()
-		}
+		updateData.Comment = stringOrEmpty(plan.Comment)
 	}
 	if !plan.Networks.Equal(state.Networks) {
 		networks := []string{}
@@ -323,7 +333,7 @@ func (r *ShareNFSResource) Update(ctx context.Context, req resource.UpdateReques
 			diags = plan.Networks.ElementsAs(ctx, &networks, false)
 			resp.Diagnostics.Append(diags...)
 		}
-		updateData["networks"] = networks
+		updateData.Networks = &networks
 	}
 	if !plan.Hosts.Equal(state.Hosts) {
 		hosts := []string{}
@@ -331,19 +341,19 @@ func (r *ShareNFSResource) Update(ctx context.Context, req resource.UpdateReques
 			diags = plan.Hosts.ElementsAs(ctx, &hosts, false)
 			resp.Diagnostics.Append(diags...)
 		}
-		updateData["hosts"] = hosts
+		updateData.Hosts = &hosts
 	}
 	if !plan.MaprootUser.Equal(state.MaprootUser) {
-		updateData["maproot_user"] = plan.MaprootUser.ValueString()
+		updateData.MaprootUser = truenas.Ptr(plan.MaprootUser.ValueString())
 	}
 	if !plan.MaprootGroup.Equal(state.MaprootGroup) {
-		updateData["maproot_group"] = plan.MaprootGroup.ValueString()
+		updateData.MaprootGroup = truenas.Ptr(plan.MaprootGroup.ValueString())
 	}
 	if !plan.MapallUser.Equal(state.MapallUser) {
-		updateData["mapall_user"] = plan.MapallUser.ValueString()
+		updateData.MapallUser = truenas.Ptr(plan.MapallUser.ValueString())
 	}
 	if !plan.MapallGroup.Equal(state.MapallGroup) {
-		updateData["mapall_group"] = plan.MapallGroup.ValueString()
+		updateData.MapallGroup = truenas.Ptr(plan.MapallGroup.ValueString())
 	}
 	if !plan.Security.Equal(state.Security) {
 		var security []string
@@ -355,29 +365,27 @@ func (r *ShareNFSResource) Update(ctx context.Context, req resource.UpdateReques
 		for i, s := range security {
 			security[i] = strings.ToUpper(s)
 		}
-		updateData["security"] = security
+		updateData.Security = &security
 	}
 	if !plan.Ro.Equal(state.Ro) {
-		updateData["ro"] = plan.Ro.ValueBool()
+		updateData.Ro = truenas.Ptr(plan.Ro.ValueBool())
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "sharing.nfs", state.ID.ValueInt64(), updateData, &result)
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Updating NFS Share",
-				"Could not update NFS share: "+err.Error(),
-			)
-			return
-		}
+	// The share may move to another pool; hold both
+	unlock := lockParents(ctx, r.client, &resp.Diagnostics, poolLockKey(state.Path.ValueString()), poolLockKey(plan.Path.ValueString()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
+	err := r.client.Update(ctx, "sharing.nfs", state.ID.ValueInt64(), updateData, nil)
+	if err != nil {
+		addClientError(&resp.Diagnostics, nfsShareFields, "Error Updating NFS Share", "Could not update NFS share", err)
+		return
 	}
 
 	if err := r.readShare(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading NFS Share",
-			"Could not read NFS share after update: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, nfsShareFields, "Error Reading NFS Share", "Could not read NFS share after update", err)
 		return
 	}
 
@@ -393,16 +401,21 @@ func (r *ShareNFSResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_share_nfs", state.ID)
+
 	tflog.Debug(ctx, "Deleting NFS share", map[string]interface{}{
 		"id": state.ID.ValueInt64(),
 	})
 
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(state.Path.ValueString()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
 	err := r.client.Delete(ctx, "sharing.nfs", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Deleting NFS Share",
-			"Could not delete NFS share: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, nfsShareFields, "Error Deleting NFS Share", "Could not delete NFS share", err)
 		return
 	}
 }
@@ -420,83 +433,71 @@ func (r *ShareNFSResource) ImportState(ctx context.Context, req resource.ImportS
 }
 
 func (r *ShareNFSResource) readShare(ctx context.Context, id int64, model *ShareNFSResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "sharing.nfs", id, &result)
+	var share truenas.NFSShare
+	err := r.client.GetInstance(ctx, "sharing.nfs", id, &share)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Path = types.StringValue(result["path"].(string))
+	model.ID = types.Int64Value(share.ID)
+	model.Path = types.StringValue(share.Path)
 
-	if aliases, ok := result["aliases"].([]interface{}); ok {
-		aliasList := make([]string, len(aliases))
-		for i, a := range aliases {
-			aliasList[i] = a.(string)
-		}
-		aliasValues, diags := types.ListValueFrom(ctx, types.StringType, aliasList)
+	if share.Aliases != nil {
+		aliasValues, diags := types.ListValueFrom(ctx, types.StringType, share.Aliases)
 		if !diags.HasError() {
 			model.Aliases = aliasValues
 		}
 	}
-	if comment, ok := result["comment"].(string); ok && comment != "" {
-		model.Comment = types.StringValue(comment)
+	if share.Comment != nil && *share.Comment != "" {
+		model.Comment = types.StringValue(*share.Comment)
 	} else if model.Comment.IsUnknown() {
 		model.Comment = types.StringNull()
 	}
-	if enabled, ok := result["enabled"].(bool); ok {
-		model.Enabled = types.BoolValue(enabled)
+	if share.Enabled != nil {
+		model.Enabled = types.BoolValue(*share.Enabled)
 	}
-	if networks, ok := result["networks"].([]interface{}); ok && len(networks) > 0 {
-		networkList := make([]string, len(networks))
-		for i, n := range networks {
-			networkList[i] = n.(string)
-		}
-		networkValues, diags := types.ListValueFrom(ctx, types.StringType, networkList)
+	if len(share.Networks) > 0 {
+		networkValues, diags := types.ListValueFrom(ctx, types.StringType, share.Networks)
 		if !diags.HasError() {
 			model.Networks = networkValues
 		}
 	} else if model.Networks.IsUnknown() {
 		model.Networks = types.ListNull(types.StringType)
 	}
-	if hosts, ok := result["hosts"].([]interface{}); ok {
-		hostList := make([]string, len(hosts))
-		for i, h := range hosts {
-			hostList[i] = h.(string)
-		}
-		hostValues, diags := types.ListValueFrom(ctx, types.StringType, hostList)
+	if share.Hosts != nil {
+		hostValues, diags := types.ListValueFrom(ctx, types.StringType, share.Hosts)
 		if !diags.HasError() {
 			model.Hosts = hostValues
 		}
 	}
-	if maprootUser, ok := result["maproot_user"].(string); ok {
-		model.MaprootUser = types.StringValue(maprootUser)
+	if share.MaprootUser != nil {
+		model.MaprootUser = types.StringValue(*share.MaprootUser)
 	}
-	if maprootGroup, ok := result["maproot_group"].(string); ok {
-		model.MaprootGroup = types.StringValue(maprootGroup)
+	if share.MaprootGroup != nil {
+		model.MaprootGroup = types.StringValue(*share.MaprootGroup)
 	}
-	if mapallUser, ok := result["mapall_user"].(string); ok {
-		model.MapallUser = types.StringValue(mapallUser)
+	if share.MapallUser != nil {
+		model.MapallUser = types.StringValue(*share.MapallUser)
 	}
-	if mapallGroup, ok := result["mapall_group"].(string); ok {
-		model.MapallGroup = types.StringValue(mapallGroup)
+	if share.MapallGroup != nil {
+		model.MapallGroup = types.StringValue(*share.MapallGroup)
 	}
-	if security, ok := result["security"].([]interface{}); ok {
-		secList := make([]string, len(security))
-		for i, s := range security {
+	if share.Security != nil {
+		secList := make([]string, len(share.Security))
+		for i, s := range share.Security {
 			// Normalize to lowercase for consistent state (users typically write lowercase)
-			secList[i] = strings.ToLower(s.(string))
+			secList[i] = strings.ToLower(s)
 		}
 		secValues, diags := types.ListValueFrom(ctx, types.StringType, secList)
 		if !diags.HasError() {
 			model.Security = secValues
 		}
 	}
-	if ro, ok := result["ro"].(bool); ok {
-		model.Ro = types.BoolValue(ro)
+	if share.Ro != nil {
+		model.Ro = types.BoolValue(*share.Ro)
 	}
-	if locked, ok := result["locked"].(bool); ok {
-		model.Locked = types.BoolValue(locked)
+	if share.Locked != nil {
+		model.Locked = types.BoolValue(*share.Locked)
 	}
 
 	return nil
@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadShareNFS(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		model   ShareNFSResourceModel
+		want    ShareNFSResourceModel
+		wantErr bool
+	}{
+		{
+			name: "restricted export",
+			payload: `{
+				"id": 3, "path": "/mnt/tank/media", "aliases": [], "comment": "Media export", "enabled": true,
+				"networks": ["192.168.1.0/24"], "hosts": ["nas-client.lan"],
+				"ro": true, "maproot_user": "root", "maproot_group": "wheel", "mapall_user": null, "mapall_group": null,
+				"security": ["SYS", "KRB5"], "locked": false
+			}`,
+			want: ShareNFSResourceModel{
+				ID:           types.Int64Value(3),
+				Path:         types.StringValue("/mnt/tank/media"),
+				Aliases:      stringList(),
+				Comment:      types.StringValue("Media export"),
+				Enabled:      types.BoolValue(true),
+				Networks:     stringList("192.168.1.0/24"),
+				Hosts:        stringList("nas-client.lan"),
+				MaprootUser:  types.StringValue("root"),
+				MaprootGroup: types.StringValue("wheel"),
+				Security:     stringList("sys", "krb5"),
+				Ro:           types.BoolValue(true),
+				Locked:       types.BoolValue(false),
+			},
+		},
+		{
+			name:    "unset comment and networks after create",
+			payload: `{"id": 3, "path": "/mnt/tank/media", "comment": "", "networks": [], "enabled": true}`,
+			model: ShareNFSResourceModel{
+				Comment:  types.StringUnknown(),
+				Networks: types.ListUnknown(types.StringType),
+			},
+			want: ShareNFSResourceModel{
+				ID:       types.Int64Value(3),
+				Path:     types.StringValue("/mnt/tank/media"),
+				Comment:  types.StringNull(),
+				Networks: types.ListNull(types.StringType),
+				Enabled:  types.BoolValue(true),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed networks",
+			payload: `{"id": 3, "path": "/mnt/tank/media", "networks": "192.168.1.0/24"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ShareNFSResource{client: newFakeAPI("sharing.nfs", 3, tt.payload)}
+
+			model := tt.model
+			err := r.readShare(context.Background(), 3, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readShare() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readShare() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readShare() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
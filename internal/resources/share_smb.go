@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -28,7 +28,7 @@ func NewShareSMBResource() resource.Resource {
 }
 
 type ShareSMBResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type ShareSMBResourceModel struct {
@@ -58,6 +58,31 @@ type ShareSMBResourceModel struct {
 	Locked            types.Bool   `tfsdk:"locked"`
 }
 
+// smbShareFields maps the fields of sharing.smb.create and sharing.smb.update to schema attributes
+var smbShareFields = apiFields{
+	"path":          path.Root("path"),
+	"path_suffix":   path.Root("path_suffix"),
+	"name":          path.Root("name"),
+	"comment":       path.Root("comment"),
+	"enabled":       path.Root("enabled"),
+	"home":          path.Root("home"),
+	"purpose":       path.Root("purpose"),
+	"timemachine":   path.Root("timemachine"),
+	"ro":            path.Root("ro"),
+	"browsable":     path.Root("browsable"),
+	"recyclebin":    path.Root("recyclebin"),
+	"guestok":       path.Root("guestok"),
+	"abe":           path.Root("abe"),
+	"hostsallow":    path.Root("hostsallow"),
+	"hostsdeny":     path.Root("hostsdeny"),
+	"auxsmbconf":    path.Root("auxsmbconf"),
+	"acl":           path.Root("acl"),
+	"durablehandle": path.Root("durablehandle"),
+	"shadowcopy":    path.Root("shadowcopy"),
+	"streams":       path.Root("streams"),
+	"fsrvp":         path.Root("fsrvp"),
+}
+
 func (r *ShareSMBResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_share_smb"
 }
@@ -206,11 +231,11 @@ func (r *ShareSMBResource) Configure(ctx context.Context, req resource.Configure
 		return
 	}
 
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData),
+			fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData),
 		)
 		return
 	}
@@ -219,6 +244,8 @@ func (r *ShareSMBResource) Configure(ctx context.Context, req resource.Configure
 }
 
 func (r *ShareSMBResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_share_smb", nil)
+
 	var plan ShareSMBResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -231,48 +258,34 @@ func (r *ShareSMBResource) Create(ctx context.Context, req resource.CreateReques
 		"path": plan.Path.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"path":    plan.Path.ValueString(),
-		"name":    plan.Name.ValueString(),
-		"enabled": plan.Enabled.ValueBool(),
+	createData := truenas.SMBShareRequest{
+		Path:          truenas.Ptr(plan.Path.ValueString()),
+		Name:          truenas.Ptr(plan.Name.ValueString()),
+		Enabled:       truenas.Ptr(plan.Enabled.ValueBool()),
+		PathSuffix:    plan.PathSuffix.ValueStringPointer(),
+		Comment:       plan.Comment.ValueStringPointer(),
+		Home:          plan.Home.ValueBoolPointer(),
+		Purpose:       plan.Purpose.ValueStringPointer(),
+		Timemachine:   plan.TimeMachine.ValueBoolPointer(),
+		Ro:            plan.Ro.ValueBoolPointer(),
+		Browsable:     plan.Browsable.ValueBoolPointer(),
+		Recyclebin:    plan.Recyclebin.ValueBoolPointer(),
+		Guestok:       plan.Guestok.ValueBoolPointer(),
+		Abe:           plan.Abe.ValueBoolPointer(),
+		Auxsmbconf:    plan.AuxSMBConf.ValueStringPointer(),
+		ACL:           plan.Acl.ValueBoolPointer(),
+		Durablehandle: plan.Durablehandle.ValueBoolPointer(),
+		Shadowcopy:    plan.Shadowcopy.ValueBoolPointer(),
+		Streams:       plan.Streams.ValueBoolPointer(),
+		Fsrvp:         plan.Fsrvp.ValueBoolPointer(),
 	}
 
-	if !plan.PathSuffix.IsNull() {
-		createData["path_suffix"] = plan.PathSuffix.ValueString()
-	}
-	if !plan.Comment.IsNull() {
-		createData["comment"] = plan.Comment.ValueString()
-	}
-	if !plan.Home.IsNull() {
-		createData["home"] = plan.Home.ValueBool()
-	}
-	if !plan.Purpose.IsNull() {
-		createData["purpose"] = plan.Purpose.ValueString()
-	}
-	if !plan.TimeMachine.IsNull() {
-		createData["timemachine"] = plan.TimeMachine.ValueBool()
-	}
-	if !plan.Ro.IsNull() {
-		createData["ro"] = plan.Ro.ValueBool()
-	}
-	if !plan.Browsable.IsNull() {
-		createData["browsable"] = plan.Browsable.ValueBool()
-	}
-	if !plan.Recyclebin.IsNull() {
-		createData["recyclebin"] = plan.Recyclebin.ValueBool()
-	}
-	if !plan.Guestok.IsNull() {
-		createData["guestok"] = plan.Guestok.ValueBool()
-	}
-	if !plan.Abe.IsNull() {
-		createData["abe"] = plan.Abe.ValueBool()
-	}
 	if !plan.HostsAllow.IsNull() {
 		var hosts []string
 		diags = plan.HostsAllow.ElementsAs(ctx, &hosts, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["hostsallow"] = hosts
+			createData.Hostsallow = &hosts
 		}
 	}
 	if !plan.HostsDeny.IsNull() {
@@ -280,45 +293,26 @@ func (r *ShareSMBResource) Create(ctx context.Context, req resource.CreateReques
 		diags = plan.HostsDeny.ElementsAs(ctx, &hosts, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["hostsdeny"] = hosts
+			createData.Hostsdeny = &hosts
 		}
 	}
-	if !plan.AuxSMBConf.IsNull() {
-		createData["auxsmbconf"] = plan.AuxSMBConf.ValueString()
-	}
-	if !plan.Acl.IsNull() {
-		createData["acl"] = plan.Acl.ValueBool()
-	}
-	if !plan.Durablehandle.IsNull() {
-		createData["durablehandle"] = plan.Durablehandle.ValueBool()
-	}
-	if !plan.Shadowcopy.IsNull() {
-		createData["shadowcopy"] = plan.Shadowcopy.ValueBool()
-	}
-	if !plan.Streams.IsNull() {
-		createData["streams"] = plan.Streams.ValueBool()
-	}
-	if !plan.Fsrvp.IsNull() {
-		createData["fsrvp"] = plan.Fsrvp.ValueBool()
-	}
 	// Note: audit_logging is not supported in TrueNAS Scale 25
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "sharing.smb", createData, &result)
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(plan.Path.ValueString()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
+	var share truenas.SMBShare
+	err := r.client.Create(ctx, "sharing.smb", createData, &share)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Creating SMB Share",
-			"Could not create SMB share: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, smbShareFields, "Error Creating SMB Share", "Could not create SMB share", err)
 		return
 	}
 
-	shareID := int64(result["id"].(float64))
-	if err := r.readShare(ctx, shareID, &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading SMB Share",
-			"Could not read SMB share after creation: "+err.Error(),
-		)
+	if err := r.readShare(ctx, share.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, smbShareFields, "Error Reading SMB Share", "Could not read SMB share after creation", err)
 		return
 	}
 
@@ -334,15 +328,14 @@ func (r *ShareSMBResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_share_smb", state.ID)
+
 	if err := r.readShare(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
-			"Error Reading SMB Share",
-			"Could not read SMB share: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, smbShareFields, "Error Reading SMB Share", "Could not read SMB share", err)
 		return
 	}
 
@@ -365,37 +358,31 @@ func (r *ShareSMBResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_share_smb", state.ID)
+
 	tflog.Debug(ctx, "Updating SMB share", map[string]interface{}{
 		"id": state.ID.ValueInt64(),
 	})
 
-	updateData := map[string]interface{}{}
+	var updateData truenas.SMBShareRequest
 
 	if !plan.Path.Equal(state.Path) {
-		updateData["path"] = plan.Path.ValueString()
+		updateData.Path = truenas.Ptr(plan.Path.ValueString())
 	}
 	if !plan.PathSuffix.Equal(state.PathSuffix) {
-		if plan.PathSuffix.IsNull() {
-			updateData["path_suffix"] = ""
-		} else {
-			updateData["path_suffix"] = plan.PathSuffix.ValueString()
-		}
+		updateData.PathSuffix = stringOrEmpty(plan.PathSuffix)
 	}
 	if !plan.Comment.Equal(state.Comment) {
-		if plan.Comment.IsNull() {
-			updateData["comment"] = ""
-		} else {
-			updateData["comment"] = plan.Comment.ValueString()
-		}
+		updateData.Comment = stringOrEmpty(plan.Comment)
 	}
 	if !plan.Enabled.Equal(state.Enabled) {
-		updateData["enabled"] = plan.Enabled.ValueBool()
+		updateData.Enabled = truenas.Ptr(plan.Enabled.ValueBool())
 	}
 	if !plan.Home.Equal(state.Home) {
 		resp.Diagnostics.AddWarning("SMB Field Update Limitation", "The 'home' field cannot be updated after creation in TrueNAS Scale 25. Recreate the share to change this value.")
 	}
 	if !plan.Purpose.Equal(state.Purpose) {
-		updateData["purpose"] = plan.Purpose.ValueString()
+		updateData.Purpose = truenas.Ptr(plan.Purpose.ValueString())
 	}
 	if !plan.TimeMachine.Equal(state.TimeMachine) {
 		resp.Diagnostics.AddWarning("SMB Field Update Limitation", "The 'timemachine' field cannot be updated after creation in TrueNAS Scale 25. Recreate the share to change this value.")
@@ -439,7 +426,7 @@ func (r *ShareSMBResource) Update(ctx context.Context, req resource.UpdateReques
 			diags = plan.HostsAllow.ElementsAs(ctx, &hosts, false)
 			resp.Diagnostics.Append(diags...)
 		}
-		updateData["hostsallow"] = hosts
+		updateData.Hostsallow = &hosts
 	}
 	if !plan.HostsDeny.Equal(state.HostsDeny) {
 		var hosts []string
@@ -447,35 +434,31 @@ func (r *ShareSMBResource) Update(ctx context.Context, req resource.UpdateReques
 			diags = plan.HostsDeny.ElementsAs(ctx, &hosts, false)
 			resp.Diagnostics.Append(diags...)
 		}
-		updateData["hostsdeny"] = hosts
+		updateData.Hostsdeny = &hosts
 	}
 	if !plan.AuxSMBConf.Equal(state.AuxSMBConf) {
-		if plan.AuxSMBConf.IsNull() {
-			updateData["auxsmbconf"] = ""
-		} else {
-			updateData["auxsmbconf"] = plan.AuxSMBConf.ValueString()
-		}
+		updateData.Auxsmbconf = stringOrEmpty(plan.AuxSMBConf)
 	}
 	// Note: acl, durablehandle, shadowcopy, streams, fsrvp, and audit_logging
 	// cannot be updated after creation in TrueNAS Scale 25
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "sharing.smb", state.ID.ValueInt64(), updateData, &result)
+	if updateData != (truenas.SMBShareRequest{}) {
+		// The share may move to another pool; hold both
+		unlock := lockParents(ctx, r.client, &resp.Diagnostics, poolLockKey(state.Path.ValueString()), poolLockKey(plan.Path.ValueString()))
+		if unlock == nil {
+			return
+		}
+		defer unlock()
+
+		err := r.client.Update(ctx, "sharing.smb", state.ID.ValueInt64(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Updating SMB Share",
-				"Could not update SMB share: "+err.Error(),
-			)
+			addClientError(&resp.Diagnostics, smbShareFields, "Error Updating SMB Share", "Could not update SMB share", err)
 			return
 		}
 	}
 
 	if err := r.readShare(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading SMB Share",
-			"Could not read SMB share after update: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, smbShareFields, "Error Reading SMB Share", "Could not read SMB share after update", err)
 		return
 	}
 
@@ -491,16 +474,21 @@ func (r *ShareSMBResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_share_smb", state.ID)
+
 	tflog.Debug(ctx, "Deleting SMB share", map[string]interface{}{
 		"id": state.ID.ValueInt64(),
 	})
 
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(state.Path.ValueString()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
 	err := r.client.Delete(ctx, "sharing.smb", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Deleting SMB Share",
-			"Could not delete SMB share: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, smbShareFields, "Error Deleting SMB Share", "Could not delete SMB share", err)
 		return
 	}
 }
@@ -518,94 +506,86 @@ func (r *ShareSMBResource) ImportState(ctx context.Context, req resource.ImportS
 }
 
 func (r *ShareSMBResource) readShare(ctx context.Context, id int64, model *ShareSMBResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "sharing.smb", id, &result)
+	var share truenas.SMBShare
+	err := r.client.GetInstance(ctx, "sharing.smb", id, &share)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Path = types.StringValue(result["path"].(string))
-	model.Name = types.StringValue(result["name"].(string))
+	model.ID = types.Int64Value(share.ID)
+	model.Path = types.StringValue(share.Path)
+	model.Name = types.StringValue(share.Name)
 
-	if pathSuffix, ok := result["path_suffix"].(string); ok && pathSuffix != "" {
-		model.PathSuffix = types.StringValue(pathSuffix)
+	if share.PathSuffix != nil && *share.PathSuffix != "" {
+		model.PathSuffix = types.StringValue(*share.PathSuffix)
 	}
-	if comment, ok := result["comment"].(string); ok && comment != "" {
-		model.Comment = types.StringValue(comment)
+	if share.Comment != nil && *share.Comment != "" {
+		model.Comment = types.StringValue(*share.Comment)
 	} else if model.Comment.IsUnknown() {
 		model.Comment = types.StringNull()
 	}
-	if enabled, ok := result["enabled"].(bool); ok {
-		model.Enabled = types.BoolValue(enabled)
+	if share.Enabled != nil {
+		model.Enabled = types.BoolValue(*share.Enabled)
 	}
-	if home, ok := result["home"].(bool); ok {
-		model.Home = types.BoolValue(home)
+	if share.Home != nil {
+		model.Home = types.BoolValue(*share.Home)
 	}
-	if purpose, ok := result["purpose"].(string); ok {
-		model.Purpose = types.StringValue(purpose)
+	if share.Purpose != nil {
+		model.Purpose = types.StringValue(*share.Purpose)
 	}
-	if timemachine, ok := result["timemachine"].(bool); ok {
-		model.TimeMachine = types.BoolValue(timemachine)
+	if share.Timemachine != nil {
+		model.TimeMachine = types.BoolValue(*share.Timemachine)
 	}
-	if ro, ok := result["ro"].(bool); ok {
-		model.Ro = types.BoolValue(ro)
+	if share.Ro != nil {
+		model.Ro = types.BoolValue(*share.Ro)
 	}
-	if browsable, ok := result["browsable"].(bool); ok {
-		model.Browsable = types.BoolValue(browsable)
+	if share.Browsable != nil {
+		model.Browsable = types.BoolValue(*share.Browsable)
 	}
-	if recyclebin, ok := result["recyclebin"].(bool); ok {
-		model.Recyclebin = types.BoolValue(recyclebin)
+	if share.Recyclebin != nil {
+		model.Recyclebin = types.BoolValue(*share.Recyclebin)
 	}
-	if guestok, ok := result["guestok"].(bool); ok {
-		model.Guestok = types.BoolValue(guestok)
+	if share.Guestok != nil {
+		model.Guestok = types.BoolValue(*share.Guestok)
 	}
-	if abe, ok := result["abe"].(bool); ok {
-		model.Abe = types.BoolValue(abe)
+	if share.Abe != nil {
+		model.Abe = types.BoolValue(*share.Abe)
 	}
-	if hostsallow, ok := result["hostsallow"].([]interface{}); ok {
-		hosts := make([]string, len(hostsallow))
-		for i, h := range hostsallow {
-			hosts[i] = h.(string)
-		}
-		hostValues, diags := types.ListValueFrom(ctx, types.StringType, hosts)
+	if share.Hostsallow != nil {
+		hostValues, diags := types.ListValueFrom(ctx, types.StringType, share.Hostsallow)
 		if !diags.HasError() {
 			model.HostsAllow = hostValues
 		}
 	}
-	if hostsdeny, ok := result["hostsdeny"].([]interface{}); ok {
-		hosts := make([]string, len(hostsdeny))
-		for i, h := range hostsdeny {
-			hosts[i] = h.(string)
-		}
-		hostValues, diags := types.ListValueFrom(ctx, types.StringType, hosts)
+	if share.Hostsdeny != nil {
+		hostValues, diags := types.ListValueFrom(ctx, types.StringType, share.Hostsdeny)
 		if !diags.HasError() {
 			model.HostsDeny = hostValues
 		}
 	}
-	if auxsmbconf, ok := result["auxsmbconf"].(string); ok {
-		model.AuxSMBConf = types.StringValue(auxsmbconf)
+	if share.Auxsmbconf != nil {
+		model.AuxSMBConf = types.StringValue(*share.Auxsmbconf)
 	}
-	if acl, ok := result["acl"].(bool); ok {
-		model.Acl = types.BoolValue(acl)
+	if share.ACL != nil {
+		model.Acl = types.BoolValue(*share.ACL)
 	}
-	if durablehandle, ok := result["durablehandle"].(bool); ok {
-		model.Durablehandle = types.BoolValue(durablehandle)
+	if share.Durablehandle != nil {
+		model.Durablehandle = types.BoolValue(*share.Durablehandle)
 	}
-	if shadowcopy, ok := result["shadowcopy"].(bool); ok {
-		model.Shadowcopy = types.BoolValue(shadowcopy)
+	if share.Shadowcopy != nil {
+		model.Shadowcopy = types.BoolValue(*share.Shadowcopy)
 	}
-	if streams, ok := result["streams"].(bool); ok {
-		model.Streams = types.BoolValue(streams)
+	if share.Streams != nil {
+		model.Streams = types.BoolValue(*share.Streams)
 	}
-	if fsrvp, ok := result["fsrvp"].(bool); ok {
-		model.Fsrvp = types.BoolValue(fsrvp)
+	if share.Fsrvp != nil {
+		model.Fsrvp = types.BoolValue(*share.Fsrvp)
 	}
-	if auditLogging, ok := result["audit_logging"].(bool); ok {
-		model.AuditLogging = types.BoolValue(auditLogging)
+	if share.AuditLogging != nil {
+		model.AuditLogging = types.BoolValue(*share.AuditLogging)
 	}
-	if locked, ok := result["locked"].(bool); ok {
-		model.Locked = types.BoolValue(locked)
+	if share.Locked != nil {
+		model.Locked = types.BoolValue(*share.Locked)
 	}
 
 	return nil
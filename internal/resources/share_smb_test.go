@@ -0,0 +1,99 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadShareSMB(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		model   ShareSMBResourceModel
+		want    ShareSMBResourceModel
+		wantErr bool
+	}{
+		{
+			name: "time machine share",
+			payload: `{
+				"id": 5, "purpose": "TIMEMACHINE", "path": "/mnt/tank/backups", "path_suffix": "%U", "home": false,
+				"name": "backups", "comment": "Mac backups", "ro": false, "browsable": true, "timemachine": true,
+				"recyclebin": false, "guestok": false, "abe": false, "hostsallow": ["192.168.1.0/24"], "hostsdeny": [],
+				"auxsmbconf": "", "enabled": true, "acl": true, "durablehandle": true, "shadowcopy": true,
+				"streams": true, "fsrvp": false, "audit_logging": false, "locked": false
+			}`,
+			want: ShareSMBResourceModel{
+				ID:            types.Int64Value(5),
+				Path:          types.StringValue("/mnt/tank/backups"),
+				PathSuffix:    types.StringValue("%U"),
+				Name:          types.StringValue("backups"),
+				Comment:       types.StringValue("Mac backups"),
+				Enabled:       types.BoolValue(true),
+				Home:          types.BoolValue(false),
+				Purpose:       types.StringValue("TIMEMACHINE"),
+				TimeMachine:   types.BoolValue(true),
+				Ro:            types.BoolValue(false),
+				Browsable:     types.BoolValue(true),
+				Recyclebin:    types.BoolValue(false),
+				Guestok:       types.BoolValue(false),
+				Abe:           types.BoolValue(false),
+				HostsAllow:    stringList("192.168.1.0/24"),
+				HostsDeny:     stringList(),
+				AuxSMBConf:    types.StringValue(""),
+				Acl:           types.BoolValue(true),
+				Durablehandle: types.BoolValue(true),
+				Shadowcopy:    types.BoolValue(true),
+				Streams:       types.BoolValue(true),
+				Fsrvp:         types.BoolValue(false),
+				AuditLogging:  types.BoolValue(false),
+				Locked:        types.BoolValue(false),
+			},
+		},
+		{
+			name:    "empty comment after create",
+			payload: `{"id": 5, "path": "/mnt/tank/backups", "path_suffix": "", "name": "backups", "comment": ""}`,
+			model:   ShareSMBResourceModel{Comment: types.StringUnknown()},
+			want: ShareSMBResourceModel{
+				ID:      types.Int64Value(5),
+				Path:    types.StringValue("/mnt/tank/backups"),
+				Name:    types.StringValue("backups"),
+				Comment: types.StringNull(),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed flag",
+			payload: `{"id": 5, "path": "/mnt/tank/backups", "name": "backups", "ro": "no"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ShareSMBResource{client: newFakeAPI("sharing.smb", 5, tt.payload)}
+
+			model := tt.model
+			err := r.readShare(context.Background(), 5, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readShare() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readShare() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readShare() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -14,9 +15,13 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
+// snapshotDeleteTimeout bounds snapshot deletion, which can take minutes
+// when recursive
+const snapshotDeleteTimeout = 5 * time.Minute
+
 var (
 	_ resource.Resource                = &SnapshotResource{}
 	_ resource.ResourceWithImportState = &SnapshotResource{}
@@ -27,7 +32,7 @@ func NewSnapshotResource() resource.Resource {
 }
 
 type SnapshotResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type SnapshotResourceModel struct {
@@ -43,6 +48,16 @@ type SnapshotResourceModel struct {
 	CreationTime       types.String `tfsdk:"creation_time"`
 }
 
+// snapshotFields maps the fields of zfs.snapshot.create and zfs.snapshot.update to schema attributes
+var snapshotFields = apiFields{
+	"dataset":                path.Root("dataset"),
+	"name":                   path.Root("name"),
+	"recursive":              path.Root("recursive"),
+	"vmware_sync":            path.Root("vmware_sync"),
+	"properties":             path.Root("properties"),
+	"user_properties_update": path.Root("properties"),
+}
+
 func (r *SnapshotResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_snapshot"
 }
@@ -115,11 +130,11 @@ func (r *SnapshotResource) Configure(ctx context.Context, req resource.Configure
 		return
 	}
 
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData),
+			fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData),
 		)
 		return
 	}
@@ -128,6 +143,8 @@ func (r *SnapshotResource) Configure(ctx context.Context, req resource.Configure
 }
 
 func (r *SnapshotResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_snapshot", nil)
+
 	var plan SnapshotResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -141,42 +158,36 @@ func (r *SnapshotResource) Create(ctx context.Context, req resource.CreateReques
 		"id": snapshotID,
 	})
 
-	createData := map[string]interface{}{
-		"dataset":   plan.Dataset.ValueString(),
-		"name":      plan.Name.ValueString(),
-		"recursive": plan.Recursive.ValueBool(),
-	}
-
-	if !plan.VMWareSync.IsNull() {
-		createData["vmware_sync"] = plan.VMWareSync.ValueBool()
+	createData := truenas.SnapshotCreateRequest{
+		Dataset:    plan.Dataset.ValueString(),
+		Name:       plan.Name.ValueString(),
+		Recursive:  plan.Recursive.ValueBool(),
+		VMWareSync: plan.VMWareSync.ValueBoolPointer(),
 	}
 
 	if !plan.Properties.IsNull() {
-		var props map[string]string
-		diags = plan.Properties.ElementsAs(ctx, &props, false)
+		diags = plan.Properties.ElementsAs(ctx, &createData.Properties, false)
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
 		}
-		createData["properties"] = props
 	}
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "zfs.snapshot", createData, &result)
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(plan.Dataset.ValueString()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
+	err := r.client.Create(ctx, "zfs.snapshot", createData, nil)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Creating Snapshot",
-			"Could not create snapshot: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, snapshotFields, "Error Creating Snapshot", "Could not create snapshot", err)
 		return
 	}
 
 	// Read the created snapshot
 	if err := r.readSnapshot(ctx, snapshotID, &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading Snapshot",
-			"Could not read snapshot after creation: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, snapshotFields, "Error Reading Snapshot", "Could not read snapshot after creation", err)
 		return
 	}
 
@@ -192,15 +203,14 @@ func (r *SnapshotResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_snapshot", state.ID)
+
 	if err := r.readSnapshot(ctx, state.ID.ValueString(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
-			"Error Reading Snapshot",
-			"Could not read snapshot: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, snapshotFields, "Error Reading Snapshot", "Could not read snapshot", err)
 		return
 	}
 
@@ -223,37 +233,34 @@ func (r *SnapshotResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_snapshot", state.ID)
+
 	// Snapshots have very limited update capabilities
 	// Properties might be updatable
 	if !plan.Properties.Equal(state.Properties) && !plan.Properties.IsNull() {
-		var props map[string]string
-		diags = plan.Properties.ElementsAs(ctx, &props, false)
+		var updateData truenas.SnapshotUpdateRequest
+		diags = plan.Properties.ElementsAs(ctx, &updateData.UserPropertiesUpdate, false)
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
 		}
 
-		updateData := map[string]interface{}{
-			"user_properties_update": props,
+		unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(state.ID.ValueString()))
+		if unlock == nil {
+			return
 		}
+		defer unlock()
 
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "zfs.snapshot", state.ID.ValueString(), updateData, &result)
+		err := r.client.Update(ctx, "zfs.snapshot", state.ID.ValueString(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Updating Snapshot",
-				"Could not update snapshot: "+err.Error(),
-			)
+			addClientError(&resp.Diagnostics, snapshotFields, "Error Updating Snapshot", "Could not update snapshot", err)
 			return
 		}
 	}
 
 	// Read the updated snapshot
 	if err := r.readSnapshot(ctx, state.ID.ValueString(), &plan); err != nil {
-		resp.Diagnostics.AddError(
-			"Error Reading Snapshot",
-			"Could not read snapshot after update: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, snapshotFields, "Error Reading Snapshot", "Could not read snapshot after update", err)
 		return
 	}
 
@@ -269,20 +276,26 @@ func (r *SnapshotResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_snapshot", state.ID)
+
 	tflog.Debug(ctx, "Deleting snapshot", map[string]interface{}{
 		"id": state.ID.ValueString(),
 	})
 
-	deleteOptions := map[string]interface{}{
-		"recursive": state.Recursive.ValueBool(),
+	deleteOptions := truenas.SnapshotDeleteOptions{
+		Recursive: state.Recursive.ValueBool(),
 	}
 
-	err := r.client.DeleteWithOptions(ctx, "zfs.snapshot", state.ID.ValueString(), deleteOptions)
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, poolLockKey(state.ID.ValueString()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
+	// Recursive deletes of large snapshot trees outlast the request timeout
+	err := r.client.DeleteWithOptions(ctx, "zfs.snapshot", state.ID.ValueString(), deleteOptions, truenas.WithTimeout(snapshotDeleteTimeout))
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Deleting Snapshot",
-			"Could not delete snapshot: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, snapshotFields, "Error Deleting Snapshot", "Could not delete snapshot", err)
 		return
 	}
 }
@@ -292,13 +305,13 @@ func (r *SnapshotResource) ImportState(ctx context.Context, req resource.ImportS
 }
 
 func (r *SnapshotResource) readSnapshot(ctx context.Context, id string, model *SnapshotResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "zfs.snapshot", id, &result)
+	var snapshot truenas.Snapshot
+	err := r.client.GetInstance(ctx, "zfs.snapshot", id, &snapshot)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.StringValue(result["id"].(string))
+	model.ID = types.StringValue(snapshot.ID)
 
 	// Parse dataset and name from the ID
 	parts := strings.SplitN(id, "@", 2)
@@ -307,48 +320,32 @@ func (r *SnapshotResource) readSnapshot(ctx context.Context, id string, model *S
 		model.Name = types.StringValue(parts[1])
 	}
 
-	if holds, ok := result["holds"].([]interface{}); ok && len(holds) > 0 {
-		holdsList := make([]string, len(holds))
-		for i, h := range holds {
-			holdsList[i] = h.(string)
-		}
-		holdValues, diags := types.ListValueFrom(ctx, types.StringType, holdsList)
-		if !diags.HasError() {
-			model.Holds = holdValues
-		}
-	} else {
+	holds := []string(snapshot.Holds)
+	if holds == nil {
 		// Set empty list when no holds
-		emptyHolds, _ := types.ListValueFrom(ctx, types.StringType, []string{})
-		model.Holds = emptyHolds
+		holds = []string{}
+	}
+	holdValues, diags := types.ListValueFrom(ctx, types.StringType, holds)
+	if !diags.HasError() {
+		model.Holds = holdValues
 	}
 
-	if properties, ok := result["properties"].(map[string]interface{}); ok {
-		if referenced, ok := properties["referenced"].(map[string]interface{}); ok {
-			if parsed, ok := referenced["parsed"].(float64); ok {
-				model.ReferencedBytes = types.Int64Value(int64(parsed))
-			}
-		}
-		if used, ok := properties["used"].(map[string]interface{}); ok {
-			if parsed, ok := used["parsed"].(float64); ok {
-				model.UsedBytes = types.Int64Value(int64(parsed))
-			}
-		}
-		if creation, ok := properties["creation"].(map[string]interface{}); ok {
-			// creation.parsed can be either a string or a timestamp
-			if parsed, ok := creation["parsed"].(string); ok {
-				model.CreationTime = types.StringValue(parsed)
-			} else if rawValue, ok := creation["rawvalue"].(string); ok {
-				model.CreationTime = types.StringValue(rawValue)
-			} else if value, ok := creation["value"].(string); ok {
-				model.CreationTime = types.StringValue(value)
-			} else {
-				model.CreationTime = types.StringNull()
-			}
-		} else {
-			model.CreationTime = types.StringNull()
+	if referenced, ok := snapshot.Properties["referenced"].Int64Value(); ok {
+		model.ReferencedBytes = types.Int64Value(referenced)
+	}
+	if used, ok := snapshot.Properties["used"].Int64Value(); ok {
+		model.UsedBytes = types.Int64Value(used)
+	}
+	model.CreationTime = types.StringNull()
+	if creation := snapshot.Properties["creation"]; creation != nil {
+		// creation.parsed can be either a string or a timestamp
+		if parsed, ok := creation.Parsed.(string); ok {
+			model.CreationTime = types.StringValue(parsed)
+		} else if creation.Rawvalue != nil {
+			model.CreationTime = types.StringValue(*creation.Rawvalue)
+		} else if creation.Value != nil {
+			model.CreationTime = types.StringValue(*creation.Value)
 		}
-	} else {
-		model.CreationTime = types.StringNull()
 	}
 
 	return nil
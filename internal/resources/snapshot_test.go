@@ -0,0 +1,85 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadSnapshot(t *testing.T) {
+	const id = "tank/media@daily-2025-06-02"
+
+	tests := []struct {
+		name    string
+		payload string
+		want    SnapshotResourceModel
+		wantErr bool
+	}{
+		{
+			name: "held snapshot",
+			payload: `{
+				"id": "tank/media@daily-2025-06-02", "name": "tank/media@daily-2025-06-02",
+				"dataset": "tank/media", "snapshot_name": "daily-2025-06-02",
+				"holds": {"backup": "Mon Jun  2 03:00 2025"},
+				"properties": {
+					"referenced": {"value": "1.50G", "rawvalue": "1610612736", "parsed": 1610612736, "source": "NONE"},
+					"used": {"value": "128K", "rawvalue": "131072", "parsed": 131072, "source": "NONE"},
+					"creation": {"value": "Mon Jun  2 03:00 2025", "rawvalue": "1748833200", "parsed": {"$date": 1748833200000}, "source": "NONE"}
+				}
+			}`,
+			want: SnapshotResourceModel{
+				ID:              types.StringValue(id),
+				Dataset:         types.StringValue("tank/media"),
+				Name:            types.StringValue("daily-2025-06-02"),
+				Holds:           stringList("backup"),
+				ReferencedBytes: types.Int64Value(1610612736),
+				UsedBytes:       types.Int64Value(131072),
+				CreationTime:    types.StringValue("1748833200"),
+			},
+		},
+		{
+			name:    "no holds or properties",
+			payload: `{"id": "tank/media@daily-2025-06-02", "name": "tank/media@daily-2025-06-02", "holds": {}}`,
+			want: SnapshotResourceModel{
+				ID:      types.StringValue(id),
+				Dataset: types.StringValue("tank/media"),
+				Name:    types.StringValue("daily-2025-06-02"),
+				Holds:   stringList(),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed holds",
+			payload: `{"id": "tank/media@daily-2025-06-02", "holds": "backup"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SnapshotResource{client: newFakeAPI("zfs.snapshot", id, tt.payload)}
+
+			var model SnapshotResourceModel
+			err := r.readSnapshot(context.Background(), id, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readSnapshot() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readSnapshot() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readSnapshot() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -24,7 +24,7 @@ func NewStaticRouteResource() resource.Resource {
 }
 
 type StaticRouteResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type StaticRouteResourceModel struct {
@@ -34,6 +34,13 @@ type StaticRouteResourceModel struct {
 	Description types.String `tfsdk:"description"`
 }
 
+// staticRouteFields maps the fields of staticroute.create and staticroute.update to schema attributes
+var staticRouteFields = apiFields{
+	"destination": path.Root("destination"),
+	"gateway":     path.Root("gateway"),
+	"description": path.Root("description"),
+}
+
 func (r *StaticRouteResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_static_route"
 }
@@ -66,15 +73,17 @@ func (r *StaticRouteResource) Configure(ctx context.Context, req resource.Config
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *StaticRouteResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_static_route", nil)
+
 	var plan StaticRouteResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -87,25 +96,21 @@ func (r *StaticRouteResource) Create(ctx context.Context, req resource.CreateReq
 		"gateway":     plan.Gateway.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"destination": plan.Destination.ValueString(),
-		"gateway":     plan.Gateway.ValueString(),
+	createData := truenas.StaticRouteRequest{
+		Destination: truenas.Ptr(plan.Destination.ValueString()),
+		Gateway:     truenas.Ptr(plan.Gateway.ValueString()),
+		Description: plan.Description.ValueStringPointer(),
 	}
 
-	if !plan.Description.IsNull() {
-		createData["description"] = plan.Description.ValueString()
-	}
-
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "staticroute", createData, &result)
+	var route truenas.StaticRoute
+	err := r.client.Create(ctx, "staticroute", createData, &route)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating Static Route", "Could not create static route: "+err.Error())
+		addClientError(&resp.Diagnostics, staticRouteFields, "Error Creating Static Route", "Could not create static route", err)
 		return
 	}
 
-	routeID := int64(result["id"].(float64))
-	if err := r.readStaticRoute(ctx, routeID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading Static Route", "Could not read static route after creation: "+err.Error())
+	if err := r.readStaticRoute(ctx, route.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, staticRouteFields, "Error Reading Static Route", "Could not read static route after creation", err)
 		return
 	}
 
@@ -121,12 +126,14 @@ func (r *StaticRouteResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_static_route", state.ID)
+
 	if err := r.readStaticRoute(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading Static Route", "Could not read static route: "+err.Error())
+		addClientError(&resp.Diagnostics, staticRouteFields, "Error Reading Static Route", "Could not read static route", err)
 		return
 	}
 
@@ -149,33 +156,30 @@ func (r *StaticRouteResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
-	updateData := map[string]interface{}{}
+	ctx = withAddress(ctx, "trueform_static_route", state.ID)
+
+	var updateData truenas.StaticRouteRequest
 
 	if !plan.Destination.Equal(state.Destination) {
-		updateData["destination"] = plan.Destination.ValueString()
+		updateData.Destination = truenas.Ptr(plan.Destination.ValueString())
 	}
 	if !plan.Gateway.Equal(state.Gateway) {
-		updateData["gateway"] = plan.Gateway.ValueString()
+		updateData.Gateway = truenas.Ptr(plan.Gateway.ValueString())
 	}
 	if !plan.Description.Equal(state.Description) {
-		if plan.Description.IsNull() {
-			updateData["description"] = ""
-		} else {
-			updateData["description"] = plan.Description.ValueString()
-		}
+		updateData.Description = stringOrEmpty(plan.Description)
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "staticroute", state.ID.ValueInt64(), updateData, &result)
+	if updateData != (truenas.StaticRouteRequest{}) {
+		err := r.client.Update(ctx, "staticroute", state.ID.ValueInt64(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError("Error Updating Static Route", "Could not update static route: "+err.Error())
+			addClientError(&resp.Diagnostics, staticRouteFields, "Error Updating Static Route", "Could not update static route", err)
 			return
 		}
 	}
 
 	if err := r.readStaticRoute(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading Static Route", "Could not read static route after update: "+err.Error())
+		addClientError(&resp.Diagnostics, staticRouteFields, "Error Reading Static Route", "Could not read static route after update", err)
 		return
 	}
 
@@ -191,9 +195,11 @@ func (r *StaticRouteResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_static_route", state.ID)
+
 	err := r.client.Delete(ctx, "staticroute", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting Static Route", "Could not delete static route: "+err.Error())
+		addClientError(&resp.Diagnostics, staticRouteFields, "Error Deleting Static Route", "Could not delete static route", err)
 		return
 	}
 }
@@ -211,18 +217,18 @@ func (r *StaticRouteResource) ImportState(ctx context.Context, req resource.Impo
 }
 
 func (r *StaticRouteResource) readStaticRoute(ctx context.Context, id int64, model *StaticRouteResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "staticroute", id, &result)
+	var route truenas.StaticRoute
+	err := r.client.GetInstance(ctx, "staticroute", id, &route)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Destination = types.StringValue(result["destination"].(string))
-	model.Gateway = types.StringValue(result["gateway"].(string))
+	model.ID = types.Int64Value(route.ID)
+	model.Destination = types.StringValue(route.Destination)
+	model.Gateway = types.StringValue(route.Gateway)
 
-	if description, ok := result["description"].(string); ok {
-		model.Description = types.StringValue(description)
+	if route.Description != nil {
+		model.Description = types.StringValue(*route.Description)
 	}
 
 	return nil
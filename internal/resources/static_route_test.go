@@ -0,0 +1,70 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadStaticRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    StaticRouteResourceModel
+		wantErr bool
+	}{
+		{
+			name:    "route with description",
+			payload: `{"id": 2, "destination": "10.20.0.0/16", "gateway": "192.168.1.254", "description": "Lab network"}`,
+			want: StaticRouteResourceModel{
+				ID:          types.Int64Value(2),
+				Destination: types.StringValue("10.20.0.0/16"),
+				Gateway:     types.StringValue("192.168.1.254"),
+				Description: types.StringValue("Lab network"),
+			},
+		},
+		{
+			name:    "route without description",
+			payload: `{"id": 2, "destination": "10.20.0.0/16", "gateway": "192.168.1.254", "description": null}`,
+			want: StaticRouteResourceModel{
+				ID:          types.Int64Value(2),
+				Destination: types.StringValue("10.20.0.0/16"),
+				Gateway:     types.StringValue("192.168.1.254"),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed gateway",
+			payload: `{"id": 2, "destination": "10.20.0.0/16", "gateway": ["192.168.1.254"]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &StaticRouteResource{client: newFakeAPI("staticroute", 2, tt.payload)}
+
+			var model StaticRouteResourceModel
+			err := r.readStaticRoute(context.Background(), 2, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readStaticRoute() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readStaticRoute() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readStaticRoute() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -28,7 +28,7 @@ func NewUserResource() resource.Resource {
 }
 
 type UserResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type UserResourceModel struct {
@@ -55,6 +55,26 @@ type UserResourceModel struct {
 	Builtin          types.Bool   `tfsdk:"builtin"`
 }
 
+// userFields maps the fields of user.create and user.update to schema attributes
+var userFields = apiFields{
+	"uid":               path.Root("uid"),
+	"username":          path.Root("username"),
+	"full_name":         path.Root("full_name"),
+	"email":             path.Root("email"),
+	"password":          path.Root("password"),
+	"password_disabled": path.Root("password_disabled"),
+	"group":             path.Root("group"),
+	"group_create":      path.Root("group_create"),
+	"groups":            path.Root("groups"),
+	"home":              path.Root("home"),
+	"home_mode":         path.Root("home_mode"),
+	"home_create":       path.Root("home_create"),
+	"shell":             path.Root("shell"),
+	"sshpubkey":         path.Root("sshpubkey"),
+	"locked":            path.Root("locked"),
+	"smb":               path.Root("smb"),
+}
+
 func (r *UserResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_user"
 }
@@ -183,15 +203,17 @@ func (r *UserResource) Configure(ctx context.Context, req resource.ConfigureRequ
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *UserResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_user", nil)
+
 	var plan UserResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -203,61 +225,50 @@ func (r *UserResource) Create(ctx context.Context, req resource.CreateRequest, r
 		"username": plan.Username.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"username":          plan.Username.ValueString(),
-		"home":              plan.Home.ValueString(),
-		"shell":             plan.Shell.ValueString(),
-		"password_disabled": plan.PasswordDisabled.ValueBool(),
-		"locked":            plan.Locked.ValueBool(),
-		"smb":               plan.SMB.ValueBool(),
+	createData := truenas.UserRequest{
+		Username:         truenas.Ptr(plan.Username.ValueString()),
+		Home:             truenas.Ptr(plan.Home.ValueString()),
+		Shell:            truenas.Ptr(plan.Shell.ValueString()),
+		PasswordDisabled: truenas.Ptr(plan.PasswordDisabled.ValueBool()),
+		Locked:           truenas.Ptr(plan.Locked.ValueBool()),
+		SMB:              truenas.Ptr(plan.SMB.ValueBool()),
+		// Use group_create to create a new group for the user (defaults to true)
+		GroupCreate: truenas.Ptr(plan.GroupCreate.ValueBool()),
+		FullName:    plan.FullName.ValueStringPointer(),
+		Email:       plan.Email.ValueStringPointer(),
+		HomeMode:    plan.HomeMode.ValueStringPointer(),
+		HomeCreate:  plan.HomeCreate.ValueBoolPointer(),
+		SSHPubKey:   plan.SSHPubKey.ValueStringPointer(),
 	}
 
 	// Only set uid if explicitly provided (non-null and non-zero)
 	if !plan.UID.IsNull() && !plan.UID.IsUnknown() && plan.UID.ValueInt64() != 0 {
-		createData["uid"] = plan.UID.ValueInt64()
-	}
-	if !plan.FullName.IsNull() {
-		createData["full_name"] = plan.FullName.ValueString()
+		createData.UID = truenas.Ptr(plan.UID.ValueInt64())
 	}
-	if !plan.Email.IsNull() {
-		createData["email"] = plan.Email.ValueString()
+	if plan.Password.ValueString() != "" {
+		createData.Password = truenas.Ptr(plan.Password.ValueString())
 	}
-	if !plan.Password.IsNull() && plan.Password.ValueString() != "" {
-		createData["password"] = plan.Password.ValueString()
+	if plan.Group.ValueInt64() != 0 {
+		createData.Group = truenas.Ptr(plan.Group.ValueInt64())
 	}
-	if !plan.Group.IsNull() && plan.Group.ValueInt64() != 0 {
-		createData["group"] = plan.Group.ValueInt64()
-	}
-	// Use group_create to create a new group for the user (defaults to true)
-	createData["group_create"] = plan.GroupCreate.ValueBool()
 	if !plan.Groups.IsNull() {
 		var groups []int64
 		diags = plan.Groups.ElementsAs(ctx, &groups, false)
 		resp.Diagnostics.Append(diags...)
 		if !resp.Diagnostics.HasError() {
-			createData["groups"] = groups
+			createData.Groups = &groups
 		}
 	}
-	if !plan.HomeMode.IsNull() {
-		createData["home_mode"] = plan.HomeMode.ValueString()
-	}
-	if !plan.HomeCreate.IsNull() {
-		createData["home_create"] = plan.HomeCreate.ValueBool()
-	}
-	if !plan.SSHPubKey.IsNull() {
-		createData["sshpubkey"] = plan.SSHPubKey.ValueString()
-	}
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "user", createData, &result)
+	var user truenas.User
+	err := r.client.Create(ctx, "user", createData, &user)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating User", "Could not create user: "+err.Error())
+		addClientError(&resp.Diagnostics, userFields, "Error Creating User", "Could not create user", err)
 		return
 	}
 
-	userID := int64(result["id"].(float64))
-	if err := r.readUser(ctx, userID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading User", "Could not read user after creation: "+err.Error())
+	if err := r.readUser(ctx, user.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, userFields, "Error Reading User", "Could not read user after creation", err)
 		return
 	}
 
@@ -273,12 +284,14 @@ func (r *UserResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_user", state.ID)
+
 	if err := r.readUser(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading User", "Could not read user: "+err.Error())
+		addClientError(&resp.Diagnostics, userFields, "Error Reading User", "Could not read user", err)
 		return
 	}
 
@@ -301,31 +314,25 @@ func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	updateData := map[string]interface{}{}
+	ctx = withAddress(ctx, "trueform_user", state.ID)
+
+	var updateData truenas.UserRequest
 
 	if !plan.FullName.Equal(state.FullName) {
-		if plan.FullName.IsNull() {
-			updateData["full_name"] = ""
-		} else {
-			updateData["full_name"] = plan.FullName.ValueString()
-		}
+		updateData.FullName = stringOrEmpty(plan.FullName)
 	}
 	if !plan.Email.Equal(state.Email) {
-		if plan.Email.IsNull() {
-			updateData["email"] = ""
-		} else {
-			updateData["email"] = plan.Email.ValueString()
-		}
+		updateData.Email = stringOrEmpty(plan.Email)
 	}
-	if !plan.Password.Equal(state.Password) && !plan.Password.IsNull() && plan.Password.ValueString() != "" {
-		updateData["password"] = plan.Password.ValueString()
+	if !plan.Password.Equal(state.Password) && plan.Password.ValueString() != "" {
+		updateData.Password = truenas.Ptr(plan.Password.ValueString())
 	}
 	if !plan.PasswordDisabled.Equal(state.PasswordDisabled) {
-		updateData["password_disabled"] = plan.PasswordDisabled.ValueBool()
+		updateData.PasswordDisabled = truenas.Ptr(plan.PasswordDisabled.ValueBool())
 	}
 	// Only update group if explicitly set to a valid non-zero value
 	if !plan.Group.Equal(state.Group) && !plan.Group.IsNull() && !plan.Group.IsUnknown() && plan.Group.ValueInt64() > 0 {
-		updateData["group"] = plan.Group.ValueInt64()
+		updateData.Group = truenas.Ptr(plan.Group.ValueInt64())
 	}
 	// Only update groups if explicitly set
 	if !plan.Groups.Equal(state.Groups) && !plan.Groups.IsNull() && !plan.Groups.IsUnknown() {
@@ -333,43 +340,38 @@ func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		diags = plan.Groups.ElementsAs(ctx, &groups, false)
 		resp.Diagnostics.Append(diags...)
 		if len(groups) > 0 {
-			updateData["groups"] = groups
+			updateData.Groups = &groups
 		}
 	}
 	if !plan.Home.Equal(state.Home) {
-		updateData["home"] = plan.Home.ValueString()
+		updateData.Home = truenas.Ptr(plan.Home.ValueString())
 	}
 	if !plan.HomeMode.Equal(state.HomeMode) {
-		updateData["home_mode"] = plan.HomeMode.ValueString()
+		updateData.HomeMode = truenas.Ptr(plan.HomeMode.ValueString())
 	}
 	if !plan.Shell.Equal(state.Shell) {
-		updateData["shell"] = plan.Shell.ValueString()
+		updateData.Shell = truenas.Ptr(plan.Shell.ValueString())
 	}
 	if !plan.SSHPubKey.Equal(state.SSHPubKey) {
-		if plan.SSHPubKey.IsNull() {
-			updateData["sshpubkey"] = ""
-		} else {
-			updateData["sshpubkey"] = plan.SSHPubKey.ValueString()
-		}
+		updateData.SSHPubKey = stringOrEmpty(plan.SSHPubKey)
 	}
 	if !plan.Locked.Equal(state.Locked) {
-		updateData["locked"] = plan.Locked.ValueBool()
+		updateData.Locked = truenas.Ptr(plan.Locked.ValueBool())
 	}
 	if !plan.SMB.Equal(state.SMB) {
-		updateData["smb"] = plan.SMB.ValueBool()
+		updateData.SMB = truenas.Ptr(plan.SMB.ValueBool())
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "user", state.ID.ValueInt64(), updateData, &result)
+	if updateData != (truenas.UserRequest{}) {
+		err := r.client.Update(ctx, "user", state.ID.ValueInt64(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError("Error Updating User", "Could not update user: "+err.Error())
+			addClientError(&resp.Diagnostics, userFields, "Error Updating User", "Could not update user", err)
 			return
 		}
 	}
 
 	if err := r.readUser(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading User", "Could not read user after update: "+err.Error())
+		addClientError(&resp.Diagnostics, userFields, "Error Reading User", "Could not read user after update", err)
 		return
 	}
 
@@ -385,9 +387,11 @@ func (r *UserResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_user", state.ID)
+
 	err := r.client.Delete(ctx, "user", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting User", "Could not delete user: "+err.Error())
+		addClientError(&resp.Diagnostics, userFields, "Error Deleting User", "Could not delete user", err)
 		return
 	}
 }
@@ -405,40 +409,34 @@ func (r *UserResource) ImportState(ctx context.Context, req resource.ImportState
 }
 
 func (r *UserResource) readUser(ctx context.Context, id int64, model *UserResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "user", id, &result)
+	var user truenas.User
+	err := r.client.GetInstance(ctx, "user", id, &user)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.UID = types.Int64Value(int64(result["uid"].(float64)))
-	model.Username = types.StringValue(result["username"].(string))
+	model.ID = types.Int64Value(user.ID)
+	model.UID = types.Int64Value(user.UID)
+	model.Username = types.StringValue(user.Username)
 
-	if fullName, ok := result["full_name"].(string); ok {
-		model.FullName = types.StringValue(fullName)
+	if user.FullName != nil {
+		model.FullName = types.StringValue(*user.FullName)
 	}
-	if email, ok := result["email"].(string); ok {
-		model.Email = types.StringValue(email)
+	if user.Email != nil {
+		model.Email = types.StringValue(*user.Email)
 	}
-	if passwordDisabled, ok := result["password_disabled"].(bool); ok {
-		model.PasswordDisabled = types.BoolValue(passwordDisabled)
+	if user.PasswordDisabled != nil {
+		model.PasswordDisabled = types.BoolValue(*user.PasswordDisabled)
 	}
-	if group, ok := result["group"].(map[string]interface{}); ok {
-		if gid, ok := group["id"].(float64); ok {
-			model.Group = types.Int64Value(int64(gid))
-		}
+	if user.Group != nil {
+		model.Group = types.Int64Value(user.Group.ID)
 	}
-	if groups, ok := result["groups"].([]interface{}); ok && len(groups) > 0 {
+	if len(user.Groups) > 0 {
 		var groupIDs []int64
-		for _, g := range groups {
-			if gmap, ok := g.(map[string]interface{}); ok {
-				if gid, ok := gmap["id"].(float64); ok {
-					// Filter out invalid group IDs (0 or negative)
-					if int64(gid) > 0 {
-						groupIDs = append(groupIDs, int64(gid))
-					}
-				}
+		for _, g := range user.Groups {
+			// Filter out invalid group IDs (0 or negative)
+			if g.ID > 0 {
+				groupIDs = append(groupIDs, g.ID)
 			}
 		}
 		// Only set groups if there are valid group IDs
@@ -449,39 +447,35 @@ func (r *UserResource) readUser(ctx context.Context, id int64, model *UserResour
 			}
 		}
 	}
-	if home, ok := result["home"].(string); ok {
-		model.Home = types.StringValue(home)
+	if user.Home != nil {
+		model.Home = types.StringValue(*user.Home)
 	}
-	if shell, ok := result["shell"].(string); ok {
-		model.Shell = types.StringValue(shell)
+	if user.Shell != nil {
+		model.Shell = types.StringValue(*user.Shell)
 	}
-	if sshpubkey, ok := result["sshpubkey"].(string); ok {
-		model.SSHPubKey = types.StringValue(sshpubkey)
+	if user.SSHPubKey != nil {
+		model.SSHPubKey = types.StringValue(*user.SSHPubKey)
 	}
-	if locked, ok := result["locked"].(bool); ok {
-		model.Locked = types.BoolValue(locked)
+	if user.Locked != nil {
+		model.Locked = types.BoolValue(*user.Locked)
 	}
-	if smb, ok := result["smb"].(bool); ok {
-		model.SMB = types.BoolValue(smb)
+	if user.SMB != nil {
+		model.SMB = types.BoolValue(*user.SMB)
 	}
-	if sudo, ok := result["sudo"].(bool); ok {
-		model.Sudo = types.BoolValue(sudo)
+	if user.Sudo != nil {
+		model.Sudo = types.BoolValue(*user.Sudo)
 	}
-	if sudoNopasswd, ok := result["sudo_nopasswd"].(bool); ok {
-		model.SudoNopasswd = types.BoolValue(sudoNopasswd)
+	if user.SudoNopasswd != nil {
+		model.SudoNopasswd = types.BoolValue(*user.SudoNopasswd)
 	}
-	if sudoCommands, ok := result["sudo_commands"].([]interface{}); ok && len(sudoCommands) > 0 {
-		commands := make([]string, len(sudoCommands))
-		for i, cmd := range sudoCommands {
-			commands[i] = cmd.(string)
-		}
-		cmdValues, diags := types.ListValueFrom(ctx, types.StringType, commands)
+	if len(user.SudoCommands) > 0 {
+		cmdValues, diags := types.ListValueFrom(ctx, types.StringType, user.SudoCommands)
 		if !diags.HasError() {
 			model.SudoCommands = cmdValues
 		}
 	}
-	if builtin, ok := result["builtin"].(bool); ok {
-		model.Builtin = types.BoolValue(builtin)
+	if user.Builtin != nil {
+		model.Builtin = types.BoolValue(*user.Builtin)
 	}
 
 	return nil
@@ -0,0 +1,93 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    UserResourceModel
+		wantErr bool
+	}{
+		{
+			name: "local user",
+			payload: `{
+				"id": 71, "uid": 3000, "username": "backup", "unixhash": "*", "smbhash": "*",
+				"home": "/mnt/tank/home/backup", "shell": "/usr/bin/bash", "full_name": "Backup Operator",
+				"builtin": false, "smb": true, "password_disabled": false, "ssh_password_enabled": false,
+				"sshpubkey": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHZ backup@lan", "locked": false,
+				"sudo_commands": ["/usr/sbin/zfs"], "sudo_commands_nopasswd": [], "email": "backup@example.com",
+				"group": {"id": 41, "bsdgrp_gid": 3000, "bsdgrp_group": "backup"},
+				"groups": [42, 0, 45]
+			}`,
+			want: UserResourceModel{
+				ID:               types.Int64Value(71),
+				UID:              types.Int64Value(3000),
+				Username:         types.StringValue("backup"),
+				FullName:         types.StringValue("Backup Operator"),
+				Email:            types.StringValue("backup@example.com"),
+				PasswordDisabled: types.BoolValue(false),
+				Group:            types.Int64Value(41),
+				Groups:           types.ListValueMust(types.Int64Type, []attr.Value{types.Int64Value(42), types.Int64Value(45)}),
+				Home:             types.StringValue("/mnt/tank/home/backup"),
+				Shell:            types.StringValue("/usr/bin/bash"),
+				SSHPubKey:        types.StringValue("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHZ backup@lan"),
+				Locked:           types.BoolValue(false),
+				SMB:              types.BoolValue(true),
+				Builtin:          types.BoolValue(false),
+				SudoCommands:     stringList("/usr/sbin/zfs"),
+			},
+		},
+		{
+			name:    "older release with sudo flags",
+			payload: `{"id": 71, "uid": 3000, "username": "backup", "group": 41, "groups": [], "sudo": true, "sudo_nopasswd": true, "email": null}`,
+			want: UserResourceModel{
+				ID:           types.Int64Value(71),
+				UID:          types.Int64Value(3000),
+				Username:     types.StringValue("backup"),
+				Group:        types.Int64Value(41),
+				Sudo:         types.BoolValue(true),
+				SudoNopasswd: types.BoolValue(true),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed uid",
+			payload: `{"id": 71, "uid": "backup", "username": "backup"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &UserResource{client: newFakeAPI("user", 71, tt.payload)}
+
+			var model UserResourceModel
+			err := r.readUser(context.Background(), 71, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readUser() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readUser() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readUser() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
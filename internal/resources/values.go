@@ -0,0 +1,21 @@
+package resources
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// knownString returns v for a request field, or nil when v is null or
+// unknown, as for optional computed attributes the server fills in
+func knownString(v types.String) *string {
+	if v.IsNull() || v.IsUnknown() {
+		return nil
+	}
+	return v.ValueStringPointer()
+}
+
+// stringOrEmpty returns v for a request field, or "" when v is null, so that
+// removing an optional text attribute clears it on the server
+func stringOrEmpty(v types.String) *string {
+	s := v.ValueString()
+	return &s
+}
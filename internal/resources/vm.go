@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -16,9 +17,12 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
+// vmStopTimeout bounds the vm.stop job, which waits for the guest to shut down
+const vmStopTimeout = 5 * time.Minute
+
 var (
 	_ resource.Resource                = &VMResource{}
 	_ resource.ResourceWithImportState = &VMResource{}
@@ -29,7 +33,7 @@ func NewVMResource() resource.Resource {
 }
 
 type VMResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type VMResourceModel struct {
@@ -56,6 +60,28 @@ type VMResourceModel struct {
 	Status           types.String `tfsdk:"status"`
 }
 
+// vmFields maps the fields of vm.create and vm.update to schema attributes
+var vmFields = apiFields{
+	"name":                  path.Root("name"),
+	"description":           path.Root("description"),
+	"vcpus":                 path.Root("vcpus"),
+	"cores":                 path.Root("cores"),
+	"threads":               path.Root("threads"),
+	"memory":                path.Root("memory"),
+	"min_memory":            path.Root("min_memory"),
+	"bootloader":            path.Root("bootloader"),
+	"bootloader_ovmf":       path.Root("bootloader_ovmf"),
+	"autostart":             path.Root("autostart"),
+	"hide_from_msr":         path.Root("hide_from_msr"),
+	"ensure_display_device": path.Root("ensure_display_device"),
+	"time":                  path.Root("time"),
+	"shutdown_timeout":      path.Root("shutdown_timeout"),
+	"arch_type":             path.Root("arch_type"),
+	"machine_type":          path.Root("machine_type"),
+	"cpu_mode":              path.Root("cpu_mode"),
+	"cpu_model":             path.Root("cpu_model"),
+}
+
 func (r *VMResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_vm"
 }
@@ -179,15 +205,17 @@ func (r *VMResource) Configure(ctx context.Context, req resource.ConfigureReques
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *VMResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_vm", nil)
+
 	var plan VMResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -199,60 +227,36 @@ func (r *VMResource) Create(ctx context.Context, req resource.CreateRequest, res
 		"name": plan.Name.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"name":       plan.Name.ValueString(),
-		"vcpus":      plan.VCPUs.ValueInt64(),
-		"cores":      plan.Cores.ValueInt64(),
-		"threads":    plan.Threads.ValueInt64(),
-		"memory":     plan.Memory.ValueInt64(),
-		"bootloader": plan.Bootloader.ValueString(),
-		"autostart":  plan.Autostart.ValueBool(),
-	}
-
-	if !plan.Description.IsNull() {
-		createData["description"] = plan.Description.ValueString()
-	}
-	if !plan.MinMemory.IsNull() {
-		createData["min_memory"] = plan.MinMemory.ValueInt64()
-	}
-	if !plan.BootloaderOVMF.IsNull() {
-		createData["bootloader_ovmf"] = plan.BootloaderOVMF.ValueString()
-	}
-	if !plan.HideFromMSR.IsNull() {
-		createData["hide_from_msr"] = plan.HideFromMSR.ValueBool()
-	}
-	if !plan.EnsureDisplayDevice.IsNull() {
-		createData["ensure_display_device"] = plan.EnsureDisplayDevice.ValueBool()
-	}
-	if !plan.Time.IsNull() {
-		createData["time"] = plan.Time.ValueString()
-	}
-	if !plan.ShutdownTimeout.IsNull() {
-		createData["shutdown_timeout"] = plan.ShutdownTimeout.ValueInt64()
-	}
-	if !plan.ArchType.IsNull() {
-		createData["arch_type"] = plan.ArchType.ValueString()
-	}
-	if !plan.MachineType.IsNull() {
-		createData["machine_type"] = plan.MachineType.ValueString()
-	}
-	if !plan.CPUMode.IsNull() {
-		createData["cpu_mode"] = plan.CPUMode.ValueString()
-	}
-	if !plan.CPUModel.IsNull() {
-		createData["cpu_model"] = plan.CPUModel.ValueString()
-	}
-
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "vm", createData, &result)
+	createData := truenas.VMRequest{
+		Name:                truenas.Ptr(plan.Name.ValueString()),
+		VCPUs:               truenas.Ptr(plan.VCPUs.ValueInt64()),
+		Cores:               truenas.Ptr(plan.Cores.ValueInt64()),
+		Threads:             truenas.Ptr(plan.Threads.ValueInt64()),
+		Memory:              truenas.Ptr(plan.Memory.ValueInt64()),
+		Bootloader:          truenas.Ptr(plan.Bootloader.ValueString()),
+		Autostart:           truenas.Ptr(plan.Autostart.ValueBool()),
+		Description:         plan.Description.ValueStringPointer(),
+		MinMemory:           plan.MinMemory.ValueInt64Pointer(),
+		BootloaderOVMF:      plan.BootloaderOVMF.ValueStringPointer(),
+		HideFromMSR:         plan.HideFromMSR.ValueBoolPointer(),
+		EnsureDisplayDevice: plan.EnsureDisplayDevice.ValueBoolPointer(),
+		Time:                plan.Time.ValueStringPointer(),
+		ShutdownTimeout:     plan.ShutdownTimeout.ValueInt64Pointer(),
+		ArchType:            plan.ArchType.ValueStringPointer(),
+		MachineType:         plan.MachineType.ValueStringPointer(),
+		CPUMode:             plan.CPUMode.ValueStringPointer(),
+		CPUModel:            plan.CPUModel.ValueStringPointer(),
+	}
+
+	var vm truenas.VM
+	err := r.client.Create(ctx, "vm", createData, &vm)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating VM", "Could not create VM: "+err.Error())
+		addClientError(&resp.Diagnostics, vmFields, "Error Creating VM", "Could not create VM", err)
 		return
 	}
 
-	vmID := int64(result["id"].(float64))
-	if err := r.readVM(ctx, vmID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading VM", "Could not read VM after creation: "+err.Error())
+	if err := r.readVM(ctx, vm.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, vmFields, "Error Reading VM", "Could not read VM after creation", err)
 		return
 	}
 
@@ -268,12 +272,14 @@ func (r *VMResource) Read(ctx context.Context, req resource.ReadRequest, resp *r
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_vm", state.ID)
+
 	if err := r.readVM(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading VM", "Could not read VM: "+err.Error())
+		addClientError(&resp.Diagnostics, vmFields, "Error Reading VM", "Could not read VM", err)
 		return
 	}
 
@@ -296,69 +302,66 @@ func (r *VMResource) Update(ctx context.Context, req resource.UpdateRequest, res
 		return
 	}
 
-	updateData := map[string]interface{}{}
+	ctx = withAddress(ctx, "trueform_vm", state.ID)
+
+	var updateData truenas.VMRequest
 
 	if !plan.Description.Equal(state.Description) {
-		if plan.Description.IsNull() {
-			updateData["description"] = ""
-		} else {
-			updateData["description"] = plan.Description.ValueString()
-		}
+		updateData.Description = stringOrEmpty(plan.Description)
 	}
 	if !plan.VCPUs.Equal(state.VCPUs) {
-		updateData["vcpus"] = plan.VCPUs.ValueInt64()
+		updateData.VCPUs = truenas.Ptr(plan.VCPUs.ValueInt64())
 	}
 	if !plan.Cores.Equal(state.Cores) {
-		updateData["cores"] = plan.Cores.ValueInt64()
+		updateData.Cores = truenas.Ptr(plan.Cores.ValueInt64())
 	}
 	if !plan.Threads.Equal(state.Threads) {
-		updateData["threads"] = plan.Threads.ValueInt64()
+		updateData.Threads = truenas.Ptr(plan.Threads.ValueInt64())
 	}
 	if !plan.Memory.Equal(state.Memory) {
-		updateData["memory"] = plan.Memory.ValueInt64()
+		updateData.Memory = truenas.Ptr(plan.Memory.ValueInt64())
 	}
 	if !plan.MinMemory.Equal(state.MinMemory) {
-		updateData["min_memory"] = plan.MinMemory.ValueInt64()
+		updateData.MinMemory = truenas.Ptr(plan.MinMemory.ValueInt64())
 	}
 	if !plan.Bootloader.Equal(state.Bootloader) {
-		updateData["bootloader"] = plan.Bootloader.ValueString()
+		updateData.Bootloader = truenas.Ptr(plan.Bootloader.ValueString())
 	}
 	if !plan.BootloaderOVMF.Equal(state.BootloaderOVMF) {
-		updateData["bootloader_ovmf"] = plan.BootloaderOVMF.ValueString()
+		updateData.BootloaderOVMF = truenas.Ptr(plan.BootloaderOVMF.ValueString())
 	}
 	if !plan.Autostart.Equal(state.Autostart) {
-		updateData["autostart"] = plan.Autostart.ValueBool()
+		updateData.Autostart = truenas.Ptr(plan.Autostart.ValueBool())
 	}
 	if !plan.HideFromMSR.Equal(state.HideFromMSR) {
-		updateData["hide_from_msr"] = plan.HideFromMSR.ValueBool()
+		updateData.HideFromMSR = truenas.Ptr(plan.HideFromMSR.ValueBool())
 	}
 	if !plan.EnsureDisplayDevice.Equal(state.EnsureDisplayDevice) {
-		updateData["ensure_display_device"] = plan.EnsureDisplayDevice.ValueBool()
+		updateData.EnsureDisplayDevice = truenas.Ptr(plan.EnsureDisplayDevice.ValueBool())
 	}
 	if !plan.Time.Equal(state.Time) {
-		updateData["time"] = plan.Time.ValueString()
+		updateData.Time = truenas.Ptr(plan.Time.ValueString())
 	}
 	if !plan.ShutdownTimeout.Equal(state.ShutdownTimeout) {
-		updateData["shutdown_timeout"] = plan.ShutdownTimeout.ValueInt64()
+		updateData.ShutdownTimeout = truenas.Ptr(plan.ShutdownTimeout.ValueInt64())
 	}
 	if !plan.CPUMode.Equal(state.CPUMode) {
-		updateData["cpu_mode"] = plan.CPUMode.ValueString()
+		updateData.CPUMode = truenas.Ptr(plan.CPUMode.ValueString())
 	}
 	if !plan.CPUModel.Equal(state.CPUModel) {
-		updateData["cpu_model"] = plan.CPUModel.ValueString()
+		updateData.CPUModel = truenas.Ptr(plan.CPUModel.ValueString())
 	}
 
-	if len(updateData) > 0 {
-		var result map[string]interface{}
-		err := r.client.Update(ctx, "vm", state.ID.ValueInt64(), updateData, &result)
+	if updateData != (truenas.VMRequest{}) {
+		err := r.client.Update(ctx, "vm", state.ID.ValueInt64(), updateData, nil)
 		if err != nil {
-			resp.Diagnostics.AddError("Error Updating VM", "Could not update VM: "+err.Error())
+			addClientError(&resp.Diagnostics, vmFields, "Error Updating VM", "Could not update VM", err)
 			return
 		}
 	}
 
 	if err := r.readVM(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading VM", "Could not read VM after update: "+err.Error())
+		addClientError(&resp.Diagnostics, vmFields, "Error Reading VM", "Could not read VM after update", err)
 		return
 	}
 
@@ -374,12 +377,15 @@ func (r *VMResource) Delete(ctx context.Context, req resource.DeleteRequest, res
 		return
 	}
 
-	// Stop the VM first if running (ignore error - VM may already be stopped)
-	_ = r.client.Call(ctx, "vm.stop", []interface{}{state.ID.ValueInt64()}, nil)
+	ctx = withAddress(ctx, "trueform_vm", state.ID)
+
+	// Stop the VM first if running (ignore error - VM may already be stopped).
+	// vm.stop is a job and the VM cannot be deleted until it has finished.
+	_ = r.client.Call(ctx, "vm.stop", []interface{}{state.ID.ValueInt64()}, nil, truenas.AsJob(), truenas.WithTimeout(vmStopTimeout))
 
 	err := r.client.Delete(ctx, "vm", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting VM", "Could not delete VM: "+err.Error())
+		addClientError(&resp.Diagnostics, vmFields, "Error Deleting VM", "Could not delete VM", err)
 		return
 	}
 }
@@ -397,73 +403,71 @@ func (r *VMResource) ImportState(ctx context.Context, req resource.ImportStateRe
 }
 
 func (r *VMResource) readVM(ctx context.Context, id int64, model *VMResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "vm", id, &result)
+	var vm truenas.VM
+	err := r.client.GetInstance(ctx, "vm", id, &vm)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.Name = types.StringValue(result["name"].(string))
+	model.ID = types.Int64Value(vm.ID)
+	model.Name = types.StringValue(vm.Name)
 
-	if description, ok := result["description"].(string); ok {
-		model.Description = types.StringValue(description)
+	if vm.Description != nil {
+		model.Description = types.StringValue(*vm.Description)
 	}
-	if vcpus, ok := result["vcpus"].(float64); ok {
-		model.VCPUs = types.Int64Value(int64(vcpus))
+	if vm.VCPUs != nil {
+		model.VCPUs = types.Int64Value(int64(*vm.VCPUs))
 	}
-	if cores, ok := result["cores"].(float64); ok {
-		model.Cores = types.Int64Value(int64(cores))
+	if vm.Cores != nil {
+		model.Cores = types.Int64Value(int64(*vm.Cores))
 	}
-	if threads, ok := result["threads"].(float64); ok {
-		model.Threads = types.Int64Value(int64(threads))
+	if vm.Threads != nil {
+		model.Threads = types.Int64Value(int64(*vm.Threads))
 	}
-	if memory, ok := result["memory"].(float64); ok {
-		model.Memory = types.Int64Value(int64(memory))
+	if vm.Memory != nil {
+		model.Memory = types.Int64Value(int64(*vm.Memory))
 	}
-	if minMemory, ok := result["min_memory"].(float64); ok {
-		model.MinMemory = types.Int64Value(int64(minMemory))
+	if vm.MinMemory != nil {
+		model.MinMemory = types.Int64Value(int64(*vm.MinMemory))
 	}
-	if bootloader, ok := result["bootloader"].(string); ok {
-		model.Bootloader = types.StringValue(bootloader)
+	if vm.Bootloader != nil {
+		model.Bootloader = types.StringValue(*vm.Bootloader)
 	}
-	if bootloaderOVMF, ok := result["bootloader_ovmf"].(string); ok {
-		model.BootloaderOVMF = types.StringValue(bootloaderOVMF)
+	if vm.BootloaderOVMF != nil {
+		model.BootloaderOVMF = types.StringValue(*vm.BootloaderOVMF)
 	}
-	if autostart, ok := result["autostart"].(bool); ok {
-		model.Autostart = types.BoolValue(autostart)
+	if vm.Autostart != nil {
+		model.Autostart = types.BoolValue(*vm.Autostart)
 	}
-	if hideFromMSR, ok := result["hide_from_msr"].(bool); ok {
-		model.HideFromMSR = types.BoolValue(hideFromMSR)
+	if vm.HideFromMSR != nil {
+		model.HideFromMSR = types.BoolValue(*vm.HideFromMSR)
 	}
-	if ensureDisplayDevice, ok := result["ensure_display_device"].(bool); ok {
-		model.EnsureDisplayDevice = types.BoolValue(ensureDisplayDevice)
+	if vm.EnsureDisplayDevice != nil {
+		model.EnsureDisplayDevice = types.BoolValue(*vm.EnsureDisplayDevice)
 	}
-	if time, ok := result["time"].(string); ok {
-		model.Time = types.StringValue(time)
+	if vm.Time != nil {
+		model.Time = types.StringValue(*vm.Time)
 	}
-	if shutdownTimeout, ok := result["shutdown_timeout"].(float64); ok {
-		model.ShutdownTimeout = types.Int64Value(int64(shutdownTimeout))
+	if vm.ShutdownTimeout != nil {
+		model.ShutdownTimeout = types.Int64Value(int64(*vm.ShutdownTimeout))
 	}
-	if archType, ok := result["arch_type"].(string); ok {
-		model.ArchType = types.StringValue(archType)
+	if vm.ArchType != nil {
+		model.ArchType = types.StringValue(*vm.ArchType)
 	}
-	if machineType, ok := result["machine_type"].(string); ok {
-		model.MachineType = types.StringValue(machineType)
+	if vm.MachineType != nil {
+		model.MachineType = types.StringValue(*vm.MachineType)
 	}
-	if uuid, ok := result["uuid"].(string); ok {
-		model.UUID = types.StringValue(uuid)
+	if vm.UUID != nil {
+		model.UUID = types.StringValue(*vm.UUID)
 	}
-	if cpuMode, ok := result["cpu_mode"].(string); ok {
-		model.CPUMode = types.StringValue(cpuMode)
+	if vm.CPUMode != nil {
+		model.CPUMode = types.StringValue(*vm.CPUMode)
 	}
-	if cpuModel, ok := result["cpu_model"].(string); ok {
-		model.CPUModel = types.StringValue(cpuModel)
+	if vm.CPUModel != nil {
+		model.CPUModel = types.StringValue(*vm.CPUModel)
 	}
-	if status, ok := result["status"].(map[string]interface{}); ok {
-		if state, ok := status["state"].(string); ok {
-			model.Status = types.StringValue(state)
-		}
+	if vm.Status != nil {
+		model.Status = types.StringValue(vm.Status.State)
 	}
 
 	return nil
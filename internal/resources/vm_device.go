@@ -14,7 +14,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/trueform/terraform-provider-trueform/internal/client"
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
 )
 
 var (
@@ -27,7 +27,7 @@ func NewVMDeviceResource() resource.Resource {
 }
 
 type VMDeviceResource struct {
-	client *client.Client
+	client truenas.API
 }
 
 type VMDeviceResourceModel struct {
@@ -62,6 +62,28 @@ type VMDeviceResourceModel struct {
 	RawPath     types.String `tfsdk:"raw_path"`
 }
 
+// vmDeviceFields maps the fields of vm.device.create and vm.device.update to schema attributes
+// attributes.path and attributes.type are left out: which attribute they
+// come from depends on dtype.
+var vmDeviceFields = apiFields{
+	"vm":                                path.Root("vm"),
+	"dtype":                             path.Root("dtype"),
+	"order":                             path.Root("order"),
+	"attributes.physical_sectorsize":    path.Root("disk_sector_size"),
+	"attributes.logical_sectorsize":     path.Root("disk_sector_size"),
+	"attributes.mac":                    path.Root("nic_mac"),
+	"attributes.nic_attach":             path.Root("nic_attach"),
+	"attributes.trust_guest_rx_filters": path.Root("trust_guest_rx_filters"),
+	"attributes.port":                   path.Root("display_port"),
+	"attributes.bind":                   path.Root("display_bind"),
+	"attributes.password":               path.Root("display_password"),
+	"attributes.web":                    path.Root("display_web"),
+	"attributes.resolution":             path.Root("display_resolution"),
+	"attributes.pptdev":                 path.Root("pci_device"),
+	"attributes.device":                 path.Root("usb_device"),
+	"attributes.size":                   path.Root("raw_size"),
+}
+
 func (r *VMDeviceResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_vm_device"
 }
@@ -183,15 +205,17 @@ func (r *VMDeviceResource) Configure(ctx context.Context, req resource.Configure
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*client.Client)
+	client, ok := req.ProviderData.(truenas.API)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *client.Client, got: %T.", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected truenas.API, got: %T.", req.ProviderData))
 		return
 	}
 	r.client = client
 }
 
 func (r *VMDeviceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAddress(ctx, "trueform_vm_device", nil)
+
 	var plan VMDeviceResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -204,92 +228,61 @@ func (r *VMDeviceResource) Create(ctx context.Context, req resource.CreateReques
 		"dtype": plan.DeviceType.ValueString(),
 	})
 
-	createData := map[string]interface{}{
-		"vm":    plan.VM.ValueInt64(),
-		"dtype": plan.DeviceType.ValueString(),
-		"order": plan.Order.ValueInt64(),
+	createData := truenas.VMDeviceRequest{
+		VM:    truenas.Ptr(plan.VM.ValueInt64()),
+		DType: truenas.Ptr(plan.DeviceType.ValueString()),
+		Order: truenas.Ptr(plan.Order.ValueInt64()),
 	}
 
 	// Build attributes based on device type
-	attrs := map[string]interface{}{}
+	var attrs truenas.VMDeviceAttributes
 
 	switch plan.DeviceType.ValueString() {
 	case "DISK":
-		if !plan.DiskPath.IsNull() {
-			attrs["path"] = plan.DiskPath.ValueString()
-		}
-		if !plan.DiskType.IsNull() {
-			attrs["type"] = plan.DiskType.ValueString()
-		}
-		if !plan.DiskSectorSize.IsNull() {
-			attrs["physical_sectorsize"] = plan.DiskSectorSize.ValueInt64()
-			attrs["logical_sectorsize"] = plan.DiskSectorSize.ValueInt64()
-		}
+		attrs.Path = plan.DiskPath.ValueStringPointer()
+		attrs.Type = plan.DiskType.ValueStringPointer()
+		attrs.PhysicalSectorsize = plan.DiskSectorSize.ValueInt64Pointer()
+		attrs.LogicalSectorsize = plan.DiskSectorSize.ValueInt64Pointer()
 	case "NIC":
-		if !plan.NICType.IsNull() {
-			attrs["type"] = plan.NICType.ValueString()
-		}
-		if !plan.NICMac.IsNull() {
-			attrs["mac"] = plan.NICMac.ValueString()
-		}
-		if !plan.NICAttach.IsNull() {
-			attrs["nic_attach"] = plan.NICAttach.ValueString()
-		}
-		if !plan.TrustGuestRXFilters.IsNull() {
-			attrs["trust_guest_rx_filters"] = plan.TrustGuestRXFilters.ValueBool()
-		}
+		attrs.Type = plan.NICType.ValueStringPointer()
+		attrs.Mac = plan.NICMac.ValueStringPointer()
+		attrs.NICAttach = plan.NICAttach.ValueStringPointer()
+		attrs.TrustGuestRXFilters = plan.TrustGuestRXFilters.ValueBoolPointer()
 	case "CDROM":
-		if !plan.CDROMPath.IsNull() {
-			attrs["path"] = plan.CDROMPath.ValueString()
-		}
+		attrs.Path = plan.CDROMPath.ValueStringPointer()
 	case "DISPLAY":
-		if !plan.DisplayType.IsNull() {
-			attrs["type"] = plan.DisplayType.ValueString()
-		}
-		if !plan.DisplayPort.IsNull() {
-			attrs["port"] = plan.DisplayPort.ValueInt64()
-		}
-		if !plan.DisplayBind.IsNull() {
-			attrs["bind"] = plan.DisplayBind.ValueString()
-		}
-		if !plan.DisplayPassword.IsNull() {
-			attrs["password"] = plan.DisplayPassword.ValueString()
-		}
-		if !plan.DisplayWeb.IsNull() {
-			attrs["web"] = plan.DisplayWeb.ValueBool()
-		}
-		if !plan.DisplayResolution.IsNull() {
-			attrs["resolution"] = plan.DisplayResolution.ValueString()
-		}
+		attrs.Type = plan.DisplayType.ValueStringPointer()
+		attrs.Port = plan.DisplayPort.ValueInt64Pointer()
+		attrs.Bind = plan.DisplayBind.ValueStringPointer()
+		attrs.Password = plan.DisplayPassword.ValueStringPointer()
+		attrs.Web = plan.DisplayWeb.ValueBoolPointer()
+		attrs.Resolution = plan.DisplayResolution.ValueStringPointer()
 	case "PCI":
-		if !plan.PCIDevice.IsNull() {
-			attrs["pptdev"] = plan.PCIDevice.ValueString()
-		}
+		attrs.Pptdev = plan.PCIDevice.ValueStringPointer()
 	case "USB":
-		if !plan.USBDevice.IsNull() {
-			attrs["device"] = plan.USBDevice.ValueString()
-		}
+		attrs.Device = plan.USBDevice.ValueStringPointer()
 	case "RAW":
-		if !plan.RawSize.IsNull() {
-			attrs["size"] = plan.RawSize.ValueInt64()
-		}
-		if !plan.RawPath.IsNull() {
-			attrs["path"] = plan.RawPath.ValueString()
-		}
+		attrs.Size = plan.RawSize.ValueInt64Pointer()
+		attrs.Path = plan.RawPath.ValueStringPointer()
 	}
 
-	createData["attributes"] = attrs
+	createData.Attributes = &attrs
+
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, vmLockKey(plan.VM.ValueInt64()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
 
-	var result map[string]interface{}
-	err := r.client.Create(ctx, "vm.device", createData, &result)
+	var device truenas.VMDevice
+	err := r.client.Create(ctx, "vm.device", createData, &device)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Creating VM Device", "Could not create VM device: "+err.Error())
+		addClientError(&resp.Diagnostics, vmDeviceFields, "Error Creating VM Device", "Could not create VM device", err)
 		return
 	}
 
-	deviceID := int64(result["id"].(float64))
-	if err := r.readDevice(ctx, deviceID, &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading VM Device", "Could not read VM device after creation: "+err.Error())
+	if err := r.readDevice(ctx, device.ID, &plan); err != nil {
+		addClientError(&resp.Diagnostics, vmDeviceFields, "Error Reading VM Device", "Could not read VM device after creation", err)
 		return
 	}
 
@@ -305,12 +298,14 @@ func (r *VMDeviceResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_vm_device", state.ID)
+
 	if err := r.readDevice(ctx, state.ID.ValueInt64(), &state); err != nil {
-		if client.IsNotFoundError(err) {
+		if truenas.IsNotFoundError(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Error Reading VM Device", "Could not read VM device: "+err.Error())
+		addClientError(&resp.Diagnostics, vmDeviceFields, "Error Reading VM Device", "Could not read VM device", err)
 		return
 	}
 
@@ -333,53 +328,46 @@ func (r *VMDeviceResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	updateData := map[string]interface{}{
-		"order": plan.Order.ValueInt64(),
+	ctx = withAddress(ctx, "trueform_vm_device", state.ID)
+
+	updateData := truenas.VMDeviceRequest{
+		Order: truenas.Ptr(plan.Order.ValueInt64()),
 	}
 
-	attrs := map[string]interface{}{}
+	var attrs truenas.VMDeviceAttributes
 
 	switch plan.DeviceType.ValueString() {
 	case "DISK":
-		if !plan.DiskPath.IsNull() {
-			attrs["path"] = plan.DiskPath.ValueString()
-		}
-		if !plan.DiskType.IsNull() {
-			attrs["type"] = plan.DiskType.ValueString()
-		}
+		attrs.Path = plan.DiskPath.ValueStringPointer()
+		attrs.Type = plan.DiskType.ValueStringPointer()
 	case "NIC":
-		if !plan.NICType.IsNull() {
-			attrs["type"] = plan.NICType.ValueString()
-		}
-		if !plan.NICAttach.IsNull() {
-			attrs["nic_attach"] = plan.NICAttach.ValueString()
-		}
+		attrs.Type = plan.NICType.ValueStringPointer()
+		attrs.NICAttach = plan.NICAttach.ValueStringPointer()
 	case "CDROM":
-		if !plan.CDROMPath.IsNull() {
-			attrs["path"] = plan.CDROMPath.ValueString()
-		}
+		attrs.Path = plan.CDROMPath.ValueStringPointer()
 	case "DISPLAY":
-		if !plan.DisplayPassword.IsNull() {
-			attrs["password"] = plan.DisplayPassword.ValueString()
-		}
-		if !plan.DisplayWeb.IsNull() {
-			attrs["web"] = plan.DisplayWeb.ValueBool()
-		}
+		attrs.Password = plan.DisplayPassword.ValueStringPointer()
+		attrs.Web = plan.DisplayWeb.ValueBoolPointer()
+	}
+
+	if attrs != (truenas.VMDeviceAttributes{}) {
+		updateData.Attributes = &attrs
 	}
 
-	if len(attrs) > 0 {
-		updateData["attributes"] = attrs
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, vmLockKey(state.VM.ValueInt64()))
+	if unlock == nil {
+		return
 	}
+	defer unlock()
 
-	var result map[string]interface{}
-	err := r.client.Update(ctx, "vm.device", state.ID.ValueInt64(), updateData, &result)
+	err := r.client.Update(ctx, "vm.device", state.ID.ValueInt64(), updateData, nil)
 	if err != nil {
-		resp.Diagnostics.AddError("Error Updating VM Device", "Could not update VM device: "+err.Error())
+		addClientError(&resp.Diagnostics, vmDeviceFields, "Error Updating VM Device", "Could not update VM device", err)
 		return
 	}
 
 	if err := r.readDevice(ctx, state.ID.ValueInt64(), &plan); err != nil {
-		resp.Diagnostics.AddError("Error Reading VM Device", "Could not read VM device after update: "+err.Error())
+		addClientError(&resp.Diagnostics, vmDeviceFields, "Error Reading VM Device", "Could not read VM device after update", err)
 		return
 	}
 
@@ -395,9 +383,17 @@ func (r *VMDeviceResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
+	ctx = withAddress(ctx, "trueform_vm_device", state.ID)
+
+	unlock := lockParent(ctx, r.client, &resp.Diagnostics, vmLockKey(state.VM.ValueInt64()))
+	if unlock == nil {
+		return
+	}
+	defer unlock()
+
 	err := r.client.Delete(ctx, "vm.device", state.ID.ValueInt64())
 	if err != nil {
-		resp.Diagnostics.AddError("Error Deleting VM Device", "Could not delete VM device: "+err.Error())
+		addClientError(&resp.Diagnostics, vmDeviceFields, "Error Deleting VM Device", "Could not delete VM device", err)
 		return
 	}
 }
@@ -415,62 +411,62 @@ func (r *VMDeviceResource) ImportState(ctx context.Context, req resource.ImportS
 }
 
 func (r *VMDeviceResource) readDevice(ctx context.Context, id int64, model *VMDeviceResourceModel) error {
-	var result map[string]interface{}
-	err := r.client.GetInstance(ctx, "vm.device", id, &result)
+	var device truenas.VMDevice
+	err := r.client.GetInstance(ctx, "vm.device", id, &device)
 	if err != nil {
 		return err
 	}
 
-	model.ID = types.Int64Value(int64(result["id"].(float64)))
-	model.VM = types.Int64Value(int64(result["vm"].(float64)))
-	model.DeviceType = types.StringValue(result["dtype"].(string))
+	model.ID = types.Int64Value(device.ID)
+	model.VM = types.Int64Value(device.VM)
+	model.DeviceType = types.StringValue(device.DType)
 
-	if order, ok := result["order"].(float64); ok {
-		model.Order = types.Int64Value(int64(order))
+	if device.Order != nil {
+		model.Order = types.Int64Value(int64(*device.Order))
 	}
 
-	if attrs, ok := result["attributes"].(map[string]interface{}); ok {
+	if attrs := device.Attributes; attrs != nil {
 		switch model.DeviceType.ValueString() {
 		case "DISK":
-			if path, ok := attrs["path"].(string); ok {
-				model.DiskPath = types.StringValue(path)
+			if attrs.Path != nil {
+				model.DiskPath = types.StringValue(*attrs.Path)
 			}
-			if dtype, ok := attrs["type"].(string); ok {
-				model.DiskType = types.StringValue(dtype)
+			if attrs.Type != nil {
+				model.DiskType = types.StringValue(*attrs.Type)
 			}
 		case "NIC":
-			if nicType, ok := attrs["type"].(string); ok {
-				model.NICType = types.StringValue(nicType)
+			if attrs.Type != nil {
+				model.NICType = types.StringValue(*attrs.Type)
 			}
-			if mac, ok := attrs["mac"].(string); ok {
-				model.NICMac = types.StringValue(mac)
+			if attrs.Mac != nil {
+				model.NICMac = types.StringValue(*attrs.Mac)
 			}
-			if attach, ok := attrs["nic_attach"].(string); ok {
-				model.NICAttach = types.StringValue(attach)
+			if attrs.NICAttach != nil {
+				model.NICAttach = types.StringValue(*attrs.NICAttach)
 			}
 		case "CDROM":
-			if path, ok := attrs["path"].(string); ok {
-				model.CDROMPath = types.StringValue(path)
+			if attrs.Path != nil {
+				model.CDROMPath = types.StringValue(*attrs.Path)
 			}
 		case "DISPLAY":
-			if displayType, ok := attrs["type"].(string); ok {
-				model.DisplayType = types.StringValue(displayType)
+			if attrs.Type != nil {
+				model.DisplayType = types.StringValue(*attrs.Type)
 			}
-			if port, ok := attrs["port"].(float64); ok {
-				model.DisplayPort = types.Int64Value(int64(port))
+			if attrs.Port != nil {
+				model.DisplayPort = types.Int64Value(*attrs.Port)
 			}
-			if bind, ok := attrs["bind"].(string); ok {
-				model.DisplayBind = types.StringValue(bind)
+			if attrs.Bind != nil {
+				model.DisplayBind = types.StringValue(*attrs.Bind)
 			}
-			if web, ok := attrs["web"].(bool); ok {
-				model.DisplayWeb = types.BoolValue(web)
+			if attrs.Web != nil {
+				model.DisplayWeb = types.BoolValue(*attrs.Web)
 			}
-			if resolution, ok := attrs["resolution"].(string); ok {
-				model.DisplayResolution = types.StringValue(resolution)
+			if attrs.Resolution != nil {
+				model.DisplayResolution = types.StringValue(*attrs.Resolution)
 			}
 		case "PCI":
-			if pptdev, ok := attrs["pptdev"].(string); ok {
-				model.PCIDevice = types.StringValue(pptdev)
+			if attrs.Pptdev != nil {
+				model.PCIDevice = types.StringValue(*attrs.Pptdev)
 			}
 		}
 	}
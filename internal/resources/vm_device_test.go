@@ -0,0 +1,112 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadDevice(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    VMDeviceResourceModel
+		wantErr bool
+	}{
+		{
+			name:    "disk",
+			payload: `{"id": 9, "vm": 1, "dtype": "DISK", "order": 1001, "attributes": {"dtype": "DISK", "path": "/dev/zvol/tank/vms/debian", "type": "VIRTIO", "physical_sectorsize": null, "logical_sectorsize": null}}`,
+			want: VMDeviceResourceModel{
+				ID:         types.Int64Value(9),
+				VM:         types.Int64Value(1),
+				DeviceType: types.StringValue("DISK"),
+				Order:      types.Int64Value(1001),
+				DiskPath:   types.StringValue("/dev/zvol/tank/vms/debian"),
+				DiskType:   types.StringValue("VIRTIO"),
+			},
+		},
+		{
+			name:    "nic",
+			payload: `{"id": 9, "vm": 1, "dtype": "NIC", "order": 1002, "attributes": {"dtype": "NIC", "type": "VIRTIO", "mac": "00:a0:98:6b:3c:11", "nic_attach": "br0", "trust_guest_rx_filters": false}}`,
+			want: VMDeviceResourceModel{
+				ID:         types.Int64Value(9),
+				VM:         types.Int64Value(1),
+				DeviceType: types.StringValue("NIC"),
+				Order:      types.Int64Value(1002),
+				NICType:    types.StringValue("VIRTIO"),
+				NICMac:     types.StringValue("00:a0:98:6b:3c:11"),
+				NICAttach:  types.StringValue("br0"),
+			},
+		},
+		{
+			name:    "cdrom",
+			payload: `{"id": 9, "vm": 1, "dtype": "CDROM", "order": 1000, "attributes": {"dtype": "CDROM", "path": "/mnt/tank/iso/debian-12.iso"}}`,
+			want: VMDeviceResourceModel{
+				ID:         types.Int64Value(9),
+				VM:         types.Int64Value(1),
+				DeviceType: types.StringValue("CDROM"),
+				Order:      types.Int64Value(1000),
+				CDROMPath:  types.StringValue("/mnt/tank/iso/debian-12.iso"),
+			},
+		},
+		{
+			name:    "display",
+			payload: `{"id": 9, "vm": 1, "dtype": "DISPLAY", "order": 1003, "attributes": {"dtype": "DISPLAY", "type": "SPICE", "port": 5900, "bind": "0.0.0.0", "password": "secret", "web": true, "resolution": "1920x1080"}}`,
+			want: VMDeviceResourceModel{
+				ID:                types.Int64Value(9),
+				VM:                types.Int64Value(1),
+				DeviceType:        types.StringValue("DISPLAY"),
+				Order:             types.Int64Value(1003),
+				DisplayType:       types.StringValue("SPICE"),
+				DisplayPort:       types.Int64Value(5900),
+				DisplayBind:       types.StringValue("0.0.0.0"),
+				DisplayWeb:        types.BoolValue(true),
+				DisplayResolution: types.StringValue("1920x1080"),
+			},
+		},
+		{
+			name:    "pci",
+			payload: `{"id": 9, "vm": 1, "dtype": "PCI", "order": null, "attributes": {"dtype": "PCI", "pptdev": "pci_0000_01_00_0"}}`,
+			want: VMDeviceResourceModel{
+				ID:         types.Int64Value(9),
+				VM:         types.Int64Value(1),
+				DeviceType: types.StringValue("PCI"),
+				PCIDevice:  types.StringValue("pci_0000_01_00_0"),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed port",
+			payload: `{"id": 9, "vm": 1, "dtype": "DISPLAY", "attributes": {"port": "5900"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &VMDeviceResource{client: newFakeAPI("vm.device", 9, tt.payload)}
+
+			var model VMDeviceResourceModel
+			err := r.readDevice(context.Background(), 9, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readDevice() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readDevice() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readDevice() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
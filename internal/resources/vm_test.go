@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func TestReadVM(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    VMResourceModel
+		wantErr bool
+	}{
+		{
+			name: "running vm",
+			payload: `{
+				"id": 1, "name": "debian", "description": "Build host", "vcpus": 1, "cores": 2, "threads": 1,
+				"memory": 4096, "min_memory": null, "bootloader": "UEFI", "bootloader_ovmf": "OVMF_CODE.fd",
+				"autostart": true, "hide_from_msr": false, "ensure_display_device": true, "time": "LOCAL",
+				"shutdown_timeout": 90, "arch_type": null, "machine_type": null,
+				"uuid": "4d0e7c1a-9a3f-4f0c-8a36-2c1b0a6d5e21", "cpu_mode": "HOST-PASSTHROUGH", "cpu_model": null,
+				"devices": [], "status": {"state": "RUNNING", "pid": 4242, "domain_state": "RUNNING"}
+			}`,
+			want: VMResourceModel{
+				ID:                  types.Int64Value(1),
+				Name:                types.StringValue("debian"),
+				Description:         types.StringValue("Build host"),
+				VCPUs:               types.Int64Value(1),
+				Cores:               types.Int64Value(2),
+				Threads:             types.Int64Value(1),
+				Memory:              types.Int64Value(4096),
+				Bootloader:          types.StringValue("UEFI"),
+				BootloaderOVMF:      types.StringValue("OVMF_CODE.fd"),
+				Autostart:           types.BoolValue(true),
+				HideFromMSR:         types.BoolValue(false),
+				EnsureDisplayDevice: types.BoolValue(true),
+				Time:                types.StringValue("LOCAL"),
+				ShutdownTimeout:     types.Int64Value(90),
+				UUID:                types.StringValue("4d0e7c1a-9a3f-4f0c-8a36-2c1b0a6d5e21"),
+				CPUMode:             types.StringValue("HOST-PASSTHROUGH"),
+				Status:              types.StringValue("RUNNING"),
+			},
+		},
+		{
+			name:    "minimal vm",
+			payload: `{"id": 1, "name": "debian", "memory": 512}`,
+			want: VMResourceModel{
+				ID:     types.Int64Value(1),
+				Name:   types.StringValue("debian"),
+				Memory: types.Int64Value(512),
+			},
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+		},
+		{
+			name:    "malformed memory",
+			payload: `{"id": 1, "name": "debian", "memory": "4G"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &VMResource{client: newFakeAPI("vm", 1, tt.payload)}
+
+			var model VMResourceModel
+			err := r.readVM(context.Background(), 1, &model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readVM() error = nil, want error")
+				}
+				if tt.payload == "" && !truenas.IsNotFoundError(err) {
+					t.Errorf("readVM() error = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readVM() error = %v", err)
+			}
+			assertModel(t, model, tt.want)
+		})
+	}
+}
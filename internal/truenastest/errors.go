@@ -0,0 +1,81 @@
+package truenastest
+
+import "fmt"
+
+// JSON-RPC error codes sent by the middleware
+const (
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeCallError      = -32001
+)
+
+// Error is a JSON-RPC error returned to the client. Errname, Errno and
+// Extra end up in the error's data the way the middleware reports them.
+type Error struct {
+	Code    int
+	Message string
+	Errname string
+	Errno   int
+	// Extra holds [attribute, message, errno] validation triples
+	Extra [][]interface{}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// rpcError returns the error in wire format
+func (e *Error) rpcError() map[string]interface{} {
+	out := map[string]interface{}{
+		"code":    e.Code,
+		"message": e.Message,
+	}
+	if e.Errname != "" || len(e.Extra) > 0 {
+		data := map[string]interface{}{
+			"error":   e.Errno,
+			"errname": e.Errname,
+			"reason":  e.Message,
+		}
+		if len(e.Extra) > 0 {
+			data["extra"] = e.Extra
+		}
+		out["data"] = data
+	}
+	return out
+}
+
+// toError converts a handler error to an *Error
+func toError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{Code: codeCallError, Message: err.Error(), Errname: "EFAULT", Errno: 14}
+}
+
+// NotFound returns the error the middleware sends when an instance does not
+// exist
+func NotFound(namespace string, id interface{}) *Error {
+	return &Error{
+		Code:    codeInvalidParams,
+		Message: fmt.Sprintf("%s %v does not exist", namespace, id),
+		Errname: "InstanceNotFound",
+		Errno:   2,
+	}
+}
+
+// ValidationError returns a validation failure for a single attribute, e.g.
+// "pool_dataset_create.quota"
+func ValidationError(attribute, message string) *Error {
+	return &Error{
+		Code:    codeInvalidParams,
+		Message: fmt.Sprintf("[EINVAL] %s: %s", attribute, message),
+		Errname: "EINVAL",
+		Errno:   22,
+		Extra:   [][]interface{}{{attribute, message, 22}},
+	}
+}
+
+// Busy returns the error the middleware sends while a resource is busy
+func Busy(message string) *Error {
+	return &Error{Code: codeCallError, Message: "[EBUSY] " + message, Errname: "EBUSY", Errno: 16}
+}
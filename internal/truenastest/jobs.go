@@ -0,0 +1,108 @@
+package truenastest
+
+import "fmt"
+
+// startJob runs a job-based method to completion and returns its job id.
+// Subscribers to core.get_jobs see the job being added and finishing.
+func (s *Server) startJob(method string, params []interface{}, run func() (interface{}, error)) int64 {
+	s.mu.Lock()
+	s.nextJobID++
+	id := s.nextJobID
+	job := map[string]interface{}{
+		"id":        float64(id),
+		"method":    method,
+		"arguments": normalize(params),
+		"state":     "RUNNING",
+		"progress":  map[string]interface{}{"percent": float64(0), "description": ""},
+		"result":    nil,
+		"error":     nil,
+		"exception": nil,
+		"exc_info":  nil,
+	}
+	s.jobs[id] = job
+
+	var failure string
+	failed := false
+	if queued := s.jobFailures[method]; len(queued) > 0 {
+		failure, failed = queued[0], true
+		s.jobFailures[method] = queued[1:]
+	}
+	added := copyItem(job)
+	s.mu.Unlock()
+
+	s.notify("core.get_jobs", "added", float64(id), added)
+
+	var result interface{}
+	var err error
+	if failed {
+		err = &Error{Code: codeCallError, Message: failure}
+	} else {
+		result, err = run()
+	}
+
+	s.mu.Lock()
+	if err != nil {
+		e := toError(err)
+		job["state"] = "FAILED"
+		job["error"] = e.Message
+		job["exception"] = fmt.Sprintf("Traceback (most recent call last):\n  File \"middlewared/job.py\", line 1, in run\n%s\n", e.Message)
+		excInfo := map[string]interface{}{"type": "CallError", "extra": nil}
+		if len(e.Extra) > 0 {
+			excInfo["type"] = "ValidationErrors"
+			excInfo["extra"] = normalize(e.Extra)
+		}
+		job["exc_info"] = excInfo
+	} else {
+		job["state"] = "SUCCESS"
+		job["result"] = normalize(result)
+		job["progress"] = map[string]interface{}{"percent": float64(100), "description": "Done"}
+	}
+	changed := copyItem(job)
+	s.mu.Unlock()
+
+	s.notify("core.get_jobs", "changed", float64(id), changed)
+	return id
+}
+
+// getJobs implements core.get_jobs(filters, options)
+func (s *Server) getJobs(params []interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]map[string]interface{}, 0, len(s.jobs))
+	for id := int64(1); id <= s.nextJobID; id++ {
+		if job, ok := s.jobs[id]; ok {
+			jobs = append(jobs, job)
+		}
+	}
+	return queryItems(jobs, params)
+}
+
+// abortJob implements core.job_abort. Jobs finish as soon as they start, so
+// only jobs that are still waiting can be aborted.
+func (s *Server) abortJob(params []interface{}) (interface{}, error) {
+	id, _ := param(params, 0).(float64)
+
+	s.mu.Lock()
+	job, ok := s.jobs[int64(id)]
+	if !ok {
+		s.mu.Unlock()
+		return nil, NotFound("core.get_jobs", int64(id))
+	}
+	if job["state"] == "RUNNING" || job["state"] == "WAITING" {
+		job["state"] = "ABORTED"
+	}
+	s.mu.Unlock()
+	return nil, nil
+}
+
+// Job returns a copy of a job, or nil when it does not exist
+func (s *Server) Job(id int64) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[id]; ok {
+		return copyItem(job)
+	}
+	return nil
+}
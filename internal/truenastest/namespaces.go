@@ -0,0 +1,173 @@
+package truenastest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// datasetProperties are returned by pool.dataset as
+// {"value", "rawvalue", "parsed", "source"} objects
+var datasetProperties = []string{
+	"aclmode", "acltype", "atime", "available", "casesensitivity", "checksum",
+	"compression", "copies", "deduplication", "exec", "managedby", "quota",
+	"readonly", "recordsize", "refquota", "refreservation", "reservation",
+	"snapdir", "special_small_block_size", "sync", "used", "volblocksize",
+	"volsize",
+}
+
+// setDefault sets item[key] unless it is already present
+func setDefault(item map[string]interface{}, key string, value interface{}) {
+	if _, ok := item[key]; !ok {
+		item[key] = value
+	}
+}
+
+// property wraps a plain value the way the middleware reports ZFS properties
+func property(v interface{}) map[string]interface{} {
+	value := fmt.Sprint(v)
+	if f, ok := v.(float64); ok {
+		value = fmt.Sprintf("%.0f", f)
+	}
+	return map[string]interface{}{
+		"value":    value,
+		"rawvalue": value,
+		"parsed":   v,
+		"source":   "LOCAL",
+	}
+}
+
+// wrapProperty turns item[key] into a property object unless it already is
+func wrapProperty(item map[string]interface{}, key string) {
+	v, ok := item[key]
+	if !ok {
+		return
+	}
+	if _, isProperty := v.(map[string]interface{}); !isProperty {
+		item[key] = property(v)
+	}
+}
+
+func shapePool(item map[string]interface{}) {
+	name, _ := item["name"].(string)
+	setDefault(item, "guid", fmt.Sprintf("%v", item["id"]))
+	setDefault(item, "status", "ONLINE")
+	setDefault(item, "healthy", true)
+	setDefault(item, "path", "/mnt/"+name)
+	setDefault(item, "size", float64(1<<40))
+	setDefault(item, "allocated", float64(0))
+	setDefault(item, "free", float64(1<<40))
+}
+
+func shapeDataset(item map[string]interface{}) {
+	name, _ := item["name"].(string)
+	setDefault(item, "type", "FILESYSTEM")
+	setDefault(item, "pool", strings.SplitN(name, "/", 2)[0])
+	if item["type"] == "FILESYSTEM" {
+		setDefault(item, "mountpoint", "/mnt/"+name)
+	}
+	setDefault(item, "used", float64(0))
+	setDefault(item, "available", float64(1<<40))
+	for _, key := range datasetProperties {
+		wrapProperty(item, key)
+	}
+}
+
+func shapeSnapshot(item map[string]interface{}) {
+	id, _ := item["id"].(string)
+	parts := strings.SplitN(id, "@", 2)
+	item["name"] = id
+	if len(parts) == 2 {
+		item["dataset"] = parts[0]
+		item["snapshot_name"] = parts[1]
+	}
+	setDefault(item, "holds", []interface{}{})
+
+	props, _ := item["properties"].(map[string]interface{})
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	setDefault(props, "referenced", float64(0))
+	setDefault(props, "used", float64(0))
+	setDefault(props, "creation", "2025-01-01T00:00:00")
+	for key := range props {
+		wrapProperty(props, key)
+	}
+	item["properties"] = props
+
+	if updates, ok := item["user_properties_update"]; ok {
+		item["user_properties"] = updates
+		delete(item, "user_properties_update")
+	}
+}
+
+func shapeUser(item map[string]interface{}) {
+	if id, ok := item["id"].(float64); ok {
+		setDefault(item, "uid", 3000+id)
+	}
+	setDefault(item, "builtin", false)
+	setDefault(item, "locked", false)
+	// The middleware never returns passwords
+	delete(item, "password")
+}
+
+func shapeVM(item map[string]interface{}) {
+	setDefault(item, "status", map[string]interface{}{"state": "STOPPED"})
+	setDefault(item, "devices", []interface{}{})
+}
+
+func shapeApp(item map[string]interface{}) {
+	item["name"] = item["id"]
+	setDefault(item, "state", "RUNNING")
+
+	version, _ := item["version"].(string)
+	if version == "" {
+		version = "1.0.0"
+	}
+	train, _ := item["train"].(string)
+	if train == "" {
+		train = "stable"
+	}
+	setDefault(item, "metadata", map[string]interface{}{
+		"name":        item["catalog_app"],
+		"app_version": version,
+		"train":       train,
+	})
+}
+
+// vmState returns a vm.start/vm.stop implementation
+func vmState(state string) methodFunc {
+	return func(s *Server, ns string, params []interface{}) (interface{}, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		id := param(params, 0)
+		item, _ := s.find(ns, id)
+		if item == nil {
+			return nil, NotFound(ns, id)
+		}
+		item["status"] = map[string]interface{}{"state": state}
+		return nil, nil
+	}
+}
+
+// appUpgrade implements app.upgrade(name, {"app_version": ...})
+func appUpgrade(s *Server, ns string, params []interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := param(params, 0)
+	item, _ := s.find(ns, id)
+	if item == nil {
+		return nil, NotFound(ns, id)
+	}
+	options, _ := param(params, 1).(map[string]interface{})
+	if version, ok := options["app_version"].(string); ok {
+		metadata, _ := item["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+			item["metadata"] = metadata
+		}
+		metadata["app_version"] = version
+	}
+	return copyItem(item), nil
+}
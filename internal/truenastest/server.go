@@ -0,0 +1,413 @@
+// Package truenastest provides an in-process fake of the TrueNAS middleware
+// JSON-RPC WebSocket API for tests.
+//
+// The fake authenticates with auth.login_with_api_key, or with auth.login_ex
+// using the PASSWORD_PLAIN or API_KEY_PLAIN mechanism; two-factor logins
+// (OTP_TOKEN) are not supported. It runs jobs through core.get_jobs and
+// keeps in-memory collections for every namespace the provider uses. Errors
+// can be injected per method:
+//
+//	srv := truenastest.NewServer()
+//	defer srv.Close()
+//	srv.FailNext("pool.dataset.create", truenastest.ValidationError("pool_dataset_create.quota", "Must be greater than 1 GiB"))
+//
+//	c := truenas.NewClient(&truenas.Config{Host: srv.Host(), APIKey: srv.APIKey})
+package truenastest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultAPIKey is the API key a new Server accepts
+const DefaultAPIKey = "1-truenastest"
+
+// DefaultUsername and DefaultPassword are the credentials a new Server
+// accepts from auth.login_ex
+const (
+	DefaultUsername = "truenas_admin"
+	DefaultPassword = "truenastest"
+)
+
+// DefaultVersion is the release a new Server reports from system.version
+const DefaultVersion = "25.04.2"
+
+// Handler implements a method. Returning an *Error sends it to the client
+// as is; any other error is sent as a generic call error.
+type Handler func(params []interface{}) (interface{}, error)
+
+// Call is a method call received by the server
+type Call struct {
+	Method string
+	Params []interface{}
+}
+
+// Server is a fake TrueNAS middleware
+type Server struct {
+	// APIKey is the key auth.login_with_api_key accepts
+	APIKey string
+	// Username and Password are the credentials auth.login_ex accepts
+	Username string
+	Password string
+	// Version is the release system.version reports
+	Version string
+
+	srv      *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu          sync.Mutex
+	namespaces  map[string]*namespace
+	collections map[string]*collection
+	jobs        map[int64]map[string]interface{}
+	nextJobID   int64
+	handlers    map[string]Handler
+	failures    map[string][]*Error
+	jobFailures map[string][]string
+	calls       []Call
+	conns       map[*conn]bool
+	nextSubID   int
+}
+
+// request is a JSON-RPC request as sent by the client
+type request struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// conn is one client connection
+type conn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+
+	// authenticated and subscriptions are guarded by Server.mu
+	authenticated bool
+	subscriptions map[string]string
+}
+
+// NewServer starts a fake middleware over TLS. Clients must disable
+// certificate verification.
+func NewServer() *Server {
+	s := &Server{
+		APIKey:      DefaultAPIKey,
+		Username:    DefaultUsername,
+		Password:    DefaultPassword,
+		Version:     DefaultVersion,
+		namespaces:  defaultNamespaces(),
+		collections: make(map[string]*collection),
+		jobs:        make(map[int64]map[string]interface{}),
+		handlers:    make(map[string]Handler),
+		failures:    make(map[string][]*Error),
+		jobFailures: make(map[string][]string),
+		conns:       make(map[*conn]bool),
+	}
+	s.srv = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Host returns the host:port clients connect to
+func (s *Server) Host() string {
+	return strings.TrimPrefix(s.srv.URL, "https://")
+}
+
+// Close drops all connections and stops the server
+func (s *Server) Close() {
+	s.DropConnections()
+	s.srv.Close()
+}
+
+// DropConnections closes every open client connection, as a middleware
+// restart would
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		_ = c.ws.Close()
+	}
+}
+
+// RotateAPIKey makes later logins accept only key, as revoking the old key
+// and creating a new one would. Open connections stay logged in.
+func (s *Server) RotateAPIKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.APIKey = key
+}
+
+// Handle overrides or adds a method. Handlers run after injected failures
+// and before the built-in implementation.
+func (s *Server) Handle(method string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = h
+}
+
+// FailNext makes the next call to method fail with err. Repeated calls
+// queue further failures.
+func (s *Server) FailNext(method string, err *Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], err)
+}
+
+// FailNextJob makes the next job started by method end in the FAILED state
+// with the given error message
+func (s *Server) FailNextJob(method string, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobFailures[method] = append(s.jobFailures[method], message)
+}
+
+// Calls returns every call received so far, including authentication
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallCount returns how many times method was called
+func (s *Server) CallCount(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, call := range s.calls {
+		if call.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{ws: ws, subscriptions: make(map[string]string)}
+	s.mu.Lock()
+	s.conns[c] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = ws.Close()
+	}()
+
+	for {
+		var req request
+		if err := ws.ReadJSON(&req); err != nil {
+			return
+		}
+
+		var params []interface{}
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				c.reply(req.ID, nil, &Error{Code: codeInvalidParams, Message: "Invalid params: " + err.Error()})
+				continue
+			}
+		}
+
+		result, err := s.dispatch(c, req.Method, params)
+		c.reply(req.ID, result, err)
+	}
+}
+
+// reply writes the response to a request
+func (c *conn) reply(id int64, result interface{}, err error) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if err != nil {
+		msg["error"] = toError(err).rpcError()
+	} else {
+		msg["result"] = result
+	}
+	c.write(msg)
+}
+
+// write sends a message, serializing writers on the connection
+func (c *conn) write(msg interface{}) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.ws.WriteJSON(msg)
+}
+
+// dispatch runs a method for a connection
+func (s *Server) dispatch(c *conn, method string, params []interface{}) (interface{}, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Params: params})
+
+	if method == "auth.login_with_api_key" {
+		key, _ := param(params, 0).(string)
+		c.authenticated = key == s.APIKey
+		s.mu.Unlock()
+		return c.authenticated, nil
+	}
+	if method == "auth.login_ex" {
+		defer s.mu.Unlock()
+		return s.loginEx(c, params)
+	}
+	if !c.authenticated {
+		s.mu.Unlock()
+		return nil, &Error{Code: codeCallError, Message: "Not authenticated", Errname: "ENOTAUTHENTICATED"}
+	}
+
+	if queued := s.failures[method]; len(queued) > 0 {
+		s.failures[method] = queued[1:]
+		s.mu.Unlock()
+		return nil, queued[0]
+	}
+
+	handler := s.handlers[method]
+	s.mu.Unlock()
+	if handler != nil {
+		return handler(params)
+	}
+
+	switch method {
+	case "core.ping":
+		return "pong", nil
+	case "core.get_jobs":
+		return s.getJobs(params)
+	case "core.job_abort":
+		return s.abortJob(params)
+	case "system.version":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.Version, nil
+	case "core.get_methods":
+		return s.getMethods(), nil
+	case "core.subscribe":
+		return s.subscribe(c, params)
+	case "core.unsubscribe":
+		return s.unsubscribe(c, params)
+	}
+
+	return s.callNamespace(method, params)
+}
+
+// loginEx implements auth.login_ex. The caller must hold s.mu.
+func (s *Server) loginEx(c *conn, params []interface{}) (interface{}, error) {
+	data, _ := param(params, 0).(map[string]interface{})
+	username, _ := data["username"].(string)
+
+	var ok bool
+	switch mechanism, _ := data["mechanism"].(string); mechanism {
+	case "PASSWORD_PLAIN":
+		password, _ := data["password"].(string)
+		ok = username == s.Username && password == s.Password
+	case "API_KEY_PLAIN":
+		key, _ := data["api_key"].(string)
+		ok = username == s.Username && key == s.APIKey
+	default:
+		return nil, &Error{Code: codeInvalidParams, Message: fmt.Sprintf("Invalid params: unsupported mechanism %q", mechanism)}
+	}
+
+	c.authenticated = ok
+	if !ok {
+		return map[string]interface{}{"response_type": "AUTH_ERR"}, nil
+	}
+	return map[string]interface{}{"response_type": "SUCCESS"}, nil
+}
+
+// builtinMethods are the methods the server implements outside namespaces
+var builtinMethods = []string{
+	"auth.login_with_api_key", "auth.login_ex", "system.version", "core.get_methods", "core.ping",
+	"core.get_jobs", "core.job_abort", "core.subscribe", "core.unsubscribe",
+}
+
+// getMethods lists every method the server offers, like core.get_methods.
+// The values are empty where the middleware describes each method.
+func (s *Server) getMethods() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	methods := make(map[string]interface{})
+	for _, name := range builtinMethods {
+		methods[name] = map[string]interface{}{}
+	}
+	for name, ns := range s.namespaces {
+		for _, verb := range []string{"query", "get_instance", "create", "update", "delete"} {
+			methods[name+"."+verb] = map[string]interface{}{}
+		}
+		for verb := range ns.methods {
+			methods[name+"."+verb] = map[string]interface{}{}
+		}
+	}
+	for name := range s.handlers {
+		methods[name] = map[string]interface{}{}
+	}
+	return methods
+}
+
+// notify sends a collection_update event to connections subscribed to name
+func (s *Server) notify(name string, msg string, id interface{}, fields map[string]interface{}) {
+	s.mu.Lock()
+	var targets []*conn
+	for c := range s.conns {
+		for _, subscribed := range c.subscriptions {
+			if subscribed == name {
+				targets = append(targets, c)
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	event := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "collection_update",
+		"params": map[string]interface{}{
+			"msg":        msg,
+			"collection": name,
+			"id":         id,
+			"fields":     fields,
+		},
+	}
+	for _, c := range targets {
+		c.write(event)
+	}
+}
+
+func (s *Server) subscribe(c *conn, params []interface{}) (interface{}, error) {
+	name, ok := param(params, 0).(string)
+	if !ok || name == "" {
+		return nil, &Error{Code: codeInvalidParams, Message: "Invalid params: event name is required"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextSubID++
+	id := fmt.Sprintf("sub-%d", s.nextSubID)
+	c.subscriptions[id] = name
+	return id, nil
+}
+
+func (s *Server) unsubscribe(c *conn, params []interface{}) (interface{}, error) {
+	id, _ := param(params, 0).(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(c.subscriptions, id)
+	return nil, nil
+}
+
+// param returns params[i], or nil when it is missing
+func param(params []interface{}, i int) interface{} {
+	if i < len(params) {
+		return params[i]
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package truenastest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trueform/terraform-provider-trueform/pkg/truenas"
+)
+
+func newClient(t *testing.T, srv *Server) *truenas.Client {
+	t.Helper()
+	c := truenas.NewClient(&truenas.Config{Host: srv.Host(), APIKey: srv.APIKey})
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func newServer(t *testing.T) *Server {
+	t.Helper()
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAuthentication(t *testing.T) {
+	srv := newServer(t)
+	c := truenas.NewClient(&truenas.Config{Host: srv.Host(), APIKey: "wrong"})
+	defer c.Close()
+
+	err := c.Connect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid API key") {
+		t.Errorf("Connect() error = %v, want invalid API key", err)
+	}
+}
+
+func TestPasswordLogin(t *testing.T) {
+	srv := newServer(t)
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{name: "valid password", password: DefaultPassword},
+		{name: "wrong password", password: "wrong", wantErr: "invalid username or password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := truenas.NewClient(&truenas.Config{Host: srv.Host(), Username: DefaultUsername, Password: tt.password})
+			defer c.Close()
+
+			err := c.Connect(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Connect() error = %v", err)
+				}
+				var pools []map[string]interface{}
+				if err := c.Query(context.Background(), "pool", nil, &pools); err != nil {
+					t.Errorf("Query() after login error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Connect() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	srv := newServer(t)
+	srv.Version = "24.10.2"
+	c := newClient(t, srv)
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	if got := c.Version().String(); got != "24.10.2" {
+		t.Errorf("Version() = %v, want 24.10.2", got)
+	}
+	for _, method := range []string{"pool.dataset.create", "vm.start", "core.get_jobs"} {
+		if !c.HasMethod(context.Background(), method) {
+			t.Errorf("HasMethod(%q) = false", method)
+		}
+	}
+	if c.HasMethod(context.Background(), "pool.dataset.promote") {
+		t.Error("HasMethod(pool.dataset.promote) = true for a method the server lacks")
+	}
+}
+
+func TestCRUD(t *testing.T) {
+	tests := []struct {
+		namespace string
+		data      map[string]interface{}
+		wantID    interface{}
+		update    map[string]interface{}
+		field     string
+	}{
+		{namespace: "pool.dataset", data: map[string]interface{}{"name": "tank/data", "compression": "LZ4"}, wantID: "tank/data", update: map[string]interface{}{"comments": "updated"}, field: "comments"},
+		{namespace: "zfs.snapshot", data: map[string]interface{}{"dataset": "tank/data", "name": "snap1"}, wantID: "tank/data@snap1", update: map[string]interface{}{"user_properties_update": map[string]interface{}{"a:b": "c"}}, field: "user_properties"},
+		{namespace: "sharing.smb", data: map[string]interface{}{"path": "/mnt/tank/data", "name": "data"}, wantID: float64(1), update: map[string]interface{}{"comment": "updated"}, field: "comment"},
+		{namespace: "sharing.nfs", data: map[string]interface{}{"path": "/mnt/tank/data"}, wantID: float64(1), update: map[string]interface{}{"comment": "updated"}, field: "comment"},
+		{namespace: "user", data: map[string]interface{}{"username": "alice", "full_name": "Alice", "password": "secret"}, wantID: float64(1), update: map[string]interface{}{"full_name": "Alice B"}, field: "full_name"},
+		{namespace: "vm", data: map[string]interface{}{"name": "vm1", "memory": 512}, wantID: float64(1), update: map[string]interface{}{"memory": 1024}, field: "memory"},
+		{namespace: "vm.device", data: map[string]interface{}{"vm": 1, "dtype": "DISK"}, wantID: float64(1), update: map[string]interface{}{"order": 1001}, field: "order"},
+		{namespace: "cronjob", data: map[string]interface{}{"user": "root", "command": "true"}, wantID: float64(1), update: map[string]interface{}{"enabled": false}, field: "enabled"},
+		{namespace: "certificate", data: map[string]interface{}{"name": "cert"}, wantID: float64(1), update: map[string]interface{}{"name": "renamed"}, field: "name"},
+		{namespace: "staticroute", data: map[string]interface{}{"destination": "10.0.0.0/8", "gateway": "192.168.1.1"}, wantID: float64(1), update: map[string]interface{}{"description": "lab"}, field: "description"},
+		{namespace: "iscsi.portal", data: map[string]interface{}{"listen": []interface{}{}}, wantID: float64(1), update: map[string]interface{}{"comment": "updated"}, field: "comment"},
+		{namespace: "iscsi.target", data: map[string]interface{}{"name": "target"}, wantID: float64(1), update: map[string]interface{}{"alias": "t"}, field: "alias"},
+		{namespace: "iscsi.extent", data: map[string]interface{}{"name": "extent", "type": "DISK"}, wantID: float64(1), update: map[string]interface{}{"comment": "updated"}, field: "comment"},
+		{namespace: "iscsi.initiator", data: map[string]interface{}{"initiators": []interface{}{}}, wantID: float64(1), update: map[string]interface{}{"comment": "updated"}, field: "comment"},
+		{namespace: "iscsi.targetextent", data: map[string]interface{}{"target": 1, "extent": 1, "lunid": 0}, wantID: float64(1), update: map[string]interface{}{"lunid": 1}, field: "lunid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			srv := newServer(t)
+			c := newClient(t, srv)
+			ctx := context.Background()
+
+			var created map[string]interface{}
+			if err := c.Create(ctx, tt.namespace, tt.data, &created); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if created["id"] != tt.wantID {
+				t.Fatalf("Create() id = %v, want %v", created["id"], tt.wantID)
+			}
+
+			var updated map[string]interface{}
+			if err := c.Update(ctx, tt.namespace, tt.wantID, tt.update, &updated); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			var got map[string]interface{}
+			if err := c.GetInstance(ctx, tt.namespace, tt.wantID, &got); err != nil {
+				t.Fatalf("GetInstance() error = %v", err)
+			}
+			if got[tt.field] == nil {
+				t.Errorf("GetInstance()[%q] = nil, want updated value", tt.field)
+			}
+
+			var items []map[string]interface{}
+			if err := c.Query(ctx, tt.namespace, truenas.NewQueryParams().WithFilter("id", "=", tt.wantID), &items); err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if len(items) != 1 {
+				t.Errorf("Query() returned %d items, want 1", len(items))
+			}
+
+			if err := c.Delete(ctx, tt.namespace, tt.wantID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			err := c.GetInstance(ctx, tt.namespace, tt.wantID, &got)
+			if !truenas.IsNotFoundError(err) {
+				t.Errorf("GetInstance() after delete error = %v, want not found", err)
+			}
+		})
+	}
+}
+
+func TestDatasetProperties(t *testing.T) {
+	srv := newServer(t)
+	srv.Put("pool.dataset", map[string]interface{}{"name": "tank/data", "compression": "LZ4", "quota": 1073741824, "copies": 2})
+
+	var got map[string]interface{}
+	if err := newClient(t, srv).GetInstance(context.Background(), "pool.dataset", "tank/data", &got); err != nil {
+		t.Fatalf("GetInstance() error = %v", err)
+	}
+
+	compression, _ := got["compression"].(map[string]interface{})
+	if compression["value"] != "LZ4" {
+		t.Errorf("compression = %v, want value LZ4", got["compression"])
+	}
+	quota, _ := got["quota"].(map[string]interface{})
+	if quota["parsed"] != float64(1073741824) || quota["value"] != "1073741824" {
+		t.Errorf("quota = %v, want parsed 1073741824", got["quota"])
+	}
+	copies, _ := got["copies"].(map[string]interface{})
+	if copies["value"] != "2" {
+		t.Errorf("copies = %v, want value 2", got["copies"])
+	}
+	if got["type"] != "FILESYSTEM" || got["pool"] != "tank" {
+		t.Errorf("type = %v, pool = %v, want FILESYSTEM, tank", got["type"], got["pool"])
+	}
+}
+
+func TestQuery(t *testing.T) {
+	srv := newServer(t)
+	for _, name := range []string{"tank/a", "tank/b", "tank/c/d", "backup/a"} {
+		srv.Put("pool.dataset", map[string]interface{}{"name": name})
+	}
+	c := newClient(t, srv)
+
+	tests := []struct {
+		name   string
+		params *truenas.QueryParams
+		want   []string
+	}{
+		{
+			name:   "prefix",
+			params: truenas.NewQueryParams().Where(truenas.Field("name").StartsWith("tank/")),
+			want:   []string{"tank/a", "tank/b", "tank/c/d"},
+		},
+		{
+			name: "or with nested and",
+			params: truenas.NewQueryParams().Where(truenas.Or(
+				truenas.Field("pool").Eq("backup"),
+				truenas.And(truenas.Field("pool").Eq("tank"), truenas.Field("name").EndsWith("/d")),
+			)),
+			want: []string{"tank/c/d", "backup/a"},
+		},
+		{
+			name:   "order limit offset",
+			params: truenas.NewQueryParams().WithOrderBy("-name").WithLimit(2).WithOffset(1),
+			want:   []string{"tank/b", "tank/a"},
+		},
+		{
+			name:   "in",
+			params: truenas.NewQueryParams().Where(truenas.Field("id").In("tank/a", "backup/a")),
+			want:   []string{"tank/a", "backup/a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []map[string]interface{}
+			if err := c.Query(context.Background(), "pool.dataset", tt.params, &items); err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			var got []string
+			for _, item := range items {
+				got = append(got, item["name"].(string))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Query() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var count int
+	if err := c.Query(context.Background(), "pool.dataset", &truenas.QueryParams{Count: true}, &count); err != nil {
+		t.Fatalf("Query(count) error = %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Query(count) = %d, want 4", count)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	t.Run("pool create runs as a job", func(t *testing.T) {
+		srv := newServer(t)
+		c := newClient(t, srv)
+
+		result, err := c.CreateWithJob(context.Background(), "pool", map[string]interface{}{"name": "tank"}, time.Minute)
+		if err != nil {
+			t.Fatalf("CreateWithJob() error = %v", err)
+		}
+		if result["id"] != float64(1) || result["path"] != "/mnt/tank" || result["healthy"] != true {
+			t.Errorf("CreateWithJob() result = %v", result)
+		}
+	})
+
+	t.Run("app lifecycle", func(t *testing.T) {
+		srv := newServer(t)
+		c := newClient(t, srv)
+		ctx := context.Background()
+
+		if _, err := c.CreateWithJob(ctx, "app", map[string]interface{}{"app_name": "web", "catalog_app": "nginx", "version": "1.0.0"}, time.Minute); err != nil {
+			t.Fatalf("CreateWithJob() error = %v", err)
+		}
+
+		var jobID int64
+		if err := c.Call(ctx, "app.upgrade", []interface{}{"web", map[string]interface{}{"app_version": "1.1.0"}}, &jobID); err != nil {
+			t.Fatalf("app.upgrade error = %v", err)
+		}
+		if _, err := c.WaitForJob(ctx, jobID, time.Minute); err != nil {
+			t.Fatalf("WaitForJob() error = %v", err)
+		}
+
+		app := srv.Get("app", "web")
+		metadata, _ := app["metadata"].(map[string]interface{})
+		if app["name"] != "web" || metadata["app_version"] != "1.1.0" {
+			t.Errorf("app = %v, want web at 1.1.0", app)
+		}
+	})
+
+	t.Run("injected job failure", func(t *testing.T) {
+		srv := newServer(t)
+		srv.FailNextJob("pool.create", "[EFAULT] Disk sdb is in use")
+		c := newClient(t, srv)
+
+		_, err := c.CreateWithJob(context.Background(), "pool", map[string]interface{}{"name": "tank"}, time.Minute)
+		if !truenas.IsJobError(err) || !strings.Contains(err.Error(), "Disk sdb is in use") {
+			t.Errorf("CreateWithJob() error = %v, want job error", err)
+		}
+		if len(srv.Items("pool")) != 0 {
+			t.Error("failed job should not create the pool")
+		}
+	})
+}
+
+func TestErrorInjection(t *testing.T) {
+	srv := newServer(t)
+	srv.FailNext("pool.dataset.create", ValidationError("pool_dataset_create.quota", "Must be greater than 1 GiB"))
+	c := newClient(t, srv)
+	ctx := context.Background()
+
+	data := map[string]interface{}{"name": "tank/data"}
+	err := c.Create(ctx, "pool.dataset", data, nil)
+	verrs, ok := truenas.AsValidationErrors(err)
+	if !ok || len(verrs) != 1 || verrs[0].Field()[0] != "quota" {
+		t.Fatalf("Create() error = %v, want quota validation error", err)
+	}
+
+	if err := c.Create(ctx, "pool.dataset", data, nil); err != nil {
+		t.Fatalf("Create() after injected failure error = %v", err)
+	}
+
+	srv.Handle("system.info", func(params []interface{}) (interface{}, error) {
+		return map[string]interface{}{"version": "25.04.0"}, nil
+	})
+	var info map[string]interface{}
+	if err := c.Call(ctx, "system.info", nil, &info); err != nil || info["version"] != "25.04.0" {
+		t.Errorf("system.info = %v, %v, want handler result", info, err)
+	}
+
+	if n := srv.CallCount("pool.dataset.create"); n != 2 {
+		t.Errorf("CallCount(pool.dataset.create) = %d, want 2", n)
+	}
+}
+
+func TestDropConnections(t *testing.T) {
+	srv := newServer(t)
+	c := newClient(t, srv)
+	ctx := context.Background()
+
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	srv.DropConnections()
+
+	var pools []map[string]interface{}
+	if err := c.Query(ctx, "pool", nil, &pools); err != nil {
+		t.Fatalf("Query() after dropped connection error = %v", err)
+	}
+	if n := srv.CallCount("auth.login_with_api_key"); n != 2 {
+		t.Errorf("auth.login_with_api_key called %d times, want 2", n)
+	}
+}
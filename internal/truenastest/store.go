@@ -0,0 +1,564 @@
+package truenastest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// methodFunc implements a namespace-specific method. It is called without
+// Server.mu held.
+type methodFunc func(s *Server, ns string, params []interface{}) (interface{}, error)
+
+// namespace describes how the fake serves one middleware namespace
+type namespace struct {
+	// key returns the id of a new item; nil assigns sequential integers
+	key func(data map[string]interface{}) interface{}
+	// shape fills in the fields the middleware computes
+	shape func(item map[string]interface{})
+	// jobs lists the methods that run as jobs and return a job id
+	jobs map[string]bool
+	// methods adds or overrides methods of the namespace
+	methods map[string]methodFunc
+}
+
+// collection is the in-memory state of a namespace, in insertion order
+type collection struct {
+	items  []map[string]interface{}
+	nextID int64
+}
+
+// defaultNamespaces returns every namespace the provider uses
+func defaultNamespaces() map[string]*namespace {
+	return map[string]*namespace{
+		"pool": {
+			shape:   shapePool,
+			jobs:    map[string]bool{"create": true, "export": true},
+			methods: map[string]methodFunc{"export": deleteMethod},
+		},
+		"pool.dataset": {
+			key:   keyField("name"),
+			shape: shapeDataset,
+		},
+		"zfs.snapshot": {
+			key: func(data map[string]interface{}) interface{} {
+				return fmt.Sprintf("%v@%v", data["dataset"], data["name"])
+			},
+			shape: shapeSnapshot,
+		},
+		"sharing.smb":        {},
+		"sharing.nfs":        {},
+		"user":               {shape: shapeUser},
+		"vm":                 {shape: shapeVM, jobs: map[string]bool{"stop": true}, methods: map[string]methodFunc{"start": vmState("RUNNING"), "stop": vmState("STOPPED")}},
+		"vm.device":          {},
+		"cronjob":            {},
+		"certificate":        {},
+		"staticroute":        {},
+		"iscsi.portal":       {},
+		"iscsi.target":       {},
+		"iscsi.extent":       {},
+		"iscsi.initiator":    {},
+		"iscsi.targetextent": {},
+		"app": {
+			key:     keyField("app_name"),
+			shape:   shapeApp,
+			jobs:    map[string]bool{"create": true, "update": true, "upgrade": true, "delete": true},
+			methods: map[string]methodFunc{"upgrade": appUpgrade},
+		},
+	}
+}
+
+// Put stores item in namespace as if it had been created, filling in
+// computed fields and an id when missing. It returns the stored item.
+func (s *Server) Put(ns string, item map[string]interface{}) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.create(ns, item)
+	if err != nil {
+		panic(err)
+	}
+	return copyItem(stored)
+}
+
+// Get returns a copy of an item, or nil when it does not exist
+func (s *Server) Get(ns string, id interface{}) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, _ := s.find(ns, id); item != nil {
+		return copyItem(item)
+	}
+	return nil
+}
+
+// Items returns copies of every item in a namespace
+func (s *Server) Items(ns string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []map[string]interface{}
+	if coll := s.collections[ns]; coll != nil {
+		for _, item := range coll.items {
+			out = append(out, copyItem(item))
+		}
+	}
+	return out
+}
+
+// callNamespace serves <namespace>.<verb> methods
+func (s *Server) callNamespace(method string, params []interface{}) (interface{}, error) {
+	dot := strings.LastIndex(method, ".")
+	if dot < 0 {
+		return nil, methodNotFound(method)
+	}
+	name, verb := method[:dot], method[dot+1:]
+	ns := s.namespaces[name]
+	if ns == nil {
+		return nil, methodNotFound(method)
+	}
+
+	run := func() (interface{}, error) {
+		if fn := ns.methods[verb]; fn != nil {
+			return fn(s, name, params)
+		}
+		switch verb {
+		case "query":
+			return s.query(name, params)
+		case "get_instance":
+			return s.getInstance(name, params)
+		case "create":
+			return s.createMethod(name, params)
+		case "update":
+			return s.updateMethod(name, params)
+		case "delete":
+			return deleteMethod(s, name, params)
+		}
+		return nil, methodNotFound(method)
+	}
+
+	if ns.jobs[verb] {
+		return s.startJob(method, params, run), nil
+	}
+	return run()
+}
+
+func (s *Server) query(ns string, params []interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []map[string]interface{}
+	if coll := s.collections[ns]; coll != nil {
+		items = coll.items
+	}
+	return queryItems(items, params)
+}
+
+func (s *Server) getInstance(ns string, params []interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := param(params, 0)
+	item, _ := s.find(ns, id)
+	if item == nil {
+		return nil, NotFound(ns, id)
+	}
+	return copyItem(item), nil
+}
+
+func (s *Server) createMethod(ns string, params []interface{}) (interface{}, error) {
+	data, ok := param(params, 0).(map[string]interface{})
+	if !ok {
+		return nil, &Error{Code: codeInvalidParams, Message: "Invalid params: data must be an object"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.create(ns, data)
+	if err != nil {
+		return nil, err
+	}
+	return copyItem(item), nil
+}
+
+func (s *Server) updateMethod(ns string, params []interface{}) (interface{}, error) {
+	id := param(params, 0)
+	data, ok := param(params, 1).(map[string]interface{})
+	if !ok {
+		return nil, &Error{Code: codeInvalidParams, Message: "Invalid params: data must be an object"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, _ := s.find(ns, id)
+	if item == nil {
+		return nil, NotFound(ns, id)
+	}
+	for k, v := range normalize(data).(map[string]interface{}) {
+		if k == "id" {
+			continue
+		}
+		item[k] = v
+	}
+	if shape := s.namespaces[ns].shape; shape != nil {
+		shape(item)
+	}
+	return copyItem(item), nil
+}
+
+// deleteMethod removes the item named by the first parameter. Options such
+// as recursive are ignored.
+func deleteMethod(s *Server, ns string, params []interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := param(params, 0)
+	item, i := s.find(ns, id)
+	if item == nil {
+		return nil, NotFound(ns, id)
+	}
+	coll := s.collections[ns]
+	coll.items = append(coll.items[:i], coll.items[i+1:]...)
+	return true, nil
+}
+
+// create stores a new item. The caller must hold s.mu.
+func (s *Server) create(ns string, data map[string]interface{}) (map[string]interface{}, error) {
+	def := s.namespaces[ns]
+	if def == nil {
+		return nil, fmt.Errorf("unknown namespace %q", ns)
+	}
+	coll := s.collections[ns]
+	if coll == nil {
+		coll = &collection{}
+		s.collections[ns] = coll
+	}
+
+	item := normalize(data).(map[string]interface{})
+	switch {
+	case def.key != nil:
+		item["id"] = normalize(def.key(item))
+	case item["id"] == nil:
+		coll.nextID++
+		item["id"] = float64(coll.nextID)
+	default:
+		if id, ok := item["id"].(float64); ok && int64(id) > coll.nextID {
+			coll.nextID = int64(id)
+		}
+	}
+
+	if existing, _ := s.find(ns, item["id"]); existing != nil {
+		return nil, &Error{
+			Code:    codeCallError,
+			Message: fmt.Sprintf("[EEXIST] %s %v already exists", ns, item["id"]),
+			Errname: "EEXIST",
+			Errno:   17,
+		}
+	}
+	if def.shape != nil {
+		def.shape(item)
+	}
+	coll.items = append(coll.items, item)
+	return item, nil
+}
+
+// find returns an item and its index. The caller must hold s.mu.
+func (s *Server) find(ns string, id interface{}) (map[string]interface{}, int) {
+	coll := s.collections[ns]
+	if coll == nil {
+		return nil, -1
+	}
+	id = normalize(id)
+	for i, item := range coll.items {
+		if reflect.DeepEqual(item["id"], id) {
+			return item, i
+		}
+	}
+	return nil, -1
+}
+
+// queryItems applies query-filters and query-options to items
+func queryItems(items []map[string]interface{}, params []interface{}) (interface{}, error) {
+	filters, _ := param(params, 0).([]interface{})
+	options, _ := param(params, 1).(map[string]interface{})
+
+	var matched []map[string]interface{}
+	for _, item := range items {
+		ok, err := matchAll(item, filters)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+	}
+
+	if orderBy, ok := options["order_by"].([]interface{}); ok {
+		sortItems(matched, orderBy)
+	}
+	if offset, ok := options["offset"].(float64); ok && offset > 0 {
+		if int(offset) >= len(matched) {
+			matched = nil
+		} else {
+			matched = matched[int(offset):]
+		}
+	}
+	if limit, ok := options["limit"].(float64); ok && limit > 0 && int(limit) < len(matched) {
+		matched = matched[:int(limit)]
+	}
+
+	if count, _ := options["count"].(bool); count {
+		return len(matched), nil
+	}
+
+	selected, _ := options["select"].([]interface{})
+	out := make([]interface{}, 0, len(matched))
+	for _, item := range matched {
+		out = append(out, selectFields(item, selected))
+	}
+
+	if get, _ := options["get"].(bool); get {
+		if len(out) == 0 {
+			return nil, &Error{Code: codeCallError, Message: "[ENOENT] MatchNotFound", Errname: "ENOENT", Errno: 2}
+		}
+		return out[0], nil
+	}
+	return out, nil
+}
+
+// matchAll reports whether item matches every filter
+func matchAll(item map[string]interface{}, filters []interface{}) (bool, error) {
+	for _, f := range filters {
+		ok, err := match(item, f)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// match evaluates a [field, op, value] condition or an ["OR", [...]] term
+func match(item map[string]interface{}, filter interface{}) (bool, error) {
+	terms, ok := filter.([]interface{})
+	if !ok {
+		return false, invalidFilter(filter)
+	}
+
+	if len(terms) == 2 && terms[0] == "OR" {
+		branches, ok := terms[1].([]interface{})
+		if !ok {
+			return false, invalidFilter(filter)
+		}
+		for _, branch := range branches {
+			var ok bool
+			var err error
+			if isCondition(branch) {
+				ok, err = match(item, branch)
+			} else {
+				nested, _ := branch.([]interface{})
+				ok, err = matchAll(item, nested)
+			}
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+
+	if len(terms) != 3 {
+		return false, invalidFilter(filter)
+	}
+	field, _ := terms[0].(string)
+	op, _ := terms[1].(string)
+	return compare(lookup(item, field), op, terms[2])
+}
+
+// isCondition tells a single condition apart from a nested list of them
+func isCondition(term interface{}) bool {
+	terms, ok := term.([]interface{})
+	if !ok || len(terms) == 0 {
+		return false
+	}
+	_, ok = terms[0].(string)
+	return ok
+}
+
+// compare applies a filter operator
+func compare(got interface{}, op string, want interface{}) (bool, error) {
+	switch op {
+	case "=":
+		return reflect.DeepEqual(got, want), nil
+	case "!=":
+		return !reflect.DeepEqual(got, want), nil
+	case ">", ">=", "<", "<=":
+		c, ok := order(got, want)
+		if !ok {
+			return false, nil
+		}
+		switch op {
+		case ">":
+			return c > 0, nil
+		case ">=":
+			return c >= 0, nil
+		case "<":
+			return c < 0, nil
+		default:
+			return c <= 0, nil
+		}
+	case "in", "nin":
+		found := contains(want, got)
+		return found == (op == "in"), nil
+	case "rin", "rnin":
+		found := contains(got, want)
+		return found == (op == "rin"), nil
+	case "^", "!^", "$", "!$":
+		s, ok1 := got.(string)
+		affix, ok2 := want.(string)
+		if !ok1 || !ok2 {
+			return false, nil
+		}
+		var found bool
+		if strings.HasSuffix(op, "^") {
+			found = strings.HasPrefix(s, affix)
+		} else {
+			found = strings.HasSuffix(s, affix)
+		}
+		return found != strings.HasPrefix(op, "!"), nil
+	case "~":
+		s, ok1 := got.(string)
+		pattern, ok2 := want.(string)
+		if !ok1 || !ok2 {
+			return false, nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, &Error{Code: codeInvalidParams, Message: "Invalid params: " + err.Error()}
+		}
+		return re.MatchString(s), nil
+	}
+	return false, &Error{Code: codeInvalidParams, Message: fmt.Sprintf("Invalid params: unsupported filter operator %q", op)}
+}
+
+// order compares two numbers or two strings
+func order(a, b interface{}) (int, bool) {
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av < bv:
+			return -1, true
+		case av > bv:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(av, bv), true
+	}
+	return 0, false
+}
+
+// contains reports whether list holds v
+func contains(list interface{}, v interface{}) bool {
+	values, ok := list.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range values {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup resolves a dotted field path such as "compression.value"
+func lookup(item map[string]interface{}, field string) interface{} {
+	var cur interface{} = item
+	for _, part := range strings.Split(field, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+// sortItems sorts by order_by fields; a "-" prefix sorts descending
+func sortItems(items []map[string]interface{}, orderBy []interface{}) {
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, o := range orderBy {
+			field, _ := o.(string)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+
+			c, _ := order(lookup(items[i], field), lookup(items[j], field))
+			if c != 0 {
+				return (c < 0) != desc
+			}
+		}
+		return false
+	})
+}
+
+// selectFields returns a copy of item limited to the selected top-level
+// fields, or all fields when none are selected
+func selectFields(item map[string]interface{}, selected []interface{}) map[string]interface{} {
+	if len(selected) == 0 {
+		return copyItem(item)
+	}
+	out := make(map[string]interface{}, len(selected))
+	for _, s := range selected {
+		if field, ok := s.(string); ok {
+			if v, ok := item[field]; ok {
+				out[field] = normalize(v)
+			}
+		}
+	}
+	return out
+}
+
+// normalize round-trips v through JSON so stored values have the same types
+// as decoded request parameters
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// copyItem returns a deep copy of item
+func copyItem(item map[string]interface{}) map[string]interface{} {
+	return normalize(item).(map[string]interface{})
+}
+
+func keyField(name string) func(data map[string]interface{}) interface{} {
+	return func(data map[string]interface{}) interface{} {
+		return data[name]
+	}
+}
+
+func methodNotFound(method string) *Error {
+	return &Error{Code: codeMethodNotFound, Message: fmt.Sprintf("Method %q not found", method)}
+}
+
+func invalidFilter(filter interface{}) *Error {
+	return &Error{Code: codeInvalidParams, Message: fmt.Sprintf("Invalid params: invalid query filter %v", filter)}
+}
@@ -0,0 +1,44 @@
+package truenas
+
+// User is a local user as returned by user.query and user.get_instance. The
+// middleware never returns passwords.
+type User struct {
+	ID               int64       `json:"id"`
+	UID              int64       `json:"uid"`
+	Username         string      `json:"username"`
+	FullName         *string     `json:"full_name"`
+	Email            *string     `json:"email"`
+	PasswordDisabled *bool       `json:"password_disabled"`
+	Group            *Reference  `json:"group"`
+	Groups           []Reference `json:"groups"`
+	Home             *string     `json:"home"`
+	Shell            *string     `json:"shell"`
+	SSHPubKey        *string     `json:"sshpubkey"`
+	Locked           *bool       `json:"locked"`
+	SMB              *bool       `json:"smb"`
+	Sudo             *bool       `json:"sudo"`
+	SudoNopasswd     *bool       `json:"sudo_nopasswd"`
+	SudoCommands     []string    `json:"sudo_commands"`
+	Builtin          *bool       `json:"builtin"`
+}
+
+// UserRequest is the data of user.create and user.update. Nil fields are
+// left out.
+type UserRequest struct {
+	Username         *string  `json:"username,omitempty"`
+	UID              *int64   `json:"uid,omitempty"`
+	FullName         *string  `json:"full_name,omitempty"`
+	Email            *string  `json:"email,omitempty"`
+	Password         *string  `json:"password,omitempty"`
+	PasswordDisabled *bool    `json:"password_disabled,omitempty"`
+	Group            *int64   `json:"group,omitempty"`
+	GroupCreate      *bool    `json:"group_create,omitempty"`
+	Groups           *[]int64 `json:"groups,omitempty"`
+	Home             *string  `json:"home,omitempty"`
+	HomeMode         *string  `json:"home_mode,omitempty"`
+	HomeCreate       *bool    `json:"home_create,omitempty"`
+	Shell            *string  `json:"shell,omitempty"`
+	SSHPubKey        *string  `json:"sshpubkey,omitempty"`
+	Locked           *bool    `json:"locked,omitempty"`
+	SMB              *bool    `json:"smb,omitempty"`
+}
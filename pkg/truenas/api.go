@@ -0,0 +1,28 @@
+package truenas
+
+import (
+	"context"
+	"time"
+)
+
+// API is the part of the client that resources and data sources use. *Client
+// implements it; tests can substitute a fake that answers with canned
+// payloads.
+type API interface {
+	Call(ctx context.Context, method string, params interface{}, result interface{}, opts ...CallOption) error
+	Query(ctx context.Context, resource string, params *QueryParams, result interface{}) error
+	GetInstance(ctx context.Context, resource string, id interface{}, result interface{}) error
+	Create(ctx context.Context, resource string, data interface{}, result interface{}, opts ...CallOption) error
+	Update(ctx context.Context, resource string, id interface{}, data interface{}, result interface{}, opts ...CallOption) error
+	Delete(ctx context.Context, resource string, id interface{}, opts ...CallOption) error
+	DeleteWithOptions(ctx context.Context, resource string, id interface{}, options interface{}, opts ...CallOption) error
+
+	WaitForJob(ctx context.Context, jobID int64, timeout time.Duration) (map[string]interface{}, error)
+	CreateWithJob(ctx context.Context, resource string, data interface{}, timeout time.Duration) (map[string]interface{}, error)
+	UpdateWithJob(ctx context.Context, resource string, id interface{}, data interface{}, timeout time.Duration) (map[string]interface{}, error)
+
+	Lock(ctx context.Context, key string) (func(), error)
+	RequireVersion(minimum string) error
+}
+
+var _ API = (*Client)(nil)
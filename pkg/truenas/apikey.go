@@ -0,0 +1,80 @@
+package truenas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// apiKeyCommandTimeout bounds an APIKeyCommand, so a command waiting for
+// input cannot hang the login
+const apiKeyCommandTimeout = 30 * time.Second
+
+// LoadAPIKey returns the API key cfg configures: APIKey, or the trimmed
+// contents of APIKeyFile or output of APIKeyCommand. It returns "" when
+// none of them is set, and an error when more than one is.
+func LoadAPIKey(ctx context.Context, cfg *Config) (string, error) {
+	return loadAPIKey(ctx, cfg.APIKey, cfg.APIKeyFile, cfg.APIKeyCommand)
+}
+
+func loadAPIKey(ctx context.Context, apiKey, file, command string) (string, error) {
+	set := 0
+	for _, source := range []string{apiKey, file, command} {
+		if source != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return "", errors.New("only one of API key, API key file and API key command may be set")
+	}
+
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key file: %w", err)
+		}
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return "", fmt.Errorf("API key file %s is empty", file)
+		}
+		return key, nil
+	case command != "":
+		return runAPIKeyCommand(ctx, command)
+	}
+	return apiKey, nil
+}
+
+// runAPIKeyCommand runs command through the shell and returns its trimmed
+// output. Only stderr is quoted in errors, as stdout may hold the key.
+func runAPIKeyCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiKeyCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("API key command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("API key command failed: %w", err)
+	}
+	key := strings.TrimSpace(stdout.String())
+	if key == "" {
+		return "", errors.New("API key command printed nothing")
+	}
+	return key, nil
+}
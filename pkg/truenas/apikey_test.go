@@ -0,0 +1,87 @@
+package truenas
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/trueform/terraform-provider-trueform/internal/truenastest"
+)
+
+func TestLoadAPIKey(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "api-key")
+	if err := os.WriteFile(keyFile, []byte("  1-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    string
+		wantErr string
+	}{
+		{name: "key", cfg: Config{APIKey: "1-inline"}, want: "1-inline"},
+		{name: "none", cfg: Config{}, want: ""},
+		{name: "file", cfg: Config{APIKeyFile: keyFile}, want: "1-from-file"},
+		{name: "missing file", cfg: Config{APIKeyFile: filepath.Join(dir, "missing")}, wantErr: "failed to read API key file"},
+		{name: "empty file", cfg: Config{APIKeyFile: emptyFile}, wantErr: "is empty"},
+		{name: "command", cfg: Config{APIKeyCommand: "echo ' 1-from-command'"}, want: "1-from-command"},
+		{name: "failing command", cfg: Config{APIKeyCommand: "echo 'vault sealed' >&2; exit 3"}, wantErr: "vault sealed"},
+		{name: "silent command", cfg: Config{APIKeyCommand: "true"}, wantErr: "printed nothing"},
+		{name: "conflict", cfg: Config{APIKey: "1-inline", APIKeyFile: keyFile}, wantErr: "only one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadAPIKey(context.Background(), &tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadAPIKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadAPIKey() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("LoadAPIKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyFileRereadOnReconnect(t *testing.T) {
+	srv := truenastest.NewServer()
+	t.Cleanup(srv.Close)
+
+	keyFile := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(keyFile, []byte(srv.APIKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(&Config{Host: srv.Host(), APIKeyFile: keyFile})
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.Call(ctx, "core.ping", nil, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+
+	// Rotate the key, as Vault Agent would, and restart the middleware
+	srv.RotateAPIKey("2-rotated")
+	if err := os.WriteFile(keyFile, []byte("2-rotated\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srv.DropConnections()
+
+	var pools []map[string]interface{}
+	if err := c.Query(ctx, "pool", nil, &pools); err != nil {
+		t.Fatalf("Query() after rotation error = %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package truenas
+
+// App is an installed app as returned by app.query and app.get_instance.
+// Its ID is the app name.
+type App struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	State    *string      `json:"state"`
+	Metadata *AppMetadata `json:"metadata"`
+}
+
+// AppMetadata describes the catalog app an app was installed from
+type AppMetadata struct {
+	Name       *string `json:"name"`
+	AppVersion *string `json:"app_version"`
+	Train      *string `json:"train"`
+}
+
+// AppCreateRequest is the data of app.create
+type AppCreateRequest struct {
+	AppName    string                 `json:"app_name"`
+	CatalogApp string                 `json:"catalog_app"`
+	Train      *string                `json:"train,omitempty"`
+	Version    *string                `json:"version,omitempty"`
+	Values     map[string]interface{} `json:"values,omitempty"`
+}
+
+// AppUpdateRequest is the data of app.update
+type AppUpdateRequest struct {
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// AppUpgradeOptions are the options of app.upgrade
+type AppUpgradeOptions struct {
+	AppVersion string `json:"app_version"`
+}
@@ -0,0 +1,140 @@
+package truenas
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// auth.login_ex response types
+const (
+	loginSuccess     = "SUCCESS"
+	loginOTPRequired = "OTP_REQUIRED"
+	loginAuthError   = "AUTH_ERR"
+	loginExpired     = "EXPIRED"
+)
+
+// TOTP parameters TrueNAS uses for two-factor authentication
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+)
+
+// loginResponse is the result of auth.login_ex
+type loginResponse struct {
+	ResponseType string `json:"response_type"`
+}
+
+// authenticate logs in on a freshly dialed connection. It runs on every
+// connect, so reconnects log in again with the same mechanism.
+func (c *Client) authenticate(ctx context.Context) error {
+	hasAPIKey := c.apiKey != "" || c.apiKeyFile != "" || c.apiKeyCommand != ""
+	if !hasAPIKey && c.username != "" {
+		return c.loginWithPassword(ctx)
+	}
+	if !hasAPIKey && c.endpoint().settings.socketPath != "" {
+		// The local socket authenticates the connecting user itself
+		return nil
+	}
+
+	apiKey, err := loadAPIKey(ctx, c.apiKey, c.apiKeyFile, c.apiKeyCommand)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	var result bool
+	err = c.call(ctx, "auth.login_with_api_key", []interface{}{apiKey}, &result)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	if !result {
+		return fmt.Errorf("authentication failed: invalid API key")
+	}
+	return nil
+}
+
+// loginWithPassword logs in through auth.login_ex, answering a two-factor
+// challenge with the configured OTP token or a code generated from the OTP
+// secret
+func (c *Client) loginWithPassword(ctx context.Context) error {
+	var resp loginResponse
+	err := c.call(ctx, "auth.login_ex", []interface{}{map[string]interface{}{
+		"mechanism": "PASSWORD_PLAIN",
+		"username":  c.username,
+		"password":  c.password,
+	}}, &resp)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	switch resp.ResponseType {
+	case loginSuccess:
+		return nil
+	case loginOTPRequired:
+	case loginAuthError:
+		return fmt.Errorf("authentication failed: invalid username or password")
+	case loginExpired:
+		return fmt.Errorf("authentication failed: password for %s has expired", c.username)
+	default:
+		return fmt.Errorf("authentication failed: unexpected auth.login_ex response %q", resp.ResponseType)
+	}
+
+	token := c.otpToken
+	if c.otpSecret != "" {
+		if token, err = totpCode(c.otpSecret, time.Now()); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	if token == "" {
+		return fmt.Errorf("authentication failed: %s requires a one-time password but no OTP token or secret is configured", c.username)
+	}
+
+	err = c.call(ctx, "auth.login_ex", []interface{}{map[string]interface{}{
+		"mechanism": "OTP_TOKEN",
+		"otp_token": token,
+	}}, &resp)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	if resp.ResponseType != loginSuccess {
+		return fmt.Errorf("authentication failed: one-time password was rejected (%s)", resp.ResponseType)
+	}
+	return nil
+}
+
+// ValidateOTPSecret checks that secret is a usable base32 TOTP secret
+func ValidateOTPSecret(secret string) error {
+	_, err := totpCode(secret, time.Now())
+	return err
+}
+
+// totpCode returns the RFC 6238 code for a base32 secret at t
+func totpCode(secret string, t time.Time) (string, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(normalized, "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid OTP secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/int64(totpPeriod/time.Second)))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation as described in RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, code%mod), nil
+}
@@ -0,0 +1,178 @@
+package truenas
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// rfc6238Secret is the SHA-1 test key from RFC 6238 appendix B, base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := totpCode(rfc6238Secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("totpCode() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %v, want %v", tt.unix, got, tt.want)
+		}
+	}
+
+	// Lower case, spaces and padding are accepted
+	if _, err := totpCode("gezd gnbv gy3t qojq gezd gnbv gy3t qojq====", time.Now()); err != nil {
+		t.Errorf("totpCode() with formatted secret error = %v", err)
+	}
+	if err := ValidateOTPSecret("not base32!"); err == nil {
+		t.Error("ValidateOTPSecret() error = nil, want invalid secret")
+	}
+}
+
+// serveLogin answers auth.login_ex for user admin with password secret.
+// When otp is set the password step is followed by an OTP challenge.
+// Other methods are answered with true.
+func serveLogin(conn *websocket.Conn, otp func() string, logins *int32) {
+	for {
+		var req JSONRPCRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		var result interface{} = true
+		if req.Method == "auth.login_ex" {
+			args, _ := req.Params.([]interface{})
+			data, _ := args[0].(map[string]interface{})
+			responseType := "AUTH_ERR"
+			switch data["mechanism"] {
+			case "PASSWORD_PLAIN":
+				if data["username"] == "admin" && data["password"] == "secret" {
+					responseType = "SUCCESS"
+					if otp != nil {
+						responseType = "OTP_REQUIRED"
+					}
+				}
+			case "OTP_TOKEN":
+				if otp != nil && data["otp_token"] == otp() {
+					responseType = "SUCCESS"
+				}
+			}
+			if responseType == "SUCCESS" && logins != nil {
+				atomic.AddInt32(logins, 1)
+			}
+			result = map[string]interface{}{"response_type": responseType}
+		}
+		_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}
+}
+
+func TestLoginWithPassword(t *testing.T) {
+	currentCode := func() string {
+		code, _ := totpCode(rfc6238Secret, time.Now())
+		return code
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		otp     func() string
+		wantErr string
+	}{
+		{
+			name: "password",
+			cfg:  Config{Username: "admin", Password: "secret"},
+		},
+		{
+			name:    "wrong password",
+			cfg:     Config{Username: "admin", Password: "wrong"},
+			wantErr: "invalid username or password",
+		},
+		{
+			name: "otp token",
+			cfg:  Config{Username: "admin", Password: "secret", OTPToken: "123456"},
+			otp:  func() string { return "123456" },
+		},
+		{
+			name: "otp secret",
+			cfg:  Config{Username: "admin", Password: "secret", OTPSecret: rfc6238Secret},
+			otp:  currentCode,
+		},
+		{
+			name:    "otp required but not configured",
+			cfg:     Config{Username: "admin", Password: "secret"},
+			otp:     currentCode,
+			wantErr: "requires a one-time password",
+		},
+		{
+			name:    "otp rejected",
+			cfg:     Config{Username: "admin", Password: "secret", OTPToken: "000000"},
+			otp:     func() string { return "123456" },
+			wantErr: "one-time password was rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Host = startTestServer(t, func(conn *websocket.Conn, n int) {
+				serveLogin(conn, tt.otp, nil)
+			})
+			c := NewClient(&cfg)
+			defer c.Close()
+
+			err := c.Connect(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Connect() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Connect() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginAfterReconnect(t *testing.T) {
+	var logins int32
+	host := startTestServer(t, func(conn *websocket.Conn, n int) {
+		if n == 1 {
+			// Log in, then drop the connection before the first call is answered
+			var req JSONRPCRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			atomic.AddInt32(&logins, 1)
+			_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": map[string]interface{}{"response_type": "SUCCESS"}})
+			_ = conn.ReadJSON(&req)
+			return
+		}
+		serveLogin(conn, nil, &logins)
+	})
+
+	c := NewClient(&Config{Host: host, Username: "admin", Password: "secret"})
+	defer c.Close()
+
+	// pool.query is retried on the new connection after logging in again
+	if err := c.Call(context.Background(), "pool.query", []interface{}{}, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&logins); got != 2 {
+		t.Errorf("logged in %d times, want 2", got)
+	}
+}
@@ -0,0 +1,247 @@
+package truenas
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// CassetteMode selects whether a client records its JSON-RPC exchanges to a
+// cassette file or replays them from one instead of connecting
+type CassetteMode string
+
+const (
+	// CassetteRecord talks to the server and appends every exchange to the
+	// cassette
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay serves calls from the cassette without a server
+	CassetteReplay CassetteMode = "replay"
+)
+
+// cassetteEntry is one line of a cassette file: either a call with its
+// result or error, or a notification received after the preceding call.
+// Secret parameters and result fields are redacted.
+type cassetteEntry struct {
+	Method       string          `json:"method,omitempty"`
+	Params       interface{}     `json:"params,omitempty"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Error        *JSONRPCError   `json:"error,omitempty"`
+	Notification string          `json:"notification,omitempty"`
+	Event        json.RawMessage `json:"event,omitempty"`
+}
+
+// cassetteRecorder appends exchanges to a cassette file. Responses and
+// notifications are recorded by the reader in the order they arrive, and
+// each entry is written as it happens so the cassette survives the provider
+// being killed. The file is opened for appending so that the separate
+// provider processes Terraform starts for plan and apply add to the same
+// cassette instead of each overwriting the last.
+type cassetteRecorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	enc     *json.Encoder
+	pending map[int64]cassetteEntry
+}
+
+func newCassetteRecorder(path string) (*cassetteRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	return &cassetteRecorder{
+		file:    file,
+		enc:     json.NewEncoder(file),
+		pending: make(map[int64]cassetteEntry),
+	}, nil
+}
+
+// beginCall remembers a request until its response is recorded
+func (r *cassetteRecorder) beginCall(id int64, method string, params interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[id] = cassetteEntry{Method: method, Params: redactJSON(params)}
+}
+
+// recordResponse writes a call and its response
+func (r *cassetteRecorder) recordResponse(resp *JSONRPCResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.pending[resp.ID]
+	if !ok {
+		return
+	}
+	delete(r.pending, resp.ID)
+
+	entry.Error = resp.Error
+	if resp.Result != nil {
+		entry.Result = redactRaw(resp.Result)
+	}
+	_ = r.enc.Encode(&entry)
+}
+
+// recordNotification writes a notification from the server
+func (r *cassetteRecorder) recordNotification(method string, params json.RawMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(&cassetteEntry{Notification: method, Event: redactRaw(params)})
+}
+
+func (r *cassetteRecorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// replayedCall is a recorded call and the notifications that followed it
+type replayedCall struct {
+	cassetteEntry
+	key    string
+	events []cassetteEntry
+	used   bool
+}
+
+// cassettePlayer serves calls from a cassette. Each call is answered by the
+// first unused recording of the same method and params, so repeated polls
+// such as core.get_jobs play back in order; once they run out the last one
+// is repeated.
+type cassettePlayer struct {
+	path  string
+	mu    sync.Mutex
+	calls []*replayedCall
+}
+
+func loadCassette(path string) (*cassettePlayer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	defer file.Close()
+
+	p := &cassettePlayer{path: path}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry cassetteEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s line %d: %w", path, line, err)
+		}
+
+		if entry.Notification != "" {
+			if n := len(p.calls); n > 0 {
+				p.calls[n-1].events = append(p.calls[n-1].events, entry)
+			}
+			continue
+		}
+		p.calls = append(p.calls, &replayedCall{
+			cassetteEntry: entry,
+			key:           cassetteKey(entry.Method, entry.Params),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// play finds the recording that answers a call
+func (p *cassettePlayer) play(method string, params interface{}) (*replayedCall, error) {
+	key := cassetteKey(method, redactJSON(params))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var last *replayedCall
+	for _, call := range p.calls {
+		if call.key != key {
+			continue
+		}
+		if !call.used {
+			call.used = true
+			return call, nil
+		}
+		last = call
+	}
+	if last != nil {
+		return last, nil
+	}
+	return nil, fmt.Errorf("cassette %s has no recorded call to %s with params %s", p.path, method, strings.TrimPrefix(key, method+" "))
+}
+
+// cassetteKey identifies a call by method and redacted params. Maps encode
+// with sorted keys, so equal params give equal keys.
+func cassetteKey(method string, params interface{}) string {
+	data, _ := json.Marshal(params)
+	return method + " " + string(data)
+}
+
+// redactJSON converts v to its generic JSON form and redacts secrets
+func redactJSON(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+	return redact(generic)
+}
+
+// redactRaw redacts secrets in a raw JSON value
+func redactRaw(raw json.RawMessage) json.RawMessage {
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return raw
+	}
+	data, err := json.Marshal(redact(generic))
+	if err != nil {
+		return raw
+	}
+	return data
+}
+
+// openCassette prepares recording or replay on first connect
+func (c *Client) openCassette() error {
+	if c.cassetteFile == "" || c.recorder != nil || c.player != nil {
+		return nil
+	}
+
+	var err error
+	switch c.cassetteMode {
+	case CassetteRecord:
+		c.recorder, err = newCassetteRecorder(c.cassetteFile)
+	case CassetteReplay:
+		c.player, err = loadCassette(c.cassetteFile)
+	default:
+		err = fmt.Errorf("unknown cassette mode %q, expected %q or %q", c.cassetteMode, CassetteRecord, CassetteReplay)
+	}
+	return err
+}
+
+// replay answers a call from the cassette and delivers the notifications
+// recorded after it
+func (c *Client) replay(method string, params interface{}) (json.RawMessage, error) {
+	call, err := c.player.play(method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range call.events {
+		c.dispatchNotification(event.Notification, event.Event)
+	}
+
+	if call.Error != nil {
+		return nil, NewAPIError(call.Error)
+	}
+	return call.Result, nil
+}
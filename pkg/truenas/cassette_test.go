@@ -0,0 +1,174 @@
+package truenas
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCassette(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "session.jsonl")
+	ctx := context.Background()
+
+	// Record a session against a live server
+	polls := 0
+	host := startTestServer(t, func(conn *websocket.Conn, n int) {
+		serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+			switch req.Method {
+			case "pool.query":
+				polls++
+				return []interface{}{map[string]interface{}{"id": 1, "name": "tank", "poll": polls}}, true
+			case "user.create":
+				return map[string]interface{}{"id": 5, "username": "alice", "password": "hunter2"}, true
+			}
+			return nil, true
+		})
+	})
+	recorder := NewClient(&Config{
+		Host:         host,
+		APIKey:       "super-secret-key",
+		CassetteFile: cassette,
+		CassetteMode: CassetteRecord,
+	})
+
+	var pools []map[string]interface{}
+	for i := 0; i < 2; i++ {
+		if err := recorder.Query(ctx, "pool", nil, &pools); err != nil {
+			t.Fatalf("recording Query() error = %v", err)
+		}
+	}
+	var user map[string]interface{}
+	if err := recorder.Create(ctx, "user", map[string]interface{}{"username": "alice", "password": "hunter2"}, &user); err != nil {
+		t.Fatalf("recording Create() error = %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatalf("failed to read cassette: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "super-secret-key", "auth.login"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+
+	// Replay it without a server
+	player := NewClient(&Config{
+		Host:         "127.0.0.1:1",
+		CassetteFile: cassette,
+		CassetteMode: CassetteReplay,
+	})
+	defer player.Close()
+
+	for want := 1.0; want <= 3; want++ {
+		if err := player.Query(ctx, "pool", nil, &pools); err != nil {
+			t.Fatalf("replayed Query() error = %v", err)
+		}
+		// Recorded polls play back in order, then the last one repeats
+		expected := want
+		if expected > 2 {
+			expected = 2
+		}
+		if pools[0]["poll"] != expected {
+			t.Errorf("replayed poll = %v, want %v", pools[0]["poll"], expected)
+		}
+	}
+
+	if err := player.Create(ctx, "user", map[string]interface{}{"username": "alice", "password": "other"}, &user); err != nil {
+		t.Fatalf("replayed Create() error = %v", err)
+	}
+	if user["username"] != "alice" || user["password"] != redactedValue {
+		t.Errorf("replayed user = %v", user)
+	}
+
+	err = player.Delete(ctx, "user", 5)
+	if err == nil || !strings.Contains(err.Error(), "no recorded call to user.delete") {
+		t.Errorf("unrecorded Delete() error = %v, want missing recording", err)
+	}
+}
+
+func TestCassetteReplaysJobEvents(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "job.jsonl")
+	ctx := context.Background()
+
+	host := startTestServer(t, func(conn *websocket.Conn, n int) {
+		serveJob(conn, 7, map[string]interface{}{
+			"id":     7,
+			"state":  "SUCCESS",
+			"result": map[string]interface{}{"id": 3, "name": "tank"},
+		}, nil)
+	})
+	recorder := NewClient(&Config{Host: host, APIKey: "test-key", CassetteFile: cassette, CassetteMode: CassetteRecord})
+	if _, err := recorder.WaitForJob(ctx, 7, 5*time.Second); err != nil {
+		t.Fatalf("recording WaitForJob() error = %v", err)
+	}
+	_ = recorder.Close()
+
+	player := NewClient(&Config{CassetteFile: cassette, CassetteMode: CassetteReplay})
+	defer player.Close()
+
+	result, err := player.WaitForJob(ctx, 7, 5*time.Second)
+	if err != nil {
+		t.Fatalf("replayed WaitForJob() error = %v", err)
+	}
+	if result["name"] != "tank" {
+		t.Errorf("replayed result = %v, want tank", result)
+	}
+}
+
+func TestCassetteAppendsAcrossRecorders(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "apply.jsonl")
+	ctx := context.Background()
+
+	host := startTestServer(t, func(conn *websocket.Conn, n int) {
+		serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+			switch req.Method {
+			case "pool.query":
+				return []interface{}{map[string]interface{}{"id": 1, "name": "tank"}}, true
+			case "pool.dataset.create":
+				return map[string]interface{}{"id": "tank/media"}, true
+			}
+			return nil, true
+		})
+	})
+
+	// Terraform runs plan and apply in separate provider processes, each
+	// with its own client recording to the same cassette
+	plan := NewClient(&Config{Host: host, APIKey: "test-key", CassetteFile: cassette, CassetteMode: CassetteRecord})
+	var pools []map[string]interface{}
+	if err := plan.Query(ctx, "pool", nil, &pools); err != nil {
+		t.Fatalf("recording Query() error = %v", err)
+	}
+	_ = plan.Close()
+
+	apply := NewClient(&Config{Host: host, APIKey: "test-key", CassetteFile: cassette, CassetteMode: CassetteRecord})
+	var dataset map[string]interface{}
+	if err := apply.Create(ctx, "pool.dataset", map[string]interface{}{"name": "tank/media"}, &dataset); err != nil {
+		t.Fatalf("recording Create() error = %v", err)
+	}
+	_ = apply.Close()
+
+	player := NewClient(&Config{CassetteFile: cassette, CassetteMode: CassetteReplay})
+	defer player.Close()
+
+	if err := player.Query(ctx, "pool", nil, &pools); err != nil {
+		t.Fatalf("replayed Query() from first recorder error = %v", err)
+	}
+	if len(pools) != 1 || pools[0]["name"] != "tank" {
+		t.Errorf("replayed pools = %v", pools)
+	}
+	if err := player.Create(ctx, "pool.dataset", map[string]interface{}{"name": "tank/media"}, &dataset); err != nil {
+		t.Fatalf("replayed Create() from second recorder error = %v", err)
+	}
+	if dataset["id"] != "tank/media" {
+		t.Errorf("replayed dataset = %v", dataset)
+	}
+}
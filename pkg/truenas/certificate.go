@@ -0,0 +1,50 @@
+package truenas
+
+// Certificate is a certificate as returned by certificate.query and
+// certificate.get_instance. Type is the middleware's numeric certificate
+// type.
+type Certificate struct {
+	ID                 int64      `json:"id"`
+	Name               string     `json:"name"`
+	Type               *FlexInt64 `json:"type"`
+	CSR                *string    `json:"CSR"`
+	SignedBy           *Reference `json:"signedby"`
+	KeyLength          *FlexInt64 `json:"key_length"`
+	KeyType            *string    `json:"key_type"`
+	DigestAlgorithm    *string    `json:"digest_algorithm"`
+	Lifetime           *FlexInt64 `json:"lifetime"`
+	Country            *string    `json:"country"`
+	State              *string    `json:"state"`
+	City               *string    `json:"city"`
+	Organization       *string    `json:"organization"`
+	OrganizationalUnit *string    `json:"organizational_unit"`
+	Email              *string    `json:"email"`
+	Common             *string    `json:"common"`
+	SAN                []string   `json:"san"`
+	Fingerprint        *string    `json:"fingerprint"`
+	From               *string    `json:"from"`
+	Until              *string    `json:"until"`
+}
+
+// CertificateCreateRequest is the data of certificate.create. CreateType
+// selects how the certificate is made, such as CERTIFICATE_CREATE_IMPORTED
+// or CERTIFICATE_CREATE_INTERNAL.
+type CertificateCreateRequest struct {
+	Name               string   `json:"name"`
+	CreateType         string   `json:"create_type"`
+	Certificate        *string  `json:"certificate,omitempty"`
+	Privatekey         *string  `json:"privatekey,omitempty"`
+	SignedBy           *int64   `json:"signedby,omitempty"`
+	KeyLength          *int64   `json:"key_length,omitempty"`
+	KeyType            *string  `json:"key_type,omitempty"`
+	DigestAlgorithm    *string  `json:"digest_algorithm,omitempty"`
+	Lifetime           *int64   `json:"lifetime,omitempty"`
+	Country            *string  `json:"country,omitempty"`
+	State              *string  `json:"state,omitempty"`
+	City               *string  `json:"city,omitempty"`
+	Organization       *string  `json:"organization,omitempty"`
+	OrganizationalUnit *string  `json:"organizational_unit,omitempty"`
+	Email              *string  `json:"email,omitempty"`
+	CommonName         *string  `json:"common_name,omitempty"`
+	SAN                []string `json:"san,omitempty"`
+}
@@ -0,0 +1,930 @@
+package truenas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultTimeout        = 10 * time.Second
+	defaultPingPeriod     = 30 * time.Second
+	defaultPongTimeout    = 60 * time.Second
+	initialReconnectDelay = 1 * time.Second
+	maxReconnectDelay     = 30 * time.Second
+	maxReconnectAttempts  = 8
+	maxIdempotentRetries  = 2
+)
+
+var (
+	errNotConnected = errors.New("not connected")
+	errClientClosed = errors.New("client is closed")
+)
+
+// Client represents a TrueNAS API client
+type Client struct {
+	apiKey    string
+	verifySSL bool
+
+	// API key sources read on every login, so a rotated key is picked up
+	// on reconnect
+	apiKeyFile    string
+	apiKeyCommand string
+
+	// timeout bounds each request without a context deadline;
+	// connectTimeout bounds dialing and the WebSocket handshake
+	timeout        time.Duration
+	connectTimeout time.Duration
+
+	// Endpoints tried in order on connect; active is the index of the one
+	// in use. configErr reports invalid settings on connect.
+	endpoints []endpoint
+	active    atomic.Int32
+	configErr error
+
+	// Password login, used when no API key is configured
+	username  string
+	password  string
+	otpToken  string
+	otpSecret string
+
+	pingPeriod  time.Duration
+	pongTimeout time.Duration
+
+	conn      *websocket.Conn
+	connMu    sync.Mutex
+	requestID int64
+
+	// Serializes connection setup, including authentication
+	connectMu sync.Mutex
+
+	// Response channels keyed by request ID
+	responses   map[int64]chan *JSONRPCResponse
+	responsesMu sync.Mutex
+
+	// Event subscriptions keyed by event name. subscribeMu serializes
+	// core.subscribe/core.unsubscribe calls; subscriptionsMu guards the map.
+	subscriptions   map[string]*subscriptionGroup
+	subscriptionsMu sync.Mutex
+	subscribeMu     sync.Mutex
+
+	// Context for managing goroutines
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// Connection state
+	connected     bool
+	everConnected bool
+	lostErr       error
+	connectedMu   sync.RWMutex
+
+	// requestSlots limits concurrent requests when MaxConcurrentRequests
+	// is set; locks serializes mutations per parent object
+	requestSlots chan struct{}
+	locks        KeyedMutex
+
+	// Server version, negotiated on every connect
+	version   Version
+	versionMu sync.RWMutex
+
+	// Method names from core.get_methods, fetched on first use and kept
+	// until the server version changes
+	methods   map[string]bool
+	methodsMu sync.Mutex
+
+	// Cassette recording or replay, opened on first connect
+	cassetteFile string
+	cassetteMode CassetteMode
+	recorder     *cassetteRecorder
+	player       *cassettePlayer
+
+	// Trace file of every call, opened on first connect
+	traceFile string
+	tracer    *tracer
+
+	// interceptor is the chain of Config.Interceptors, nil when empty
+	interceptor Interceptor
+}
+
+// Config holds configuration for the TrueNAS client
+type Config struct {
+	Host      string
+	APIKey    string
+	VerifySSL bool
+
+	// Hosts replaces Host with several endpoints of one system, such as
+	// the controllers of an HA pair. They are tried in order; the client
+	// stays on the first that accepts a connection and fails over to the
+	// next when the connection drops.
+	Hosts []string
+
+	// APIKeyFile and APIKeyCommand replace APIKey with the contents of a
+	// file or the output of a shell command, trimmed of surrounding space.
+	// They are read again on every login.
+	APIKeyFile    string
+	APIKeyCommand string
+
+	// Timeout bounds each request whose context has no deadline, and
+	// ConnectTimeout bounds dialing; both default to 10 seconds
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+
+	// Username and Password log in through auth.login_ex when APIKey is
+	// empty. A two-factor challenge is answered with OTPToken, or with a
+	// code generated from the base32 OTPSecret on every login.
+	Username  string
+	Password  string
+	OTPToken  string
+	OTPSecret string
+
+	// CACertFile and CACertPEM replace the system roots with a custom CA
+	// bundle. ClientCert and ClientKey enable mutual TLS and hold either
+	// PEM data or a file path. TLSServerName overrides the name checked
+	// against the server certificate, and TLSFingerprint pins the server
+	// certificate's SHA-256 fingerprint instead of verifying its chain.
+	CACertFile     string
+	CACertPEM      string
+	ClientCert     string
+	ClientKey      string
+	TLSServerName  string
+	TLSFingerprint string
+
+	// Port, Scheme ("wss" or "ws") and APIPath change the WebSocket URL
+	// from wss://<host>/api/current. APIVersion pins a versioned endpoint
+	// such as /api/v25.04.2 instead. ProxyURL routes the connection
+	// through an http, socks5 or socks5h proxy; when empty, HTTPS_PROXY,
+	// ALL_PROXY and NO_PROXY from the environment apply.
+	Port       int
+	Scheme     string
+	APIPath    string
+	APIVersion string
+	ProxyURL   string
+
+	// Transport is TransportTCP (the default) or TransportUnix. The unix
+	// transport talks to the middleware socket at SocketPath, which
+	// defaults to DefaultSocketPath, without TLS. Root is authenticated
+	// by the socket itself, so no API key is needed.
+	Transport  string
+	SocketPath string
+
+	// MaxConcurrentRequests limits how many calls are in flight at once;
+	// zero means no limit. Waiting for a job does not hold a slot.
+	MaxConcurrentRequests int
+
+	// PingPeriod is how often keepalive pings are sent; PongTimeout is how
+	// long the connection may go without a pong (or any message) before it
+	// is considered dead
+	PingPeriod  time.Duration
+	PongTimeout time.Duration
+
+	// CassetteFile records every exchange with the server to a file, or
+	// replays a previous recording without connecting, depending on
+	// CassetteMode. Secrets are redacted from the recording.
+	CassetteFile string
+	CassetteMode CassetteMode
+
+	// TraceFile appends every call and its response to a file as JSON
+	// lines, with secrets redacted
+	TraceFile string
+
+	// Interceptors run around every Call, the first being the outermost
+	Interceptors []Interceptor
+
+	// ReadOnly rejects every call except those known to only read state,
+	// with a *ReadOnlyError. The check runs after the interceptors, so a
+	// rewritten call is checked too.
+	ReadOnly bool
+}
+
+// NewClient creates a new TrueNAS API client
+func NewClient(cfg *Config) *Client {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	connectTimeout := cfg.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = defaultTimeout
+	}
+	pingPeriod := cfg.PingPeriod
+	if pingPeriod == 0 {
+		pingPeriod = defaultPingPeriod
+	}
+	pongTimeout := cfg.PongTimeout
+	if pongTimeout == 0 {
+		pongTimeout = defaultPongTimeout
+	}
+
+	endpoints, configErr := buildEndpoints(cfg)
+	if configErr != nil {
+		// Keep a name for errors; connecting reports configErr
+		host := cfg.Host
+		if host == "" && len(cfg.Hosts) > 0 {
+			host = cfg.Hosts[0]
+		}
+		endpoints = []endpoint{{host: host}}
+	}
+
+	interceptors := cfg.Interceptors
+	if cfg.ReadOnly {
+		interceptors = append(append([]Interceptor(nil), interceptors...), readOnlyGuard)
+	}
+
+	var requestSlots chan struct{}
+	if cfg.MaxConcurrentRequests > 0 {
+		requestSlots = make(chan struct{}, cfg.MaxConcurrentRequests)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Client{
+		apiKey:         cfg.APIKey,
+		apiKeyFile:     cfg.APIKeyFile,
+		apiKeyCommand:  cfg.APIKeyCommand,
+		username:       cfg.Username,
+		password:       cfg.Password,
+		otpToken:       cfg.OTPToken,
+		otpSecret:      cfg.OTPSecret,
+		verifySSL:      cfg.VerifySSL,
+		endpoints:      endpoints,
+		configErr:      configErr,
+		timeout:        timeout,
+		connectTimeout: connectTimeout,
+		requestSlots:   requestSlots,
+		pingPeriod:     pingPeriod,
+		pongTimeout:    pongTimeout,
+		responses:      make(map[int64]chan *JSONRPCResponse),
+		subscriptions:  make(map[string]*subscriptionGroup),
+		ctx:            ctx,
+		cancel:         cancel,
+		cassetteFile:   cfg.CassetteFile,
+		cassetteMode:   cfg.CassetteMode,
+		traceFile:      cfg.TraceFile,
+		interceptor:    chainInterceptors(interceptors),
+	}
+}
+
+// Connect establishes a WebSocket connection and authenticates
+func (c *Client) Connect(ctx context.Context) error {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+	return c.connect(ctx)
+}
+
+// connect establishes the connection. The caller must hold connectMu. The
+// connection is only marked usable once it is authenticated and the
+// version is negotiated, so callers never send on a half-set-up socket.
+func (c *Client) connect(ctx context.Context) error {
+	if c.isConnected() {
+		return nil
+	}
+
+	if err := c.openCassette(); err != nil {
+		return err
+	}
+	if err := c.openTrace(); err != nil {
+		return err
+	}
+	if c.player != nil {
+		// Replayed sessions never touch the network
+		c.negotiate(ctx)
+		c.connectedMu.Lock()
+		c.connected = true
+		c.everConnected = true
+		c.connectedMu.Unlock()
+		return nil
+	}
+
+	if err := c.dial(ctx); err != nil {
+		return err
+	}
+
+	// Authenticate with API key
+	if err := c.authenticate(ctx); err != nil {
+		c.connMu.Lock()
+		c.close()
+		c.connMu.Unlock()
+		return err
+	}
+
+	c.connectedMu.Lock()
+	everConnected := c.everConnected
+	c.everConnected = true
+	c.connectedMu.Unlock()
+
+	// The server may have been upgraded while disconnected
+	c.negotiate(ctx)
+
+	// Subscriptions do not survive the old connection
+	if everConnected {
+		c.resubscribe(ctx)
+	}
+
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.conn == nil {
+		// The connection dropped during setup
+		return c.lastConnectionLost()
+	}
+	c.setConnected(true)
+	return nil
+}
+
+// dial opens the WebSocket connection and starts the response reader. The
+// endpoints are tried in turn, starting with the one in use, or with the
+// next one after a lost connection.
+func (c *Client) dial(ctx context.Context) error {
+	if c.configErr != nil {
+		return fmt.Errorf("invalid connection settings: %w", c.configErr)
+	}
+
+	c.connectedMu.RLock()
+	failover := c.everConnected && len(c.endpoints) > 1
+	c.connectedMu.RUnlock()
+
+	start := int(c.active.Load())
+	if failover {
+		start = (start + 1) % len(c.endpoints)
+	}
+
+	var conn *websocket.Conn
+	var errs []error
+	for i := range c.endpoints {
+		idx := (start + i) % len(c.endpoints)
+		ep := c.endpoints[idx]
+		var err error
+		conn, err = c.dialEndpoint(ctx, ep)
+		if err == nil {
+			c.active.Store(int32(idx))
+			if len(c.endpoints) > 1 {
+				tflog.Info(ctx, "Selected TrueNAS endpoint", map[string]interface{}{
+					"host":     ep.host,
+					"failover": failover || idx != 0,
+				})
+			}
+			break
+		}
+		errs = append(errs, err)
+		if len(c.endpoints) > 1 {
+			tflog.Warn(ctx, "Could not connect to TrueNAS endpoint", map[string]interface{}{
+				"host":  ep.host,
+				"error": err.Error(),
+			})
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if conn == nil {
+		if len(errs) == 1 {
+			return errs[0]
+		}
+		return fmt.Errorf("could not connect to any TrueNAS endpoint: %w", errors.Join(errs...))
+	}
+
+	// Set initial read deadline; pongs and responses push it forward
+	_ = conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+	})
+
+	c.connMu.Lock()
+	c.conn = conn
+	c.connMu.Unlock()
+
+	// Start response reader and keepalive
+	done := make(chan struct{})
+	c.wg.Add(2)
+	go c.readResponses(conn, done)
+	go c.keepalive(conn, done)
+
+	return nil
+}
+
+// dialEndpoint opens a WebSocket connection to ep
+func (c *Client) dialEndpoint(ctx context.Context, ep endpoint) (*websocket.Conn, error) {
+	// Create a net.Dialer with explicit timeouts to ensure TCP connection attempts timeout
+	netDialer := &net.Dialer{
+		Timeout:   c.connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	dialer := websocket.Dialer{
+		TLSClientConfig:  ep.settings.tls,
+		HandshakeTimeout: c.connectTimeout,
+		NetDialContext:   netDialer.DialContext,
+		Proxy:            ep.settings.proxy,
+	}
+	if socketPath := ep.settings.socketPath; socketPath != "" {
+		dialer.NetDialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return netDialer.DialContext(ctx, "unix", socketPath)
+		}
+	}
+
+	// Create a context with timeout for the connection attempt
+	connectCtx, cancel := context.WithTimeout(ctx, c.connectTimeout)
+	defer cancel()
+
+	conn, _, err := dialer.DialContext(connectCtx, ep.settings.url, http.Header{})
+	if err != nil {
+		return nil, NewConnectionError(ep.host, err)
+	}
+	return conn, nil
+}
+
+// reconnect re-establishes a dropped connection, backing off exponentially
+// between attempts. The caller must hold connectMu.
+func (c *Client) reconnect(ctx context.Context) error {
+	delay := initialReconnectDelay
+	var err error
+
+	for attempt := 1; attempt <= maxReconnectAttempts; attempt++ {
+		tflog.Warn(ctx, "Reconnecting to TrueNAS", map[string]interface{}{
+			"host":    c.Host(),
+			"attempt": attempt,
+		})
+
+		if err = c.connect(ctx); err == nil {
+			tflog.Info(ctx, "Reconnected to TrueNAS", map[string]interface{}{
+				"host": c.Host(),
+			})
+			return nil
+		}
+
+		if attempt == maxReconnectAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return NewConnectionLostError(c.Host(), errClientClosed)
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+
+	return fmt.Errorf("giving up after %d reconnect attempts: %w", maxReconnectAttempts, err)
+}
+
+// ensureConnected connects on first use and reconnects after a dropped connection
+func (c *Client) ensureConnected(ctx context.Context) error {
+	if c.ctx.Err() != nil {
+		return NewConnectionLostError(c.Host(), errClientClosed)
+	}
+	if c.isConnected() {
+		return nil
+	}
+
+	// Callers that find the connection down wait here while the first one
+	// connects, then use its connection
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+	if c.isConnected() {
+		return nil
+	}
+
+	c.connectedMu.RLock()
+	everConnected := c.everConnected
+	c.connectedMu.RUnlock()
+
+	if !everConnected {
+		return c.connect(ctx)
+	}
+	return c.reconnect(ctx)
+}
+
+// Call makes a JSON-RPC call and waits for the response. If the connection
+// drops, the client reconnects and idempotent methods are retried. opts
+// adjust the timeout and retry policy of this call, or wait for the job the
+// method starts. The configured interceptors run around the call.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}, opts ...CallOption) error {
+	if c.interceptor == nil {
+		return c.invoke(ctx, method, params, result, opts)
+	}
+	return c.interceptor(ctx, method, params, func(ctx context.Context, method string, params interface{}) error {
+		return c.invoke(ctx, method, params, result, opts)
+	})
+}
+
+// invoke runs a call with its timeout and retries
+func (c *Client) invoke(ctx context.Context, method string, params interface{}, result interface{}, opts []CallOption) error {
+	o := buildCallOptions(method, opts)
+
+	callCtx := ctx
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	err := c.retry(callCtx, method, o.retry, func() error {
+		if o.job {
+			return c.callJob(callCtx, method, params, result)
+		}
+		if err := c.ensureConnected(callCtx); err != nil {
+			return err
+		}
+		return c.callLimited(callCtx, method, params, result)
+	})
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		// Our own timeout expired rather than the caller's context
+		return fmt.Errorf("%s timed out after %v: %w", method, o.timeout, err)
+	}
+	return err
+}
+
+// retry runs attempt until it succeeds or fails for good. Dropped
+// connections and transient middleware errors are retried as policy allows.
+func (c *Client) retry(ctx context.Context, method string, policy *RetryPolicy, attempt func() error) error {
+	for n := 0; ; n++ {
+		err := attempt()
+		if IsMethodNotFoundError(err) {
+			return c.methodNotFound(method, err)
+		}
+		if err == nil || n >= policy.MaxRetries {
+			return err
+		}
+
+		switch {
+		case IsConnectionLostError(err) && !policy.TransientOnly:
+			tflog.Debug(ctx, "Retrying call after connection loss", map[string]interface{}{
+				"method":  method,
+				"attempt": n + 1,
+			})
+		case IsRetryableError(err):
+			delay := policy.backoff(n)
+			tflog.Warn(ctx, "Retrying call after transient TrueNAS error", map[string]interface{}{
+				"method":  method,
+				"attempt": n + 1,
+				"delay":   delay.String(),
+				"error":   err.Error(),
+			})
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return err
+			case <-c.ctx.Done():
+				return err
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// callJob starts a job with method, waits for it and decodes its result
+func (c *Client) callJob(ctx context.Context, method string, params interface{}, result interface{}) error {
+	if err := c.ensureConnected(ctx); err != nil {
+		return err
+	}
+	var jobID int64
+	if err := c.callLimited(ctx, method, params, &jobID); err != nil {
+		return err
+	}
+
+	// A context deadline replaces the default job timeout. The wait runs a
+	// little past it so that the context ends first and aborts the job.
+	timeout := defaultJobTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline) + time.Second
+	}
+	job, err := c.waitJob(ctx, jobID, timeout)
+	if err != nil {
+		return err
+	}
+	if result == nil || job["result"] == nil {
+		return nil
+	}
+	data, err := json.Marshal(job["result"])
+	if err != nil {
+		return fmt.Errorf("failed to encode job %d result: %w", jobID, err)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to unmarshal job %d result: %w", jobID, err)
+	}
+	return nil
+}
+
+// callLimited is call holding a request slot. Connection setup calls call
+// directly, so reconnecting never waits for a slot held by the caller.
+func (c *Client) callLimited(ctx context.Context, method string, params interface{}, result interface{}) error {
+	release, err := c.acquireSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return c.call(ctx, method, params, result)
+}
+
+// call sends a single request on the current connection, waits for the
+// response and decodes its result into result
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	start := time.Now()
+	raw, err := c.roundTrip(ctx, method, params)
+	if c.tracer != nil {
+		c.tracer.trace(ctx, start, method, params, raw, err)
+	}
+	if err != nil {
+		return err
+	}
+	if result != nil && raw != nil {
+		if err := json.Unmarshal(raw, result); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+	return nil
+}
+
+// roundTrip sends a request and returns the raw result of its response
+func (c *Client) roundTrip(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	if c.player != nil {
+		return c.replay(method, params)
+	}
+
+	// Generate request ID
+	id := atomic.AddInt64(&c.requestID, 1)
+
+	// Create response channel
+	respChan := make(chan *JSONRPCResponse, 1)
+	c.responsesMu.Lock()
+	c.responses[id] = respChan
+	c.responsesMu.Unlock()
+
+	defer func() {
+		c.responsesMu.Lock()
+		delete(c.responses, id)
+		c.responsesMu.Unlock()
+	}()
+
+	// Build request
+	req := NewRequest(id, method, params)
+	if c.recorder != nil && !strings.HasPrefix(method, "auth.") {
+		c.recorder.beginCall(id, method, params)
+	}
+
+	// Send request with write deadline
+	c.connMu.Lock()
+	conn := c.conn
+	if conn == nil {
+		c.connMu.Unlock()
+		return nil, NewConnectionLostError(c.Host(), errNotConnected)
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	err := conn.WriteJSON(req)
+	c.connMu.Unlock()
+
+	if err != nil {
+		c.handleDisconnect(conn, err)
+		return nil, NewConnectionLostError(c.Host(), fmt.Errorf("failed to send request: %w", err))
+	}
+
+	// Wait for the response. A deadline on ctx replaces the request timeout.
+	var timeout <-chan time.Time
+	if _, ok := ctx.Deadline(); !ok {
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case resp, ok := <-respChan:
+		if !ok {
+			// The connection dropped before a response arrived
+			return nil, c.lastConnectionLost()
+		}
+		if resp.Error != nil {
+			return nil, NewAPIError(resp.Error)
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		return nil, fmt.Errorf("request timeout after %v", c.timeout)
+	}
+}
+
+// isIdempotent reports whether a method can be safely re-sent after a dropped connection
+func isIdempotent(method string) bool {
+	switch method {
+	case "core.get_jobs":
+		return true
+	}
+	return strings.HasSuffix(method, ".query") || strings.HasSuffix(method, ".get_instance")
+}
+
+// readResponses reads responses from the WebSocket connection
+func (c *Client) readResponses(conn *websocket.Conn, done chan struct{}) {
+	defer func() {
+		close(done)
+		c.wg.Done()
+	}()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		default:
+		}
+
+		var msg jsonrpcMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			// Any read error, including a missed pong deadline, leaves the
+			// connection unusable, so tear it down and let the next call reconnect
+			c.handleDisconnect(conn, err)
+			return
+		}
+
+		// Successfully read a response - refresh deadline for next read
+		_ = conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+
+		// Notifications have no ID and go to event subscribers
+		if msg.Method != "" {
+			if c.recorder != nil {
+				c.recorder.recordNotification(msg.Method, msg.Params)
+			}
+			c.dispatchNotification(msg.Method, msg.Params)
+			continue
+		}
+
+		// Route response to waiting caller
+		resp := msg.JSONRPCResponse
+		if c.recorder != nil {
+			c.recorder.recordResponse(&resp)
+		}
+		// Claim the caller's channel before sending, so a duplicate or late
+		// response for the same ID finds nothing and is dropped rather than
+		// blocking the reader on a full channel
+		c.responsesMu.Lock()
+		ch, ok := c.responses[resp.ID]
+		delete(c.responses, resp.ID)
+		c.responsesMu.Unlock()
+		if ok {
+			ch <- &resp
+		}
+	}
+}
+
+// keepalive pings the server every pingPeriod until the reader for conn exits
+func (c *Client) keepalive(conn *websocket.Conn, done chan struct{}) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			// WriteControl is safe to call concurrently with WriteJSON
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.timeout)); err != nil {
+				c.handleDisconnect(conn, fmt.Errorf("keepalive ping failed: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// handleDisconnect closes conn if it is still current and fails every pending request
+func (c *Client) handleDisconnect(conn *websocket.Conn, err error) {
+	c.connMu.Lock()
+	if c.conn != conn {
+		// Already torn down or replaced by a newer connection
+		c.connMu.Unlock()
+		return
+	}
+	defer c.connMu.Unlock()
+	_ = c.close()
+
+	// Fail pending requests before releasing connMu so a replacement
+	// connection cannot register requests that would be failed here
+	c.failPending(NewConnectionLostError(c.Host(), err))
+}
+
+// failPending wakes every caller waiting for a response with err
+func (c *Client) failPending(err error) {
+	c.connectedMu.Lock()
+	c.lostErr = err
+	c.connectedMu.Unlock()
+
+	c.responsesMu.Lock()
+	defer c.responsesMu.Unlock()
+	for id, ch := range c.responses {
+		close(ch)
+		delete(c.responses, id)
+	}
+}
+
+func (c *Client) lastConnectionLost() error {
+	c.connectedMu.RLock()
+	defer c.connectedMu.RUnlock()
+	if c.lostErr != nil {
+		return c.lostErr
+	}
+	return NewConnectionLostError(c.Host(), errNotConnected)
+}
+
+// Close closes the client connection
+func (c *Client) Close() error {
+	c.cancel()
+	c.connMu.Lock()
+	err := c.close()
+	c.connMu.Unlock()
+	c.failPending(NewConnectionLostError(c.Host(), errClientClosed))
+	c.closeSubscriptions()
+
+	c.connectMu.Lock()
+	if c.recorder != nil {
+		if cerr := c.recorder.close(); err == nil {
+			err = cerr
+		}
+	}
+	if c.tracer != nil {
+		if cerr := c.tracer.close(); err == nil {
+			err = cerr
+		}
+	}
+	c.connectMu.Unlock()
+	return err
+}
+
+func (c *Client) close() error {
+	c.setConnected(false)
+	if c.conn != nil {
+		err := c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (c *Client) isConnected() bool {
+	c.connectedMu.RLock()
+	defer c.connectedMu.RUnlock()
+	return c.connected
+}
+
+func (c *Client) setConnected(connected bool) {
+	c.connectedMu.Lock()
+	defer c.connectedMu.Unlock()
+	c.connected = connected
+}
+
+// Query performs a query operation with optional filtering
+func (c *Client) Query(ctx context.Context, resource string, params *QueryParams, result interface{}) error {
+	method := resource + ".query"
+	var args []interface{}
+	if params != nil {
+		args = params.args()
+	}
+	return c.Call(ctx, method, args, result)
+}
+
+// GetInstance retrieves a single instance by ID
+func (c *Client) GetInstance(ctx context.Context, resource string, id interface{}, result interface{}) error {
+	method := resource + ".get_instance"
+	return c.Call(ctx, method, []interface{}{id}, result)
+}
+
+// Create creates a new resource
+func (c *Client) Create(ctx context.Context, resource string, data interface{}, result interface{}, opts ...CallOption) error {
+	method := resource + ".create"
+	return c.Call(ctx, method, []interface{}{data}, result, opts...)
+}
+
+// Update updates an existing resource
+func (c *Client) Update(ctx context.Context, resource string, id interface{}, data interface{}, result interface{}, opts ...CallOption) error {
+	method := resource + ".update"
+	return c.Call(ctx, method, []interface{}{id, data}, result, opts...)
+}
+
+// Delete deletes a resource
+func (c *Client) Delete(ctx context.Context, resource string, id interface{}, opts ...CallOption) error {
+	method := resource + ".delete"
+	return c.Call(ctx, method, []interface{}{id}, nil, opts...)
+}
+
+// DeleteWithOptions deletes a resource with additional options
+func (c *Client) DeleteWithOptions(ctx context.Context, resource string, id interface{}, options interface{}, opts ...CallOption) error {
+	method := resource + ".delete"
+	return c.Call(ctx, method, []interface{}{id, options}, nil, opts...)
+}
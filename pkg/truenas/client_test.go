@@ -0,0 +1,525 @@
+package truenas
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       int64
+		method   string
+		params   interface{}
+		wantJSON string
+	}{
+		{
+			name:     "simple request without params",
+			id:       1,
+			method:   "pool.query",
+			params:   nil,
+			wantJSON: `{"jsonrpc":"2.0","method":"pool.query","id":1}`,
+		},
+		{
+			name:   "request with array params",
+			id:     2,
+			method: "auth.login_with_api_key",
+			params: []interface{}{"test-api-key"},
+			wantJSON: `{"jsonrpc":"2.0","method":"auth.login_with_api_key","params":["test-api-key"],"id":2}`,
+		},
+		{
+			name:   "request with map params",
+			id:     3,
+			method: "pool.dataset.create",
+			params: map[string]interface{}{
+				"name": "tank/test",
+				"type": "FILESYSTEM",
+			},
+			wantJSON: `{"jsonrpc":"2.0","method":"pool.dataset.create","params":{"name":"tank/test","type":"FILESYSTEM"},"id":3}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := NewRequest(tt.id, tt.method, tt.params)
+
+			if req.JSONRPC != "2.0" {
+				t.Errorf("NewRequest().JSONRPC = %v, want 2.0", req.JSONRPC)
+			}
+			if req.Method != tt.method {
+				t.Errorf("NewRequest().Method = %v, want %v", req.Method, tt.method)
+			}
+			if req.ID != tt.id {
+				t.Errorf("NewRequest().ID = %v, want %v", req.ID, tt.id)
+			}
+
+			// Verify JSON serialization
+			data, err := json.Marshal(req)
+			if err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+
+			// For comparison, unmarshal both and compare
+			var got, want map[string]interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Failed to unmarshal got: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.wantJSON), &want); err != nil {
+				t.Fatalf("Failed to unmarshal want: %v", err)
+			}
+
+			// Compare key fields
+			if got["jsonrpc"] != want["jsonrpc"] {
+				t.Errorf("jsonrpc mismatch: got %v, want %v", got["jsonrpc"], want["jsonrpc"])
+			}
+			if got["method"] != want["method"] {
+				t.Errorf("method mismatch: got %v, want %v", got["method"], want["method"])
+			}
+			if got["id"] != want["id"] {
+				t.Errorf("id mismatch: got %v, want %v", got["id"], want["id"])
+			}
+		})
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	t.Run("empty query params", func(t *testing.T) {
+		params := NewQueryParams()
+		if params.Limit != 0 {
+			t.Errorf("NewQueryParams().Limit = %v, want 0", params.Limit)
+		}
+		if params.Offset != 0 {
+			t.Errorf("NewQueryParams().Offset = %v, want 0", params.Offset)
+		}
+		if len(params.Filters) != 0 {
+			t.Errorf("NewQueryParams().Filters length = %v, want 0", len(params.Filters))
+		}
+	})
+
+	t.Run("with filter", func(t *testing.T) {
+		params := NewQueryParams().WithFilter("name", "=", "test")
+		if len(params.Filters) != 1 {
+			t.Fatalf("Expected 1 filter, got %d", len(params.Filters))
+		}
+		filter := params.Filters[0]
+		if filter[0] != "name" {
+			t.Errorf("Filter field = %v, want name", filter[0])
+		}
+		if filter[1] != "=" {
+			t.Errorf("Filter operator = %v, want =", filter[1])
+		}
+		if filter[2] != "test" {
+			t.Errorf("Filter value = %v, want test", filter[2])
+		}
+	})
+
+	t.Run("with limit and offset", func(t *testing.T) {
+		params := NewQueryParams().WithLimit(10).WithOffset(20)
+		if params.Limit != 10 {
+			t.Errorf("Limit = %v, want 10", params.Limit)
+		}
+		if params.Offset != 20 {
+			t.Errorf("Offset = %v, want 20", params.Offset)
+		}
+	})
+
+	t.Run("with select", func(t *testing.T) {
+		params := NewQueryParams().WithSelect("id", "name", "status")
+		if len(params.Select) != 3 {
+			t.Fatalf("Expected 3 select fields, got %d", len(params.Select))
+		}
+		expected := []string{"id", "name", "status"}
+		for i, field := range expected {
+			if params.Select[i] != field {
+				t.Errorf("Select[%d] = %v, want %v", i, params.Select[i], field)
+			}
+		}
+	})
+
+	t.Run("chained operations", func(t *testing.T) {
+		params := NewQueryParams().
+			WithFilter("pool", "=", "tank").
+			WithFilter("type", "=", "FILESYSTEM").
+			WithLimit(5).
+			WithOffset(0).
+			WithSelect("name", "mountpoint")
+
+		if len(params.Filters) != 2 {
+			t.Errorf("Expected 2 filters, got %d", len(params.Filters))
+		}
+		if params.Limit != 5 {
+			t.Errorf("Limit = %v, want 5", params.Limit)
+		}
+		if len(params.Select) != 2 {
+			t.Errorf("Expected 2 select fields, got %d", len(params.Select))
+		}
+	})
+}
+
+func TestNewClientConfig(t *testing.T) {
+	cfg := &Config{
+		Host:      "truenas.local",
+		APIKey:    "test-key",
+		VerifySSL: true,
+	}
+
+	client := NewClient(cfg)
+
+	if client == nil {
+		t.Fatal("NewClient returned nil")
+	}
+	if client.Host() != cfg.Host {
+		t.Errorf("client.Host() = %v, want %v", client.Host(), cfg.Host)
+	}
+	if client.apiKey != cfg.APIKey {
+		t.Errorf("client.apiKey = %v, want %v", client.apiKey, cfg.APIKey)
+	}
+	if client.verifySSL != cfg.VerifySSL {
+		t.Errorf("client.verifySSL = %v, want %v", client.verifySSL, cfg.VerifySSL)
+	}
+	if client.responses == nil {
+		t.Error("client.responses map is nil")
+	}
+}
+
+// newTestServer starts a TLS WebSocket server that hands each accepted
+// connection, numbered from 1, to handle. It returns a client pointed at it.
+func newTestServer(t *testing.T, handle func(conn *websocket.Conn, n int)) *Client {
+	t.Helper()
+
+	c := NewClient(&Config{
+		Host:   startTestServer(t, handle),
+		APIKey: "test-key",
+	})
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// startTestServer starts a WebSocket server that passes each connection and
+// its 1-based sequence number to handle, and returns its host
+func startTestServer(t *testing.T, handle func(conn *websocket.Conn, n int)) string {
+	t.Helper()
+
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn, int(atomic.AddInt32(&conns, 1)))
+	}))
+	t.Cleanup(srv.Close)
+
+	return strings.TrimPrefix(srv.URL, "https://")
+}
+
+// testVersion is the TrueNAS version serveRequests reports
+const testVersion = "25.04.2"
+
+// serveRequests answers requests with reply until it returns false. Login
+// and version negotiation are answered without calling reply; the method
+// list is reported as unknown.
+func serveRequests(conn *websocket.Conn, reply func(req *JSONRPCRequest) (interface{}, bool)) {
+	for {
+		var req JSONRPCRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		switch req.Method {
+		case "auth.login_with_api_key":
+			_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true})
+			continue
+		case "system.version":
+			_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": testVersion})
+			continue
+		case "core.get_methods":
+			_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": nil})
+			continue
+		}
+		result, ok := reply(&req)
+		if !ok {
+			return
+		}
+		_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}
+}
+
+func TestCallReconnects(t *testing.T) {
+	t.Run("idempotent call is retried after connection loss", func(t *testing.T) {
+		c := newTestServer(t, func(conn *websocket.Conn, n int) {
+			serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+				// Drop the first connection without answering
+				return []interface{}{}, n > 1
+			})
+		})
+
+		var pools []map[string]interface{}
+		if err := c.Call(context.Background(), "pool.query", []interface{}{}, &pools); err != nil {
+			t.Fatalf("Call() error = %v, want nil", err)
+		}
+		if !c.isConnected() {
+			t.Error("client should be connected after reconnect")
+		}
+	})
+
+	t.Run("mutating call fails with connection lost", func(t *testing.T) {
+		c := newTestServer(t, func(conn *websocket.Conn, n int) {
+			serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+				return map[string]interface{}{"id": 1}, n > 1
+			})
+		})
+
+		err := c.Call(context.Background(), "pool.dataset.create", []interface{}{map[string]interface{}{}}, nil)
+		if !IsConnectionLostError(err) {
+			t.Fatalf("Call() error = %v, want ConnectionLostError", err)
+		}
+
+		// The next call reconnects transparently
+		if err := c.Call(context.Background(), "pool.dataset.create", []interface{}{map[string]interface{}{}}, nil); err != nil {
+			t.Fatalf("Call() after reconnect error = %v, want nil", err)
+		}
+	})
+}
+
+func TestConcurrentReconnect(t *testing.T) {
+	var logins int32
+	c := newTestServer(t, func(conn *websocket.Conn, n int) {
+		var writeMu sync.Mutex
+		write := func(msg map[string]interface{}) {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			_ = conn.WriteJSON(msg)
+		}
+
+		var authenticated atomic.Bool
+		for {
+			var req JSONRPCRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+			switch {
+			case req.Method == "auth.login_with_api_key":
+				atomic.AddInt32(&logins, 1)
+				// Answer the login on reconnect late, while requests
+				// sent in the meantime are rejected
+				go func() {
+					if n > 1 {
+						time.Sleep(300 * time.Millisecond)
+					}
+					authenticated.Store(true)
+					resp["result"] = true
+					write(resp)
+				}()
+				continue
+			case !authenticated.Load():
+				resp["error"] = map[string]interface{}{"code": ErrCodeNotAuthenticated, "message": "Not authenticated"}
+			case req.Method == "system.version":
+				resp["result"] = testVersion
+			case req.Method == "test.drop":
+				return
+			default:
+				resp["result"] = []interface{}{}
+			}
+			write(resp)
+		}
+	})
+
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if err := c.Call(context.Background(), "test.drop", nil, nil); !IsConnectionLostError(err) {
+		t.Fatalf("Call(test.drop) error = %v, want ConnectionLostError", err)
+	}
+
+	// The first query reconnects; the rest arrive while it is logging in
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var pools []map[string]interface{}
+			errs <- c.Query(context.Background(), "pool", nil, &pools)
+		}()
+		if i == 0 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Query() during reconnect error = %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&logins); got != 2 {
+		t.Errorf("logins = %d, want 2", got)
+	}
+}
+
+func TestCallWaitsForLogin(t *testing.T) {
+	c := newTestServer(t, func(conn *websocket.Conn, n int) {
+		var writeMu sync.Mutex
+		write := func(msg map[string]interface{}) {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			_ = conn.WriteJSON(msg)
+		}
+
+		var authenticated atomic.Bool
+		for {
+			var req JSONRPCRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+			switch {
+			case req.Method == "auth.login_with_api_key":
+				// Hold the login open so a call can race it
+				go func() {
+					time.Sleep(200 * time.Millisecond)
+					authenticated.Store(true)
+					resp["result"] = true
+					write(resp)
+				}()
+				continue
+			case !authenticated.Load():
+				resp["error"] = map[string]interface{}{"code": ErrCodeNotAuthenticated, "message": "Not authenticated"}
+			case req.Method == "system.version":
+				resp["result"] = testVersion
+			default:
+				resp["result"] = []interface{}{}
+			}
+			write(resp)
+		}
+	})
+
+	connected := make(chan error, 1)
+	go func() { connected <- c.Connect(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// Sent while the login is still outstanding
+	var pools []map[string]interface{}
+	if err := c.Query(context.Background(), "pool", nil, &pools); err != nil {
+		t.Errorf("Query() during login error = %v", err)
+	}
+	if err := <-connected; err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+}
+
+func TestDuplicateResponse(t *testing.T) {
+	c := newTestServer(t, func(conn *websocket.Conn, n int) {
+		serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+			if req.Method == "test.duplicate" {
+				// A misbehaving proxy repeats the response
+				for i := 0; i < 3; i++ {
+					_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "first"})
+				}
+			}
+			return "ok", true
+		})
+	})
+
+	var result string
+	if err := c.Call(context.Background(), "test.duplicate", nil, &result); err != nil {
+		t.Fatalf("Call(test.duplicate) error = %v", err)
+	}
+	if result != "first" {
+		t.Errorf("result = %q, want first", result)
+	}
+
+	// The reader must still route responses to later calls
+	if err := c.Call(context.Background(), "test.after", nil, &result); err != nil {
+		t.Fatalf("Call() after duplicate responses error = %v", err)
+	}
+	if result != "ok" {
+		t.Errorf("result = %q, want ok", result)
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	newKeepaliveClient := func(t *testing.T, handle func(conn *websocket.Conn, n int)) *Client {
+		c := newTestServer(t, handle)
+		c.pingPeriod = 20 * time.Millisecond
+		c.pongTimeout = 100 * time.Millisecond
+		return c
+	}
+
+	t.Run("pongs keep an idle connection alive", func(t *testing.T) {
+		c := newKeepaliveClient(t, func(conn *websocket.Conn, n int) {
+			// Reading lets gorilla answer pings with pongs
+			serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+				return nil, true
+			})
+		})
+		if err := c.Connect(context.Background()); err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+
+		time.Sleep(300 * time.Millisecond)
+		if !c.isConnected() {
+			t.Error("connection dropped despite pongs")
+		}
+	})
+
+	t.Run("missing pongs tear the connection down", func(t *testing.T) {
+		c := newKeepaliveClient(t, func(conn *websocket.Conn, n int) {
+			// Answer login and version negotiation
+			for _, result := range []interface{}{true, testVersion} {
+				var req JSONRPCRequest
+				if err := conn.ReadJSON(&req); err != nil {
+					return
+				}
+				_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+			}
+			// Stop reading so pings go unanswered
+			time.Sleep(time.Second)
+		})
+		if err := c.Connect(context.Background()); err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for c.isConnected() && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if c.isConnected() {
+			t.Error("connection still marked connected after pong timeout")
+		}
+	})
+}
+
+func TestIsIdempotent(t *testing.T) {
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"pool.query", true},
+		{"pool.dataset.get_instance", true},
+		{"core.get_jobs", true},
+		{"pool.dataset.create", false},
+		{"pool.export", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if got := isIdempotent(tt.method); got != tt.want {
+				t.Errorf("isIdempotent(%q) = %v, want %v", tt.method, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package truenas
+
+// Schedule is a cron schedule. Each field takes cron syntax, such as "*",
+// "*/5" or "1-5".
+type Schedule struct {
+	Minute string `json:"minute"`
+	Hour   string `json:"hour"`
+	Dom    string `json:"dom"`
+	Month  string `json:"month"`
+	Dow    string `json:"dow"`
+}
+
+// CronJob is a cron job as returned by cronjob.query and
+// cronjob.get_instance
+type CronJob struct {
+	ID          int64     `json:"id"`
+	User        string    `json:"user"`
+	Command     string    `json:"command"`
+	Description *string   `json:"description"`
+	Enabled     *bool     `json:"enabled"`
+	Stdout      *bool     `json:"stdout"`
+	Stderr      *bool     `json:"stderr"`
+	Schedule    *Schedule `json:"schedule"`
+}
+
+// CronJobRequest is the data of cronjob.create and cronjob.update
+type CronJobRequest struct {
+	User        string   `json:"user"`
+	Command     string   `json:"command"`
+	Description *string  `json:"description,omitempty"`
+	Enabled     bool     `json:"enabled"`
+	Stdout      bool     `json:"stdout"`
+	Stderr      bool     `json:"stderr"`
+	Schedule    Schedule `json:"schedule"`
+}
@@ -0,0 +1,198 @@
+package truenas
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	defaultScheme  = "wss"
+	defaultAPIPath = "/api/current"
+)
+
+// Transports for Config.Transport
+const (
+	TransportTCP  = "tcp"
+	TransportUnix = "unix"
+)
+
+// DefaultSocketPath is the middleware socket on a TrueNAS host
+const DefaultSocketPath = "/var/run/middleware/middlewared.sock"
+
+// dialSettings is everything the dialer needs that is derived from Config
+type dialSettings struct {
+	url   string
+	tls   *tls.Config
+	proxy func(*http.Request) (*url.URL, error)
+
+	// socketPath is set for the unix transport
+	socketPath string
+}
+
+// buildDialSettings validates the connection settings of cfg
+func buildDialSettings(cfg *Config) (*dialSettings, error) {
+	switch cfg.Transport {
+	case "", TransportTCP:
+	case TransportUnix:
+		return buildUnixDialSettings(cfg)
+	default:
+		return nil, fmt.Errorf("invalid transport %q: expected %q or %q", cfg.Transport, TransportTCP, TransportUnix)
+	}
+
+	endpoint, err := buildEndpoint(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := buildProxy(cfg.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	return &dialSettings{url: endpoint, tls: tlsConfig, proxy: proxy}, nil
+}
+
+// ValidateConfig checks the connection settings of cfg without connecting
+func ValidateConfig(cfg *Config) error {
+	_, err := buildEndpoints(cfg)
+	return err
+}
+
+// buildUnixDialSettings returns the settings for talking to the local
+// middleware socket. The socket needs neither TLS nor a proxy, and the host
+// in the URL is only used for the WebSocket handshake.
+func buildUnixDialSettings(cfg *Config) (*dialSettings, error) {
+	switch {
+	case cfg.Port != 0:
+		return nil, fmt.Errorf("port cannot be used with the unix transport")
+	case cfg.Scheme != "" && cfg.Scheme != "ws":
+		return nil, fmt.Errorf("scheme %q cannot be used with the unix transport", cfg.Scheme)
+	case cfg.ProxyURL != "":
+		return nil, fmt.Errorf("proxy URL cannot be used with the unix transport")
+	}
+
+	apiPath, err := resolveAPIPath(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	socketPath := cfg.SocketPath
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+
+	u := url.URL{Scheme: "ws", Host: "localhost", Path: apiPath}
+	return &dialSettings{url: u.String(), socketPath: socketPath}, nil
+}
+
+// buildEndpoint returns the WebSocket URL of the middleware API
+func buildEndpoint(cfg *Config) (string, error) {
+	scheme := cfg.Scheme
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+	if scheme != "wss" && scheme != "ws" {
+		return "", fmt.Errorf("invalid scheme %q: expected \"wss\" or \"ws\"", scheme)
+	}
+
+	apiPath, err := resolveAPIPath(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	host := cfg.Host
+	if cfg.Port != 0 {
+		if cfg.Port < 1 || cfg.Port > 65535 {
+			return "", fmt.Errorf("invalid port %d: must be between 1 and 65535", cfg.Port)
+		}
+		if _, _, err := net.SplitHostPort(host); err == nil {
+			return "", fmt.Errorf("host %q already includes a port; set either the port in host or port, not both", host)
+		}
+		host = net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(cfg.Port))
+	}
+
+	u := url.URL{Scheme: scheme, Host: host, Path: apiPath}
+	return u.String(), nil
+}
+
+// resolveAPIPath returns the path of the JSON-RPC endpoint
+func resolveAPIPath(cfg *Config) (string, error) {
+	if cfg.APIVersion != "" {
+		if cfg.APIPath != "" {
+			return "", fmt.Errorf("set either an API path or an API version, not both")
+		}
+		version := strings.TrimPrefix(cfg.APIVersion, "v")
+		if !versionPattern.MatchString(version) || versionPattern.FindString(version) != version {
+			return "", fmt.Errorf("invalid API version %q: expected a release such as 25.04.2", cfg.APIVersion)
+		}
+		return "/api/v" + version, nil
+	}
+
+	apiPath := cfg.APIPath
+	if apiPath == "" {
+		apiPath = defaultAPIPath
+	}
+	if !strings.HasPrefix(apiPath, "/") {
+		return "", fmt.Errorf("invalid API path %q: must start with \"/\"", apiPath)
+	}
+	return apiPath, nil
+}
+
+// buildProxy returns the dialer's proxy function. An explicit proxy URL
+// is always used; otherwise HTTPS_PROXY, HTTP_PROXY and ALL_PROXY are read
+// from the environment, honouring NO_PROXY.
+func buildProxy(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return proxyFromEnvironment, nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, socks5 or socks5h", proxyURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxyURL)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// proxyFromEnvironment is like http.ProxyFromEnvironment, but also falls
+// back to ALL_PROXY, which is how SOCKS5 proxies are usually configured.
+// The environment is read on every connect.
+func proxyFromEnvironment(req *http.Request) (*url.URL, error) {
+	cfg := httpproxy.FromEnvironment()
+	if all := getenvAny("ALL_PROXY", "all_proxy"); all != "" {
+		if cfg.HTTPSProxy == "" {
+			cfg.HTTPSProxy = all
+		}
+		if cfg.HTTPProxy == "" {
+			cfg.HTTPProxy = all
+		}
+	}
+	return cfg.ProxyFunc()(req.URL)
+}
+
+// getenvAny returns the first non-empty environment variable of names
+func getenvAny(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
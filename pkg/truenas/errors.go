@@ -0,0 +1,413 @@
+package truenas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxTracebackLines limits how much of a job traceback is shown to users
+const maxTracebackLines = 20
+
+// Error codes from TrueNAS API
+const (
+	ErrCodeParseError     = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternalError  = -32603
+
+	// TrueNAS specific error codes
+	ErrCodeNotAuthenticated = 1
+	ErrCodeNotAuthorized    = 2
+	ErrCodeNotFound         = 3
+	ErrCodeValidation       = 4
+)
+
+// APIError represents an error from the TrueNAS API
+type APIError struct {
+	Code    int
+	Message string
+	Details string
+
+	// Errname is the errno name reported by the middleware, such as EINVAL
+	Errname string
+	// Validation holds per-attribute errors from a ValidationErrors failure
+	Validation ValidationErrors
+}
+
+func (e *APIError) Error() string {
+	if len(e.Validation) > 0 {
+		return fmt.Sprintf("TrueNAS API error %d: %s", e.Code, e.Validation.Error())
+	}
+	if e.Details != "" {
+		return fmt.Sprintf("TrueNAS API error %d: %s (%s)", e.Code, e.Message, e.Details)
+	}
+	return fmt.Sprintf("TrueNAS API error %d: %s", e.Code, e.Message)
+}
+
+// IsNotFound returns true if the error indicates a resource was not found
+func (e *APIError) IsNotFound() bool {
+	if e.Code == ErrCodeNotFound {
+		return true
+	}
+	// TrueNAS Scale 25 returns InvalidParams with InstanceNotFound in details
+	if e.Code == ErrCodeInvalidParams {
+		if strings.Contains(e.Details, "InstanceNotFound") || strings.Contains(e.Details, "does not exist") {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAuthError returns true if the error is an authentication error
+func (e *APIError) IsAuthError() bool {
+	return e.Code == ErrCodeNotAuthenticated || e.Code == ErrCodeNotAuthorized
+}
+
+// IsValidationError returns true if the error is a validation error
+func (e *APIError) IsValidationError() bool {
+	return e.Code == ErrCodeValidation || len(e.Validation) > 0
+}
+
+// ValidationError is a single middleware validation failure. Attribute is
+// the dotted schema path, e.g. "pool_dataset_create.quota".
+type ValidationError struct {
+	Attribute string
+	Message   string
+	Errno     int
+}
+
+// Field returns the attribute path with the method schema prefix removed,
+// e.g. ["quota"] for "pool_dataset_create.quota"
+func (v ValidationError) Field() []string {
+	parts := strings.Split(v.Attribute, ".")
+	if len(parts) < 2 {
+		return nil
+	}
+	return parts[1:]
+}
+
+// ValidationErrors is the list of failures from a ValidationErrors exception
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, e := range v {
+		if e.Attribute != "" {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", e.Attribute, e.Message))
+		} else {
+			msgs = append(msgs, e.Message)
+		}
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// parseValidationErrors decodes the [attribute, message, errno] triples the
+// middleware places in the "extra" field of validation failures
+func parseValidationErrors(extra json.RawMessage) ValidationErrors {
+	if len(extra) == 0 {
+		return nil
+	}
+
+	var triples [][]interface{}
+	if err := json.Unmarshal(extra, &triples); err != nil {
+		return nil
+	}
+	return validationErrorsFromTriples(triples)
+}
+
+func validationErrorsFromTriples(triples [][]interface{}) ValidationErrors {
+	var errs ValidationErrors
+	for _, triple := range triples {
+		if len(triple) < 2 {
+			continue
+		}
+		attr, _ := triple[0].(string)
+		msg, ok := triple[1].(string)
+		if !ok {
+			continue
+		}
+		v := ValidationError{Attribute: attr, Message: msg}
+		if len(triple) > 2 {
+			if errno, ok := triple[2].(float64); ok {
+				v.Errno = int(errno)
+			}
+		}
+		errs = append(errs, v)
+	}
+	return errs
+}
+
+// ConnectionError represents a connection-related error
+type ConnectionError struct {
+	Host string
+	Err  error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf(`failed to connect to TrueNAS at %q: %v
+
+Please verify:
+  1. The host is reachable (try: curl -k https://%s/api/current)
+  2. TrueNAS Scale 25.04+ is running and the API is enabled
+  3. Your provider configuration is correct
+
+Example configuration:
+
+  provider "trueform" {
+    host       = "192.168.1.100"    # TrueNAS IP or hostname
+    api_key    = "1-xxxx..."        # API key from TrueNAS UI
+    verify_ssl = false              # Set true if using valid SSL cert
+  }
+
+Or use environment variables:
+  export TRUENAS_HOST="192.168.1.100"
+  export TRUENAS_API_KEY="1-xxxx..."
+  export TRUENAS_VERIFY_SSL="false"
+`, e.Host, e.Err, e.Host)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectionLostError indicates the connection to TrueNAS dropped before a
+// request completed
+type ConnectionLostError struct {
+	Host string
+	Err  error
+}
+
+func (e *ConnectionLostError) Error() string {
+	return fmt.Sprintf("connection to TrueNAS at %q lost: %v", e.Host, e.Err)
+}
+
+func (e *ConnectionLostError) Unwrap() error {
+	return e.Err
+}
+
+// JobError represents a TrueNAS job that ended in the FAILED state
+type JobError struct {
+	JobID      int64
+	Method     string
+	Arguments  interface{}
+	Message    string
+	ErrorClass string
+	Traceback  string
+	Logs       string
+	LogsPath   string
+
+	// Validation holds per-attribute errors when the job failed validation
+	Validation ValidationErrors
+}
+
+func (e *JobError) Error() string {
+	if e.Method != "" {
+		return fmt.Sprintf("job %d (%s) failed: %s", e.JobID, e.Method, e.Message)
+	}
+	return fmt.Sprintf("job %d failed: %s", e.JobID, e.Message)
+}
+
+// Detail renders the job failure as a multi-line report for diagnostics
+func (e *JobError) Detail() string {
+	var b strings.Builder
+	b.WriteString(e.Error())
+
+	if e.ErrorClass != "" {
+		fmt.Fprintf(&b, "\n\nError class: %s", e.ErrorClass)
+	}
+	if e.Arguments != nil {
+		if args, err := json.Marshal(e.Arguments); err == nil {
+			fmt.Fprintf(&b, "\nArguments: %s", args)
+		}
+	}
+	if e.Traceback != "" {
+		fmt.Fprintf(&b, "\n\nTraceback (excerpt):\n%s", e.Traceback)
+	}
+	if e.Logs != "" {
+		fmt.Fprintf(&b, "\n\nJob log (excerpt):\n%s", strings.TrimRight(e.Logs, "\n"))
+	}
+	if e.LogsPath != "" {
+		fmt.Fprintf(&b, "\n\nFull job log on the TrueNAS host: %s", e.LogsPath)
+	}
+	return b.String()
+}
+
+// NewJobError creates a JobError from a job returned by core.get_jobs.
+// Secret arguments are redacted and the traceback is trimmed to its tail.
+func NewJobError(jobID int64, job map[string]interface{}) *JobError {
+	e := &JobError{
+		JobID:   jobID,
+		Message: "job failed",
+	}
+
+	e.Method, _ = job["method"].(string)
+	if msg, ok := job["error"].(string); ok && msg != "" {
+		e.Message = msg
+	}
+	if args, ok := job["arguments"]; ok && args != nil {
+		e.Arguments = redact(args)
+	}
+	if excInfo, ok := job["exc_info"].(map[string]interface{}); ok {
+		e.ErrorClass, _ = excInfo["type"].(string)
+		if extra, ok := excInfo["extra"].([]interface{}); ok {
+			var triples [][]interface{}
+			for _, item := range extra {
+				if triple, ok := item.([]interface{}); ok {
+					triples = append(triples, triple)
+				}
+			}
+			e.Validation = validationErrorsFromTriples(triples)
+		}
+	}
+	if traceback, ok := job["exception"].(string); ok {
+		e.Traceback = tailLines(strings.TrimRight(traceback, "\n"), maxTracebackLines)
+	}
+	e.Logs, _ = job["logs_excerpt"].(string)
+	e.LogsPath, _ = job["logs_path"].(string)
+
+	return e
+}
+
+// tailLines returns the last n lines of s
+func tailLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// errorData is the structured "data" member of middleware JSON-RPC errors
+type errorData struct {
+	Errname string          `json:"errname"`
+	Extra   json.RawMessage `json:"extra"`
+}
+
+// NewAPIError creates a new APIError from a JSONRPCError
+func NewAPIError(rpcErr *JSONRPCError) *APIError {
+	apiErr := &APIError{
+		Code:    rpcErr.Code,
+		Message: rpcErr.Message,
+	}
+	if rpcErr.Data != nil {
+		apiErr.Details = string(rpcErr.Data)
+
+		var data errorData
+		if err := json.Unmarshal(rpcErr.Data, &data); err == nil {
+			apiErr.Errname = data.Errname
+			apiErr.Validation = parseValidationErrors(data.Extra)
+		}
+	}
+	return apiErr
+}
+
+// NewConnectionError creates a new ConnectionError
+func NewConnectionError(host string, err error) *ConnectionError {
+	return &ConnectionError{
+		Host: host,
+		Err:  err,
+	}
+}
+
+// NewConnectionLostError creates a new ConnectionLostError
+func NewConnectionLostError(host string, err error) *ConnectionLostError {
+	return &ConnectionLostError{
+		Host: host,
+		Err:  err,
+	}
+}
+
+// IsNotFoundError checks if an error is a not found error
+func IsNotFoundError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsNotFound()
+}
+
+// IsAuthError checks if an error is an authentication error
+func IsAuthError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsAuthError()
+}
+
+// retryableErrnames are errno names of transient middleware failures
+var retryableErrnames = map[string]bool{
+	"EBUSY":  true,
+	"EAGAIN": true,
+}
+
+// retryableMessages are lower-case fragments of transient middleware
+// failures that carry no errno name, such as lock contention between jobs
+var retryableMessages = []string{
+	"[ebusy]",
+	"[eagain]",
+	"pool is busy",
+	"device or resource busy",
+	"resource temporarily unavailable",
+	"job already running",
+	"is already running",
+}
+
+// IsRetryableError checks if an error is a transient middleware failure,
+// such as a busy pool or a locked job, that is likely to succeed when the
+// call is repeated shortly after
+func IsRetryableError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if retryableErrnames[apiErr.Errname] {
+			return true
+		}
+		return hasRetryableMessage(apiErr.Message + " " + apiErr.Details)
+	}
+	var jobErr *JobError
+	if errors.As(err, &jobErr) {
+		return hasRetryableMessage(jobErr.Message)
+	}
+	return false
+}
+
+// hasRetryableMessage reports whether msg describes a transient failure
+func hasRetryableMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, fragment := range retryableMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMethodNotFoundError checks if an error is a call to a method the
+// server does not offer
+func IsMethodNotFoundError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == ErrCodeMethodNotFound
+}
+
+// IsConnectionLostError checks if an error was caused by a dropped connection
+func IsConnectionLostError(err error) bool {
+	var lostErr *ConnectionLostError
+	return errors.As(err, &lostErr)
+}
+
+// IsJobError checks if an error is a failed TrueNAS job
+func IsJobError(err error) bool {
+	var jobErr *JobError
+	return errors.As(err, &jobErr)
+}
+
+// AsValidationErrors extracts per-attribute validation errors from an API
+// error or a failed job
+func AsValidationErrors(err error) (ValidationErrors, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && len(apiErr.Validation) > 0 {
+		return apiErr.Validation, true
+	}
+	var jobErr *JobError
+	if errors.As(err, &jobErr) && len(jobErr.Validation) > 0 {
+		return jobErr.Validation, true
+	}
+	return nil, false
+}
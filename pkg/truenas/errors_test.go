@@ -0,0 +1,438 @@
+package truenas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         *APIError
+		wantMsg     string
+		isNotFound  bool
+		isAuth      bool
+		isValidation bool
+	}{
+		{
+			name: "not found error",
+			err: &APIError{
+				Code:    ErrCodeNotFound,
+				Message: "Resource not found",
+			},
+			wantMsg:    "TrueNAS API error 3: Resource not found",
+			isNotFound: true,
+			isAuth:     false,
+			isValidation: false,
+		},
+		{
+			name: "authentication error",
+			err: &APIError{
+				Code:    ErrCodeNotAuthenticated,
+				Message: "Not authenticated",
+			},
+			wantMsg:    "TrueNAS API error 1: Not authenticated",
+			isNotFound: false,
+			isAuth:     true,
+			isValidation: false,
+		},
+		{
+			name: "authorization error",
+			err: &APIError{
+				Code:    ErrCodeNotAuthorized,
+				Message: "Not authorized",
+			},
+			wantMsg:    "TrueNAS API error 2: Not authorized",
+			isNotFound: false,
+			isAuth:     true,
+			isValidation: false,
+		},
+		{
+			name: "validation error",
+			err: &APIError{
+				Code:    ErrCodeValidation,
+				Message: "Invalid input",
+			},
+			wantMsg:    "TrueNAS API error 4: Invalid input",
+			isNotFound: false,
+			isAuth:     false,
+			isValidation: true,
+		},
+		{
+			name: "error with details",
+			err: &APIError{
+				Code:    ErrCodeInternalError,
+				Message: "Internal error",
+				Details: "Stack trace here",
+			},
+			wantMsg:    "TrueNAS API error -32603: Internal error (Stack trace here)",
+			isNotFound: false,
+			isAuth:     false,
+			isValidation: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantMsg {
+				t.Errorf("APIError.Error() = %v, want %v", got, tt.wantMsg)
+			}
+			if got := tt.err.IsNotFound(); got != tt.isNotFound {
+				t.Errorf("APIError.IsNotFound() = %v, want %v", got, tt.isNotFound)
+			}
+			if got := tt.err.IsAuthError(); got != tt.isAuth {
+				t.Errorf("APIError.IsAuthError() = %v, want %v", got, tt.isAuth)
+			}
+			if got := tt.err.IsValidationError(); got != tt.isValidation {
+				t.Errorf("APIError.IsValidationError() = %v, want %v", got, tt.isValidation)
+			}
+		})
+	}
+}
+
+func TestConnectionError(t *testing.T) {
+	t.Run("with wrapped error", func(t *testing.T) {
+		innerErr := errors.New("connection refused")
+		err := NewConnectionError("192.168.1.100", innerErr)
+
+		if err.Host != "192.168.1.100" {
+			t.Errorf("ConnectionError.Host = %v, want '192.168.1.100'", err.Host)
+		}
+		if err.Err != innerErr {
+			t.Errorf("ConnectionError.Err = %v, want %v", err.Err, innerErr)
+		}
+		if !errors.Is(err, innerErr) {
+			t.Error("errors.Is failed to match wrapped error")
+		}
+
+		// Check that error message contains key information
+		errMsg := err.Error()
+		if !contains(errMsg, "192.168.1.100") {
+			t.Errorf("ConnectionError.Error() should contain host")
+		}
+		if !contains(errMsg, "connection refused") {
+			t.Errorf("ConnectionError.Error() should contain underlying error")
+		}
+		if !contains(errMsg, "provider \"trueform\"") {
+			t.Errorf("ConnectionError.Error() should contain example configuration")
+		}
+	})
+
+	t.Run("without wrapped error", func(t *testing.T) {
+		err := NewConnectionError("truenas.local", nil)
+
+		if err.Host != "truenas.local" {
+			t.Errorf("ConnectionError.Host = %v, want 'truenas.local'", err.Host)
+		}
+		if err.Err != nil {
+			t.Errorf("ConnectionError.Err = %v, want nil", err.Err)
+		}
+
+		errMsg := err.Error()
+		if !contains(errMsg, "truenas.local") {
+			t.Errorf("ConnectionError.Error() should contain host")
+		}
+	})
+}
+
+func contains(s, substr string) bool {
+	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
+}
+
+func containsHelper(s, substr string) bool {
+	for i := 0; i <= len(s)-len(substr); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNewAPIError(t *testing.T) {
+	t.Run("from JSONRPCError without data", func(t *testing.T) {
+		rpcErr := &JSONRPCError{
+			Code:    -32600,
+			Message: "Invalid Request",
+		}
+
+		apiErr := NewAPIError(rpcErr)
+
+		if apiErr.Code != -32600 {
+			t.Errorf("APIError.Code = %v, want -32600", apiErr.Code)
+		}
+		if apiErr.Message != "Invalid Request" {
+			t.Errorf("APIError.Message = %v, want 'Invalid Request'", apiErr.Message)
+		}
+		if apiErr.Details != "" {
+			t.Errorf("APIError.Details = %v, want empty string", apiErr.Details)
+		}
+	})
+
+	t.Run("from JSONRPCError with data", func(t *testing.T) {
+		data, _ := json.Marshal("extra info")
+		rpcErr := &JSONRPCError{
+			Code:    -32602,
+			Message: "Invalid params",
+			Data:    data,
+		}
+
+		apiErr := NewAPIError(rpcErr)
+
+		if apiErr.Code != -32602 {
+			t.Errorf("APIError.Code = %v, want -32602", apiErr.Code)
+		}
+		if apiErr.Details != `"extra info"` {
+			t.Errorf("APIError.Details = %v, want '\"extra info\"'", apiErr.Details)
+		}
+	})
+}
+
+func TestIsNotFoundError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "API not found error",
+			err:  &APIError{Code: ErrCodeNotFound, Message: "not found"},
+			want: true,
+		},
+		{
+			name: "wrapped instance not found",
+			err:  fmt.Errorf("read dataset: %w", &APIError{Code: ErrCodeInvalidParams, Details: "[ENOENT] None: InstanceNotFound: pool.dataset tank/x does not exist"}),
+			want: true,
+		},
+		{
+			name: "API other error",
+			err:  &APIError{Code: ErrCodeInternalError, Message: "internal"},
+			want: false,
+		},
+		{
+			name: "standard error",
+			err:  errors.New("some error"),
+			want: false,
+		},
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNotFoundError(tt.err); got != tt.want {
+				t.Errorf("IsNotFoundError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "not authenticated error",
+			err:  &APIError{Code: ErrCodeNotAuthenticated, Message: "not authenticated"},
+			want: true,
+		},
+		{
+			name: "not authorized error",
+			err:  &APIError{Code: ErrCodeNotAuthorized, Message: "not authorized"},
+			want: true,
+		},
+		{
+			name: "wrapped not authenticated error",
+			err:  fmt.Errorf("query pool: %w", &APIError{Code: ErrCodeNotAuthenticated, Message: "not authenticated"}),
+			want: true,
+		},
+		{
+			name: "other API error",
+			err:  &APIError{Code: ErrCodeNotFound, Message: "not found"},
+			want: false,
+		},
+		{
+			name: "standard error",
+			err:  errors.New("some error"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAuthError(tt.err); got != tt.want {
+				t.Errorf("IsAuthError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsConnectionLostError(t *testing.T) {
+	lost := NewConnectionLostError("truenas.local", errors.New("EOF"))
+
+	if !IsConnectionLostError(lost) {
+		t.Error("IsConnectionLostError() = false, want true")
+	}
+	if !IsConnectionLostError(fmt.Errorf("wrapped: %w", lost)) {
+		t.Error("IsConnectionLostError() should match wrapped errors")
+	}
+	if IsConnectionLostError(errors.New("some error")) {
+		t.Error("IsConnectionLostError() = true for unrelated error")
+	}
+	if !contains(lost.Error(), "truenas.local") {
+		t.Errorf("ConnectionLostError.Error() should contain host")
+	}
+}
+
+func TestNewJobError(t *testing.T) {
+	job := map[string]interface{}{
+		"id":     float64(12),
+		"method": "pool.create",
+		"arguments": []interface{}{map[string]interface{}{
+			"name": "tank",
+			"encryption_options": map[string]interface{}{
+				"passphrase": "hunter2",
+			},
+		}},
+		"state":        "FAILED",
+		"error":        "[EFAULT] Disk sdb is in use",
+		"exception":    "Traceback (most recent call last):\n  File \"pool.py\", line 1\nCallError: [EFAULT] Disk sdb is in use\n",
+		"exc_info":     map[string]interface{}{"type": "CallError", "errno": float64(14)},
+		"logs_excerpt": "wiping sdb\nfailed\n",
+		"logs_path":    "/var/log/jobs/12.log",
+	}
+
+	err := NewJobError(12, job)
+
+	if err.Method != "pool.create" {
+		t.Errorf("JobError.Method = %v, want pool.create", err.Method)
+	}
+	if err.ErrorClass != "CallError" {
+		t.Errorf("JobError.ErrorClass = %v, want CallError", err.ErrorClass)
+	}
+	if got := err.Error(); got != "job 12 (pool.create) failed: [EFAULT] Disk sdb is in use" {
+		t.Errorf("JobError.Error() = %v", got)
+	}
+
+	detail := err.Detail()
+	for _, want := range []string{"Error class: CallError", "Traceback (excerpt)", "wiping sdb", "/var/log/jobs/12.log", redactedValue} {
+		if !contains(detail, want) {
+			t.Errorf("JobError.Detail() missing %q:\n%s", want, detail)
+		}
+	}
+	if contains(detail, "hunter2") {
+		t.Errorf("JobError.Detail() leaked a secret argument:\n%s", detail)
+	}
+
+	if !IsJobError(fmt.Errorf("wrapped: %w", err)) {
+		t.Error("IsJobError() should match wrapped errors")
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	if got := tailLines("a\nb\nc", 2); got != "b\nc" {
+		t.Errorf("tailLines() = %q, want %q", got, "b\nc")
+	}
+	if got := tailLines("a\nb", 5); got != "a\nb" {
+		t.Errorf("tailLines() = %q, want %q", got, "a\nb")
+	}
+}
+
+func TestNewAPIErrorValidation(t *testing.T) {
+	data := []byte(`{
+		"error": 22,
+		"errname": "EINVAL",
+		"reason": "[EINVAL] pool_dataset_create.quota: Must be greater than 1 GiB",
+		"extra": [
+			["pool_dataset_create.quota", "Must be greater than 1 GiB", 22],
+			["pool_dataset_create.encryption_options.passphrase", "Too short", 22]
+		]
+	}`)
+
+	apiErr := NewAPIError(&JSONRPCError{Code: ErrCodeInvalidParams, Message: "Invalid params", Data: data})
+
+	if apiErr.Errname != "EINVAL" {
+		t.Errorf("APIError.Errname = %v, want EINVAL", apiErr.Errname)
+	}
+	if !apiErr.IsValidationError() {
+		t.Error("APIError.IsValidationError() = false, want true")
+	}
+
+	verrs, ok := AsValidationErrors(fmt.Errorf("wrapped: %w", apiErr))
+	if !ok || len(verrs) != 2 {
+		t.Fatalf("AsValidationErrors() = %v, %v, want 2 errors", verrs, ok)
+	}
+	if verrs[0].Attribute != "pool_dataset_create.quota" || verrs[0].Errno != 22 {
+		t.Errorf("first validation error = %+v", verrs[0])
+	}
+	if got := verrs[0].Field(); len(got) != 1 || got[0] != "quota" {
+		t.Errorf("Field() = %v, want [quota]", got)
+	}
+	if got := verrs[1].Field(); len(got) != 2 || got[0] != "encryption_options" {
+		t.Errorf("Field() = %v, want [encryption_options passphrase]", got)
+	}
+	if !contains(apiErr.Error(), "pool_dataset_create.quota: Must be greater than 1 GiB") {
+		t.Errorf("APIError.Error() = %v, want attribute messages", apiErr.Error())
+	}
+}
+
+func TestAsValidationErrors(t *testing.T) {
+	t.Run("failed job", func(t *testing.T) {
+		jobErr := NewJobError(5, map[string]interface{}{
+			"method": "pool.create",
+			"error":  "[EINVAL] pool_create.name: Invalid pool name",
+			"exc_info": map[string]interface{}{
+				"type":  "VALIDATION",
+				"extra": []interface{}{[]interface{}{"pool_create.name", "Invalid pool name", float64(22)}},
+			},
+		})
+
+		verrs, ok := AsValidationErrors(jobErr)
+		if !ok || len(verrs) != 1 || verrs[0].Message != "Invalid pool name" {
+			t.Errorf("AsValidationErrors() = %v, %v", verrs, ok)
+		}
+	})
+
+	t.Run("non-validation errors", func(t *testing.T) {
+		if _, ok := AsValidationErrors(&APIError{Code: ErrCodeInternalError, Details: `{"extra": null}`}); ok {
+			t.Error("AsValidationErrors() matched an internal error")
+		}
+		if _, ok := AsValidationErrors(errors.New("some error")); ok {
+			t.Error("AsValidationErrors() matched a plain error")
+		}
+	})
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "EBUSY errname", err: &APIError{Code: -32001, Message: "Device busy", Errname: "EBUSY"}, want: true},
+		{name: "EAGAIN errname", err: &APIError{Code: -32001, Message: "Try again", Errname: "EAGAIN"}, want: true},
+		{name: "busy details", err: &APIError{Code: -32001, Message: "Method call error", Details: "[EBUSY] dataset is in use"}, want: true},
+		{name: "pool is busy", err: &APIError{Code: -32001, Message: "[EFAULT] pool is busy, try again later"}, want: true},
+		{name: "locked job", err: &APIError{Code: -32001, Message: "Job already running"}, want: true},
+		{name: "wrapped", err: fmt.Errorf("create dataset: %w", &APIError{Errname: "EBUSY"}), want: true},
+		{name: "busy job", err: &JobError{JobID: 1, Message: "[EBUSY] cannot export pool"}, want: true},
+		{name: "validation", err: &APIError{Code: ErrCodeValidation, Message: "Invalid quota", Errname: "EINVAL"}, want: false},
+		{name: "failed job", err: &JobError{JobID: 1, Message: "[EFAULT] disk not found"}, want: false},
+		{name: "connection lost", err: NewConnectionLostError("nas", errors.New("EOF")), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package truenas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Event message types sent in collection_update notifications
+const (
+	EventAdded   = "added"
+	EventChanged = "changed"
+	EventRemoved = "removed"
+)
+
+// eventBufferSize is how many undelivered events a subscription holds before
+// further events are dropped
+const eventBufferSize = 64
+
+// Event represents a collection_update notification for a subscribed event
+type Event struct {
+	Msg        string                 `json:"msg"`
+	Collection string                 `json:"collection"`
+	ID         interface{}            `json:"id,omitempty"`
+	Fields     map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Subscription delivers events for a single event name, such as
+// "core.get_jobs" or "pool.query"
+type Subscription struct {
+	name   string
+	client *Client
+	events chan Event
+	done   chan struct{}
+
+	closeOnce sync.Once
+	unsubOnce sync.Once
+}
+
+// subscriptionGroup shares one server-side subscription between every local
+// subscriber to the same event name
+type subscriptionGroup struct {
+	id   string
+	subs map[*Subscription]struct{}
+}
+
+// Name returns the event name the subscription is for
+func (s *Subscription) Name() string {
+	return s.name
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription ends. Events are dropped if the consumer falls more than
+// eventBufferSize events behind, and none are delivered while the client is
+// reconnecting, so consumers that need exact state should re-query.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Unsubscribe stops event delivery and closes the events channel
+func (s *Subscription) Unsubscribe(ctx context.Context) error {
+	var err error
+	s.unsubOnce.Do(func() {
+		err = s.client.unsubscribe(ctx, s)
+	})
+	return err
+}
+
+func (s *Subscription) closeEvents() {
+	s.closeOnce.Do(func() {
+		close(s.events)
+		close(s.done)
+	})
+}
+
+// Subscribe subscribes to an event name via core.subscribe. The subscription
+// ends when ctx is cancelled or Unsubscribe is called.
+func (c *Client) Subscribe(ctx context.Context, name string) (*Subscription, error) {
+	if err := c.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+
+	c.subscribeMu.Lock()
+	defer c.subscribeMu.Unlock()
+
+	sub := &Subscription{
+		name:   name,
+		client: c,
+		events: make(chan Event, eventBufferSize),
+		done:   make(chan struct{}),
+	}
+
+	// Register before subscribing so events sent right after the
+	// core.subscribe response are not missed
+	c.subscriptionsMu.Lock()
+	group, ok := c.subscriptions[name]
+	if !ok {
+		group = &subscriptionGroup{subs: make(map[*Subscription]struct{})}
+		c.subscriptions[name] = group
+	}
+	group.subs[sub] = struct{}{}
+	c.subscriptionsMu.Unlock()
+
+	if !ok {
+		var id string
+		if err := c.call(ctx, "core.subscribe", []interface{}{name}, &id); err != nil {
+			c.subscriptionsMu.Lock()
+			delete(c.subscriptions, name)
+			c.subscriptionsMu.Unlock()
+			sub.closeEvents()
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", name, err)
+		}
+
+		c.subscriptionsMu.Lock()
+		group.id = id
+		c.subscriptionsMu.Unlock()
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = sub.Unsubscribe(context.Background())
+		case <-sub.done:
+		}
+	}()
+
+	return sub, nil
+}
+
+// unsubscribe removes sub and drops the server-side subscription once no
+// local subscribers remain
+func (c *Client) unsubscribe(ctx context.Context, sub *Subscription) error {
+	c.subscribeMu.Lock()
+	defer c.subscribeMu.Unlock()
+
+	c.subscriptionsMu.Lock()
+	group, ok := c.subscriptions[sub.name]
+	if !ok {
+		c.subscriptionsMu.Unlock()
+		sub.closeEvents()
+		return nil
+	}
+	delete(group.subs, sub)
+	sub.closeEvents()
+	last := len(group.subs) == 0
+	if last {
+		delete(c.subscriptions, sub.name)
+	}
+	c.subscriptionsMu.Unlock()
+
+	if !last || !c.isConnected() {
+		return nil
+	}
+	if err := c.call(ctx, "core.unsubscribe", []interface{}{group.id}, nil); err != nil {
+		return fmt.Errorf("failed to unsubscribe from %s: %w", sub.name, err)
+	}
+	return nil
+}
+
+// resubscribe re-registers every active subscription on a new connection
+func (c *Client) resubscribe(ctx context.Context) {
+	c.subscribeMu.Lock()
+	defer c.subscribeMu.Unlock()
+
+	c.subscriptionsMu.Lock()
+	names := make([]string, 0, len(c.subscriptions))
+	for name := range c.subscriptions {
+		names = append(names, name)
+	}
+	c.subscriptionsMu.Unlock()
+
+	for _, name := range names {
+		var id string
+		if err := c.call(ctx, "core.subscribe", []interface{}{name}, &id); err != nil {
+			tflog.Warn(ctx, "Failed to restore event subscription", map[string]interface{}{
+				"name":  name,
+				"error": err.Error(),
+			})
+			continue
+		}
+
+		c.subscriptionsMu.Lock()
+		if group, ok := c.subscriptions[name]; ok {
+			group.id = id
+		}
+		c.subscriptionsMu.Unlock()
+	}
+}
+
+// closeSubscriptions ends every subscription without contacting the server
+func (c *Client) closeSubscriptions() {
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+
+	for name, group := range c.subscriptions {
+		for sub := range group.subs {
+			sub.closeEvents()
+		}
+		delete(c.subscriptions, name)
+	}
+}
+
+// dispatchNotification routes a server notification to its subscribers
+func (c *Client) dispatchNotification(method string, params json.RawMessage) {
+	if method != "collection_update" {
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(params, &event); err != nil {
+		return
+	}
+
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+
+	group, ok := c.subscriptions[event.Collection]
+	if !ok {
+		return
+	}
+	for sub := range group.subs {
+		// Never block the reader on a slow consumer
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package truenas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscribe(t *testing.T) {
+	unsubscribed := make(chan interface{}, 1)
+
+	c := newTestServer(t, func(conn *websocket.Conn, n int) {
+		for {
+			var req JSONRPCRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+
+			var result interface{} = true
+			switch req.Method {
+			case "core.subscribe":
+				result = "sub-1"
+			case "core.unsubscribe":
+				unsubscribed <- req.Params.([]interface{})[0]
+				result = nil
+			}
+			_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+
+			if req.Method == "core.subscribe" {
+				_ = conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "collection_update",
+					"params": map[string]interface{}{
+						"msg":        EventChanged,
+						"collection": "core.get_jobs",
+						"id":         42,
+						"fields":     map[string]interface{}{"state": "RUNNING"},
+					},
+				})
+			}
+		}
+	})
+
+	sub, err := c.Subscribe(context.Background(), "core.get_jobs")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	select {
+	case event := <-sub.Events():
+		if event.Msg != EventChanged {
+			t.Errorf("Event.Msg = %v, want %v", event.Msg, EventChanged)
+		}
+		if event.Collection != "core.get_jobs" {
+			t.Errorf("Event.Collection = %v, want core.get_jobs", event.Collection)
+		}
+		if event.Fields["state"] != "RUNNING" {
+			t.Errorf("Event.Fields[state] = %v, want RUNNING", event.Fields["state"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	if err := sub.Unsubscribe(context.Background()); err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+
+	select {
+	case id := <-unsubscribed:
+		if id != "sub-1" {
+			t.Errorf("core.unsubscribe id = %v, want sub-1", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("core.unsubscribe was not called")
+	}
+
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Events() channel should be closed after Unsubscribe")
+	}
+}
+
+func TestSubscribeContextCancel(t *testing.T) {
+	c := newTestServer(t, func(conn *websocket.Conn, n int) {
+		serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+			if req.Method == "core.subscribe" {
+				return "sub-1", true
+			}
+			return nil, true
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := c.Subscribe(ctx, "pool.query")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-sub.Events():
+		if ok {
+			t.Error("unexpected event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Events() channel not closed after context cancellation")
+	}
+}
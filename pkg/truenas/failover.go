@@ -0,0 +1,58 @@
+package truenas
+
+import (
+	"errors"
+	"fmt"
+)
+
+// endpoint is a server the client can connect to
+type endpoint struct {
+	// host names the endpoint in errors and logs
+	host     string
+	settings *dialSettings
+}
+
+// buildEndpoints validates the connection settings of cfg and returns its
+// endpoints in the order they are tried
+func buildEndpoints(cfg *Config) ([]endpoint, error) {
+	hosts := cfg.Hosts
+	switch {
+	case len(hosts) == 0:
+		hosts = []string{cfg.Host}
+	case cfg.Host != "":
+		return nil, errors.New("set either host or hosts, not both")
+	case cfg.Transport == TransportUnix:
+		return nil, errors.New("hosts cannot be used with the unix transport")
+	}
+
+	endpoints := make([]endpoint, 0, len(hosts))
+	for _, host := range hosts {
+		hostCfg := *cfg
+		hostCfg.Host = host
+		settings, err := buildDialSettings(&hostCfg)
+		if err != nil {
+			if len(hosts) > 1 {
+				return nil, fmt.Errorf("host %q: %w", host, err)
+			}
+			return nil, err
+		}
+		// Errors and logs name the socket when there is no host
+		if settings.socketPath != "" && host == "" {
+			host = "unix:" + settings.socketPath
+		}
+		endpoints = append(endpoints, endpoint{host: host, settings: settings})
+	}
+	return endpoints, nil
+}
+
+// endpoint returns the endpoint in use, or the one tried first on the next
+// connect
+func (c *Client) endpoint() endpoint {
+	return c.endpoints[c.active.Load()]
+}
+
+// Host returns the host of the endpoint in use. With several hosts this is
+// the one the client last connected to.
+func (c *Client) Host() string {
+	return c.endpoint().host
+}
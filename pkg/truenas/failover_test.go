@@ -0,0 +1,118 @@
+package truenas
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trueform/terraform-provider-trueform/internal/truenastest"
+)
+
+// newFailoverPair starts two fake controllers sharing an API key and a
+// client configured with both
+func newFailoverPair(t *testing.T) (*truenastest.Server, *truenastest.Server, *Client) {
+	t.Helper()
+	first := truenastest.NewServer()
+	t.Cleanup(first.Close)
+	second := truenastest.NewServer()
+	t.Cleanup(second.Close)
+
+	c := NewClient(&Config{
+		Hosts:          []string{first.Host(), second.Host()},
+		APIKey:         first.APIKey,
+		ConnectTimeout: time.Second,
+	})
+	t.Cleanup(func() { _ = c.Close() })
+	return first, second, c
+}
+
+func TestFailoverStaysOnFirstHealthyEndpoint(t *testing.T) {
+	first, second, c := newFailoverPair(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := c.Call(ctx, "core.ping", nil, nil); err != nil {
+			t.Fatalf("Call() error = %v", err)
+		}
+	}
+	if c.Host() != first.Host() {
+		t.Errorf("Host() = %v, want the first endpoint %v", c.Host(), first.Host())
+	}
+	if got := second.CallCount("core.ping"); got != 0 {
+		t.Errorf("second endpoint saw %d calls, want none", got)
+	}
+}
+
+func TestFailoverOnDisconnect(t *testing.T) {
+	first, second, c := newFailoverPair(t)
+	ctx := context.Background()
+
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	// The first controller goes away; the next call fails over
+	first.Close()
+	var pools []map[string]interface{}
+	if err := c.Query(ctx, "pool", nil, &pools); err != nil {
+		t.Fatalf("Query() after failover error = %v", err)
+	}
+	if c.Host() != second.Host() {
+		t.Errorf("Host() = %v, want the second endpoint %v", c.Host(), second.Host())
+	}
+	if got := second.CallCount("auth.login_with_api_key"); got != 1 {
+		t.Errorf("second endpoint saw %d logins, want 1", got)
+	}
+	if got := second.CallCount("pool.query"); got != 1 {
+		t.Errorf("second endpoint saw %d queries, want 1", got)
+	}
+}
+
+func TestFailoverSkipsDeadEndpoint(t *testing.T) {
+	first, second, c := newFailoverPair(t)
+	first.Close()
+
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if c.Host() != second.Host() {
+		t.Errorf("Host() = %v, want the second endpoint %v", c.Host(), second.Host())
+	}
+
+	second.Close()
+	c2 := NewClient(&Config{Hosts: []string{first.Host(), second.Host()}, APIKey: first.APIKey, ConnectTimeout: time.Second})
+	defer c2.Close()
+	err := c2.Connect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "could not connect to any TrueNAS endpoint") {
+		t.Errorf("Connect() error = %v, want every endpoint to fail", err)
+	}
+}
+
+func TestHostsSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "hosts", cfg: Config{Hosts: []string{"10.0.0.1", "10.0.0.2"}}},
+		{name: "host and hosts", cfg: Config{Host: "vip", Hosts: []string{"10.0.0.1"}}, wantErr: "either host or hosts"},
+		{name: "unix", cfg: Config{Transport: TransportUnix, Hosts: []string{"10.0.0.1"}}, wantErr: "unix transport"},
+		{name: "invalid host", cfg: Config{Hosts: []string{"10.0.0.1", "10.0.0.2:443"}, Port: 8443}, wantErr: `host "10.0.0.2:443"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(&tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateConfig() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package truenas
+
+// Filter is a TrueNAS query-filter. Build filters with Field, And and Or and
+// add them to a query with QueryParams.Where:
+//
+//	params := NewQueryParams().Where(
+//		Field("name").StartsWith("tank/"),
+//		Or(Field("type").Eq("VOLUME"), Field("readonly.value").Eq("ON")),
+//	)
+type Filter interface {
+	// terms returns the filter in middleware wire format. A condition or OR
+	// is a single term; AND is the list of its member terms.
+	terms() []interface{}
+}
+
+// condition is a single [field, operator, value] triple
+type condition struct {
+	field    string
+	operator string
+	value    interface{}
+}
+
+func (c condition) terms() []interface{} {
+	return []interface{}{[]interface{}{c.field, c.operator, c.value}}
+}
+
+// and matches when every member matches
+type and []Filter
+
+func (a and) terms() []interface{} {
+	var out []interface{}
+	for _, f := range a {
+		out = append(out, f.terms()...)
+	}
+	return out
+}
+
+// or matches when any member matches
+type or []Filter
+
+func (o or) terms() []interface{} {
+	branches := make([]interface{}, 0, len(o))
+	for _, f := range o {
+		terms := f.terms()
+		if _, isAnd := f.(and); isAnd {
+			// An AND branch is sent as a nested list of filters
+			branches = append(branches, terms)
+		} else {
+			branches = append(branches, terms...)
+		}
+	}
+	return []interface{}{[]interface{}{"OR", branches}}
+}
+
+// And matches when every filter matches
+func And(filters ...Filter) Filter {
+	return and(filters)
+}
+
+// Or matches when any filter matches
+func Or(filters ...Filter) Filter {
+	return or(filters)
+}
+
+// FieldRef names a field to build conditions on. Nested fields use dotted
+// paths, e.g. "compression.value".
+type FieldRef string
+
+// Field starts a condition on the named field
+func Field(name string) FieldRef {
+	return FieldRef(name)
+}
+
+// Op builds a condition with an arbitrary operator, such as the
+// case-insensitive "C=" or "C^"
+func (f FieldRef) Op(operator string, value interface{}) Filter {
+	return condition{field: string(f), operator: operator, value: value}
+}
+
+// Eq matches fields equal to value
+func (f FieldRef) Eq(value interface{}) Filter { return f.Op("=", value) }
+
+// Ne matches fields not equal to value
+func (f FieldRef) Ne(value interface{}) Filter { return f.Op("!=", value) }
+
+// Gt matches fields greater than value
+func (f FieldRef) Gt(value interface{}) Filter { return f.Op(">", value) }
+
+// Gte matches fields greater than or equal to value
+func (f FieldRef) Gte(value interface{}) Filter { return f.Op(">=", value) }
+
+// Lt matches fields less than value
+func (f FieldRef) Lt(value interface{}) Filter { return f.Op("<", value) }
+
+// Lte matches fields less than or equal to value
+func (f FieldRef) Lte(value interface{}) Filter { return f.Op("<=", value) }
+
+// In matches fields whose value is one of values
+func (f FieldRef) In(values ...interface{}) Filter { return f.Op("in", values) }
+
+// Nin matches fields whose value is none of values
+func (f FieldRef) Nin(values ...interface{}) Filter { return f.Op("nin", values) }
+
+// Rin matches list fields that contain value
+func (f FieldRef) Rin(value interface{}) Filter { return f.Op("rin", value) }
+
+// Rnin matches list fields that do not contain value
+func (f FieldRef) Rnin(value interface{}) Filter { return f.Op("rnin", value) }
+
+// StartsWith matches string fields with the given prefix
+func (f FieldRef) StartsWith(prefix string) Filter { return f.Op("^", prefix) }
+
+// NotStartsWith matches string fields without the given prefix
+func (f FieldRef) NotStartsWith(prefix string) Filter { return f.Op("!^", prefix) }
+
+// EndsWith matches string fields with the given suffix
+func (f FieldRef) EndsWith(suffix string) Filter { return f.Op("$", suffix) }
+
+// NotEndsWith matches string fields without the given suffix
+func (f FieldRef) NotEndsWith(suffix string) Filter { return f.Op("!$", suffix) }
+
+// Matches matches string fields against a regular expression
+func (f FieldRef) Matches(pattern string) Filter { return f.Op("~", pattern) }
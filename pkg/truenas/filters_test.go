@@ -0,0 +1,94 @@
+package truenas
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// marshalFilters encodes v without HTML escaping so operators stay readable
+func marshalFilters(t *testing.T, v interface{}) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	return string(bytes.TrimSpace(buf.Bytes()))
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []Filter
+		wantJSON string
+	}{
+		{
+			name:     "single condition",
+			filters:  []Filter{Field("name").Eq("tank")},
+			wantJSON: `[["name","=","tank"]]`,
+		},
+		{
+			name:     "string operators",
+			filters:  []Filter{Field("name").StartsWith("tank/"), Field("name").EndsWith("@daily"), Field("name").Matches("^tank/.*")},
+			wantJSON: `[["name","^","tank/"],["name","$","@daily"],["name","~","^tank/.*"]]`,
+		},
+		{
+			name:     "list operators",
+			filters:  []Filter{Field("type").In("FILESYSTEM", "VOLUME"), Field("id").Nin(1, 2), Field("groups").Rin(41)},
+			wantJSON: `[["type","in",["FILESYSTEM","VOLUME"]],["id","nin",[1,2]],["groups","rin",41]]`,
+		},
+		{
+			name:     "top-level AND is flattened",
+			filters:  []Filter{And(Field("pool").Eq("tank"), And(Field("type").Eq("VOLUME")))},
+			wantJSON: `[["pool","=","tank"],["type","=","VOLUME"]]`,
+		},
+		{
+			name:     "OR of conditions",
+			filters:  []Filter{Or(Field("name").Eq("a"), Field("name").Eq("b"))},
+			wantJSON: `[["OR",[["name","=","a"],["name","=","b"]]]]`,
+		},
+		{
+			name: "OR with nested AND and OR",
+			filters: []Filter{
+				Field("pool").Eq("tank"),
+				Or(
+					And(Field("type").Eq("VOLUME"), Field("volsize.parsed").Gt(1024)),
+					Or(Field("readonly.value").Eq("ON"), Field("name").Op("C^", "TANK/backup")),
+				),
+			},
+			wantJSON: `[["pool","=","tank"],["OR",[[["type","=","VOLUME"],["volsize.parsed",">",1024]],["OR",[["readonly.value","=","ON"],["name","C^","TANK/backup"]]]]]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := NewQueryParams().Where(tt.filters...)
+
+			got := marshalFilters(t, params.Filters)
+			if got != tt.wantJSON {
+				t.Errorf("filters JSON = %s, want %s", got, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestQueryParamsArgs(t *testing.T) {
+	params := NewQueryParams().
+		Where(Field("name").StartsWith("tank/")).
+		WithExtra(map[string]interface{}{"flat": false}).
+		WithGet().
+		WithForceSQLFilters()
+
+	got := marshalFilters(t, params.args())
+	want := `[[["name","^","tank/"]],{"extra":{"flat":false},"force_sql_filters":true,"get":true}]`
+	if got != want {
+		t.Errorf("args JSON = %s, want %s", got, want)
+	}
+
+	got = marshalFilters(t, NewQueryParams().args())
+	if got != `[[]]` {
+		t.Errorf("empty args JSON = %s, want [[]]", got)
+	}
+}
@@ -0,0 +1,38 @@
+package truenas
+
+import "context"
+
+// Invoker runs a call, or the rest of the interceptor chain in front of it
+type Invoker func(ctx context.Context, method string, params interface{}) error
+
+// Interceptor runs around every Call, including the calls made by Query,
+// Create and the other helpers and the job polls of AsJob. It may inspect
+// or replace ctx, method and params before passing them to next, act on
+// the error next returns, or return without calling next to block the
+// call. The whole call, with its retries and timeout, happens inside next;
+// connecting and logging in do not pass through interceptors.
+type Interceptor func(ctx context.Context, method string, params interface{}, next Invoker) error
+
+// chainInterceptors combines interceptors into one, the first being the
+// outermost. It returns nil when there are none.
+func chainInterceptors(interceptors []Interceptor) Interceptor {
+	switch len(interceptors) {
+	case 0:
+		return nil
+	case 1:
+		return interceptors[0]
+	}
+	chain := append([]Interceptor(nil), interceptors...)
+	return func(ctx context.Context, method string, params interface{}, next Invoker) error {
+		var invoke func(i int) Invoker
+		invoke = func(i int) Invoker {
+			if i == len(chain) {
+				return next
+			}
+			return func(ctx context.Context, method string, params interface{}) error {
+				return chain[i](ctx, method, params, invoke(i+1))
+			}
+		}
+		return invoke(0)(ctx, method, params)
+	}
+}
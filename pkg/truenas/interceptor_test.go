@@ -0,0 +1,79 @@
+package truenas
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInterceptorOrder(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, method string, params interface{}, next Invoker) error {
+			mu.Lock()
+			events = append(events, name+" before "+method)
+			mu.Unlock()
+			err := next(ctx, method, params)
+			mu.Lock()
+			events = append(events, name+" after "+method)
+			mu.Unlock()
+			return err
+		}
+	}
+
+	_, c := newFakeClient(t, Config{Interceptors: []Interceptor{record("outer"), record("inner")}})
+	var pools []map[string]interface{}
+	if err := c.Query(context.Background(), "pool", nil, &pools); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	want := []string{"outer before pool.query", "inner before pool.query", "inner after pool.query", "outer after pool.query"}
+	if strings.Join(events, ", ") != strings.Join(want, ", ") {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestInterceptorBlocksCall(t *testing.T) {
+	errDryRun := errors.New("dry run")
+	dryRun := func(ctx context.Context, method string, params interface{}, next Invoker) error {
+		if strings.HasSuffix(method, ".query") {
+			return next(ctx, method, params)
+		}
+		return errDryRun
+	}
+
+	srv, c := newFakeClient(t, Config{Interceptors: []Interceptor{dryRun}})
+	err := c.Create(context.Background(), "pool.dataset", map[string]interface{}{"name": "tank/media"}, nil)
+	if !errors.Is(err, errDryRun) {
+		t.Errorf("Create() error = %v, want the interceptor's error", err)
+	}
+	if got := srv.CallCount("pool.dataset.create"); got != 0 {
+		t.Errorf("server saw %d creates, want none", got)
+	}
+}
+
+func TestInterceptorRewritesCall(t *testing.T) {
+	srv, c := newFakeClient(t, Config{Interceptors: []Interceptor{
+		func(ctx context.Context, method string, params interface{}, next Invoker) error {
+			if method == "pool.dataset.create" {
+				data := params.([]interface{})[0].(map[string]interface{})
+				data["comments"] = "managed by tooling"
+			}
+			return next(ctx, method, params)
+		},
+	}})
+
+	var result map[string]interface{}
+	if err := c.Create(context.Background(), "pool.dataset", map[string]interface{}{"name": "tank/media"}, &result); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	calls := srv.Calls()
+	last := calls[len(calls)-1]
+	data, _ := last.Params[0].(map[string]interface{})
+	if last.Method != "pool.dataset.create" || data["comments"] != "managed by tooling" {
+		t.Errorf("server saw %s %v, want the rewritten params", last.Method, last.Params)
+	}
+}
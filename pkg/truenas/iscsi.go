@@ -0,0 +1,131 @@
+package truenas
+
+// ISCSIPortal is an iSCSI portal as returned by iscsi.portal.query and
+// iscsi.portal.get_instance
+type ISCSIPortal struct {
+	ID                  int64               `json:"id"`
+	Comment             *string             `json:"comment"`
+	DiscoveryAuthmethod *string             `json:"discovery_authmethod"`
+	DiscoveryAuthgroup  *FlexInt64          `json:"discovery_authgroup"`
+	Listen              []ISCSIPortalListen `json:"listen"`
+}
+
+// ISCSIPortalListen is an address an iSCSI portal listens on. Recent
+// releases take only the IP and listen on the default port.
+type ISCSIPortalListen struct {
+	IP   string `json:"ip"`
+	Port int64  `json:"port,omitempty"`
+}
+
+// ISCSIPortalRequest is the data of iscsi.portal.create and
+// iscsi.portal.update
+type ISCSIPortalRequest struct {
+	Listen              []ISCSIPortalListen `json:"listen"`
+	Comment             *string             `json:"comment,omitempty"`
+	DiscoveryAuthmethod *string             `json:"discovery_authmethod,omitempty"`
+	DiscoveryAuthgroup  *int64              `json:"discovery_authgroup,omitempty"`
+}
+
+// ISCSITarget is an iSCSI target as returned by iscsi.target.query and
+// iscsi.target.get_instance
+type ISCSITarget struct {
+	ID     int64              `json:"id"`
+	Name   string             `json:"name"`
+	Alias  *string            `json:"alias"`
+	Mode   *string            `json:"mode"`
+	Groups []ISCSITargetGroup `json:"groups"`
+}
+
+// ISCSITargetGroup binds a target to a portal, with optional initiator
+// group and authentication
+type ISCSITargetGroup struct {
+	Portal     int64   `json:"portal"`
+	Initiator  *int64  `json:"initiator,omitempty"`
+	Authmethod *string `json:"authmethod,omitempty"`
+	Auth       *int64  `json:"auth,omitempty"`
+}
+
+// ISCSITargetRequest is the data of iscsi.target.create and
+// iscsi.target.update. Nil fields are left out.
+type ISCSITargetRequest struct {
+	Name   *string             `json:"name,omitempty"`
+	Alias  *string             `json:"alias,omitempty"`
+	Mode   *string             `json:"mode,omitempty"`
+	Groups *[]ISCSITargetGroup `json:"groups,omitempty"`
+}
+
+// ISCSIExtent is an iSCSI extent as returned by iscsi.extent.query and
+// iscsi.extent.get_instance
+type ISCSIExtent struct {
+	ID             int64      `json:"id"`
+	Name           string     `json:"name"`
+	Type           string     `json:"type"`
+	Disk           *string    `json:"disk"`
+	Path           *string    `json:"path"`
+	Filesize       *FlexInt64 `json:"filesize"`
+	Blocksize      *FlexInt64 `json:"blocksize"`
+	Pblocksize     *bool      `json:"pblocksize"`
+	AvailThreshold *FlexInt64 `json:"avail_threshold"`
+	Comment        *string    `json:"comment"`
+	InsecureTPC    *bool      `json:"insecure_tpc"`
+	Xen            *bool      `json:"xen"`
+	RPM            *string    `json:"rpm"`
+	Ro             *bool      `json:"ro"`
+	Enabled        *bool      `json:"enabled"`
+	Serial         *string    `json:"serial"`
+	NAA            *string    `json:"naa"`
+	Locked         *bool      `json:"locked"`
+}
+
+// ISCSIExtentRequest is the data of iscsi.extent.create and
+// iscsi.extent.update. Nil fields are left out.
+type ISCSIExtentRequest struct {
+	Name           *string `json:"name,omitempty"`
+	Type           *string `json:"type,omitempty"`
+	Disk           *string `json:"disk,omitempty"`
+	Path           *string `json:"path,omitempty"`
+	Filesize       *int64  `json:"filesize,omitempty"`
+	Blocksize      *int64  `json:"blocksize,omitempty"`
+	Pblocksize     *bool   `json:"pblocksize,omitempty"`
+	AvailThreshold *int64  `json:"avail_threshold,omitempty"`
+	Comment        *string `json:"comment,omitempty"`
+	InsecureTPC    *bool   `json:"insecure_tpc,omitempty"`
+	Xen            *bool   `json:"xen,omitempty"`
+	RPM            *string `json:"rpm,omitempty"`
+	Ro             *bool   `json:"ro,omitempty"`
+	Enabled        *bool   `json:"enabled,omitempty"`
+}
+
+// ISCSIInitiator is an iSCSI initiator group as returned by
+// iscsi.initiator.query and iscsi.initiator.get_instance
+type ISCSIInitiator struct {
+	ID          int64    `json:"id"`
+	Comment     *string  `json:"comment"`
+	Initiators  []string `json:"initiators"`
+	AuthNetwork []string `json:"auth_network"`
+}
+
+// ISCSIInitiatorRequest is the data of iscsi.initiator.create and
+// iscsi.initiator.update. Nil fields are left out.
+type ISCSIInitiatorRequest struct {
+	Comment     *string   `json:"comment,omitempty"`
+	Initiators  *[]string `json:"initiators,omitempty"`
+	AuthNetwork *[]string `json:"auth_network,omitempty"`
+}
+
+// ISCSITargetExtent maps an extent to a LUN of a target, as returned by
+// iscsi.targetextent.query and iscsi.targetextent.get_instance
+type ISCSITargetExtent struct {
+	ID     int64 `json:"id"`
+	Target int64 `json:"target"`
+	Extent int64 `json:"extent"`
+	LunID  int64 `json:"lunid"`
+}
+
+// ISCSITargetExtentRequest is the data of iscsi.targetextent.create and
+// iscsi.targetextent.update. Nil fields are left out.
+type ISCSITargetExtentRequest struct {
+	Target *int64 `json:"target,omitempty"`
+	Extent *int64 `json:"extent,omitempty"`
+	LunID  *int64 `json:"lunid,omitempty"`
+}
@@ -0,0 +1,214 @@
+package truenas
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// jobPollInterval is used when job events are unavailable
+	jobPollInterval = 2 * time.Second
+	// jobEventPollInterval is a safety net for dropped job events
+	jobEventPollInterval = 15 * time.Second
+)
+
+// jobProgress tracks the last progress reported for a job so unchanged
+// updates are not logged repeatedly
+type jobProgress struct {
+	percent     float64
+	description string
+}
+
+// WaitForJob waits for a TrueNAS job to complete and returns the result.
+// Job state is followed through core.get_jobs events, falling back to
+// polling. If ctx is cancelled or the timeout passes, the job is aborted
+// on the server.
+func (c *Client) WaitForJob(ctx context.Context, jobID int64, timeout time.Duration) (map[string]interface{}, error) {
+	job, err := c.waitJob(ctx, jobID, timeout)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := jobResult(jobID, job)
+	return result, err
+}
+
+// waitJob waits for a job to succeed and returns its final state
+func (c *Client) waitJob(ctx context.Context, jobID int64, timeout time.Duration) (map[string]interface{}, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	pollInterval := jobPollInterval
+	var events <-chan Event
+	sub, err := c.Subscribe(ctx, "core.get_jobs")
+	if err != nil {
+		tflog.Debug(ctx, "Job events unavailable, polling instead", map[string]interface{}{
+			"job_id": jobID,
+			"error":  err.Error(),
+		})
+	} else {
+		defer func() {
+			_ = sub.Unsubscribe(context.Background())
+		}()
+		events = sub.Events()
+		pollInterval = jobEventPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var progress jobProgress
+
+	// Poll once up front in case the job finished before we subscribed
+	job, err := c.getJob(ctx, jobID)
+
+	for {
+		if err != nil {
+			if ctx.Err() != nil {
+				c.abortJob(ctx, jobID)
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		if job != nil {
+			logJobProgress(ctx, jobID, job, &progress)
+			if _, done, err := jobResult(jobID, job); done {
+				if err != nil {
+					return nil, err
+				}
+				return job, nil
+			}
+		}
+
+		job = nil
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// Subscription ended; keep polling
+				events = nil
+				continue
+			}
+			if id, ok := event.ID.(float64); ok && int64(id) == jobID && event.Fields != nil {
+				job = event.Fields
+			}
+		case <-ticker.C:
+			job, err = c.getJob(ctx, jobID)
+		case <-ctx.Done():
+			c.abortJob(ctx, jobID)
+			return nil, ctx.Err()
+		case <-deadline.C:
+			c.abortJob(ctx, jobID)
+			return nil, fmt.Errorf("timeout waiting for job %d to complete", jobID)
+		}
+	}
+}
+
+// getJob fetches the current state of a job
+func (c *Client) getJob(ctx context.Context, jobID int64) (map[string]interface{}, error) {
+	// The API returns an array, get the first element
+	var jobs []map[string]interface{}
+	err := c.Call(ctx, "core.get_jobs", []interface{}{
+		[][]interface{}{{"id", "=", jobID}},
+	}, &jobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job status: %w", err)
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("job %d not found", jobID)
+	}
+
+	return jobs[0], nil
+}
+
+// jobResult inspects a job and reports whether it has finished
+func jobResult(jobID int64, job map[string]interface{}) (map[string]interface{}, bool, error) {
+	state, _ := job["state"].(string)
+
+	switch state {
+	case "SUCCESS":
+		if result, ok := job["result"].(map[string]interface{}); ok {
+			return result, true, nil
+		}
+		// Some jobs return simple values or nil
+		return job, true, nil
+	case "FAILED":
+		return nil, true, NewJobError(jobID, job)
+	case "ABORTED":
+		return nil, true, fmt.Errorf("job %d was aborted", jobID)
+	default:
+		// Job still running
+		return nil, false, nil
+	}
+}
+
+// logJobProgress logs the job's progress when it has changed
+func logJobProgress(ctx context.Context, jobID int64, job map[string]interface{}, last *jobProgress) {
+	progress, ok := job["progress"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	percent, _ := progress["percent"].(float64)
+	description, _ := progress["description"].(string)
+	if percent == last.percent && description == last.description {
+		return
+	}
+	last.percent = percent
+	last.description = description
+
+	method, _ := job["method"].(string)
+	tflog.Info(ctx, fmt.Sprintf("Job %d (%s): %.0f%% %s", jobID, method, percent, description), map[string]interface{}{
+		"job_id":      jobID,
+		"method":      method,
+		"percent":     percent,
+		"description": description,
+	})
+}
+
+// abortJob asks the server to abort a job after the caller gave up on it.
+// ctx is usually already cancelled, so the abort uses its own timeout.
+func (c *Client) abortJob(ctx context.Context, jobID int64) {
+	abortCtx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	tflog.Warn(ctx, "Aborting TrueNAS job", map[string]interface{}{
+		"job_id": jobID,
+	})
+
+	if err := c.Call(abortCtx, "core.job_abort", []interface{}{jobID}, nil); err != nil {
+		tflog.Warn(ctx, "Failed to abort TrueNAS job", map[string]interface{}{
+			"job_id": jobID,
+			"error":  err.Error(),
+		})
+	}
+}
+
+// CreateWithJob creates a resource and waits for the job to complete
+func (c *Client) CreateWithJob(ctx context.Context, resource string, data interface{}, timeout time.Duration) (map[string]interface{}, error) {
+	method := resource + ".create"
+
+	var jobID float64
+	err := c.Call(ctx, method, []interface{}{data}, &jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitForJob(ctx, int64(jobID), timeout)
+}
+
+// UpdateWithJob updates a resource and waits for the job to complete
+func (c *Client) UpdateWithJob(ctx context.Context, resource string, id interface{}, data interface{}, timeout time.Duration) (map[string]interface{}, error) {
+	method := resource + ".update"
+
+	var jobID float64
+	err := c.Call(ctx, method, []interface{}{id, data}, &jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitForJob(ctx, int64(jobID), timeout)
+}
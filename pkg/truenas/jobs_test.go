@@ -0,0 +1,173 @@
+package truenas
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// serveJob answers core.get_jobs with a RUNNING job and, once subscribed,
+// pushes finalState as a job event. Requested methods are sent to seen.
+func serveJob(conn *websocket.Conn, jobID int64, finalState map[string]interface{}, seen chan<- string) {
+	for {
+		var req JSONRPCRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		select {
+		case seen <- req.Method:
+		default:
+		}
+
+		var result interface{} = true
+		switch req.Method {
+		case "core.subscribe":
+			result = "sub-1"
+		case "core.get_jobs":
+			result = []interface{}{map[string]interface{}{
+				"id":       jobID,
+				"method":   "pool.create",
+				"state":    "RUNNING",
+				"progress": map[string]interface{}{"percent": 10, "description": "Creating pool"},
+			}}
+		case "core.unsubscribe", "core.job_abort":
+			result = nil
+		}
+		_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+
+		if req.Method == "core.get_jobs" && finalState != nil {
+			_ = conn.WriteJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "collection_update",
+				"params": map[string]interface{}{
+					"msg":        EventChanged,
+					"collection": "core.get_jobs",
+					"id":         jobID,
+					"fields":     finalState,
+				},
+			})
+		}
+	}
+}
+
+func TestWaitForJob(t *testing.T) {
+	t.Run("completes from job event", func(t *testing.T) {
+		c := newTestServer(t, func(conn *websocket.Conn, n int) {
+			serveJob(conn, 7, map[string]interface{}{
+				"id":     7,
+				"state":  "SUCCESS",
+				"result": map[string]interface{}{"id": 3, "name": "tank"},
+			}, nil)
+		})
+
+		result, err := c.WaitForJob(context.Background(), 7, 5*time.Second)
+		if err != nil {
+			t.Fatalf("WaitForJob() error = %v", err)
+		}
+		if result["name"] != "tank" {
+			t.Errorf("result[name] = %v, want tank", result["name"])
+		}
+	})
+
+	t.Run("reports failure from job event", func(t *testing.T) {
+		c := newTestServer(t, func(conn *websocket.Conn, n int) {
+			serveJob(conn, 8, map[string]interface{}{
+				"id":    8,
+				"state": "FAILED",
+				"error": "[EFAULT] disk in use",
+			}, nil)
+		})
+
+		_, err := c.WaitForJob(context.Background(), 8, 5*time.Second)
+		if err == nil || !strings.Contains(err.Error(), "disk in use") {
+			t.Fatalf("WaitForJob() error = %v, want job failure", err)
+		}
+	})
+
+	t.Run("aborts job when context is cancelled", func(t *testing.T) {
+		seen := make(chan string, 16)
+		c := newTestServer(t, func(conn *websocket.Conn, n int) {
+			serveJob(conn, 9, nil, seen)
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			for method := range seen {
+				if method == "core.get_jobs" {
+					cancel()
+					return
+				}
+			}
+		}()
+
+		_, err := c.WaitForJob(ctx, 9, 5*time.Second)
+		if err != context.Canceled {
+			t.Fatalf("WaitForJob() error = %v, want context.Canceled", err)
+		}
+
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case method := <-seen:
+				if method == "core.job_abort" {
+					return
+				}
+			case <-timeout:
+				t.Fatal("core.job_abort was not called")
+			}
+		}
+	})
+
+	t.Run("aborts job when the timeout passes", func(t *testing.T) {
+		seen := make(chan string, 16)
+		c := newTestServer(t, func(conn *websocket.Conn, n int) {
+			serveJob(conn, 10, nil, seen)
+		})
+
+		_, err := c.WaitForJob(context.Background(), 10, 100*time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "timeout waiting for job 10") {
+			t.Fatalf("WaitForJob() error = %v, want timeout", err)
+		}
+
+		// The abort is sent before WaitForJob returns
+		for {
+			select {
+			case method := <-seen:
+				if method == "core.job_abort" {
+					return
+				}
+			default:
+				t.Fatal("core.job_abort was not called")
+			}
+		}
+	})
+}
+
+func TestJobResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      map[string]interface{}
+		wantDone bool
+		wantErr  bool
+	}{
+		{"running", map[string]interface{}{"state": "RUNNING"}, false, false},
+		{"success", map[string]interface{}{"state": "SUCCESS", "result": map[string]interface{}{}}, true, false},
+		{"failed", map[string]interface{}{"state": "FAILED", "error": "boom"}, true, true},
+		{"aborted", map[string]interface{}{"state": "ABORTED"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, done, err := jobResult(1, tt.job)
+			if done != tt.wantDone {
+				t.Errorf("jobResult() done = %v, want %v", done, tt.wantDone)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("jobResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
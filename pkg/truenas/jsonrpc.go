@@ -0,0 +1,180 @@
+package truenas
+
+import "encoding/json"
+
+// JSONRPCRequest represents a JSON-RPC 2.0 request
+type JSONRPCRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+	ID      int64       `json:"id"`
+}
+
+// JSONRPCResponse represents a JSON-RPC 2.0 response
+type JSONRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      int64           `json:"id"`
+}
+
+// jsonrpcMessage is any message read from the server: responses carry the
+// request ID, notifications carry a method and params instead
+type jsonrpcMessage struct {
+	JSONRPCResponse
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// JSONRPCError represents a JSON-RPC 2.0 error
+type JSONRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// NewRequest creates a new JSON-RPC 2.0 request
+func NewRequest(id int64, method string, params interface{}) *JSONRPCRequest {
+	return &JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      id,
+	}
+}
+
+// QueryParams represents common query parameters for list operations
+type QueryParams struct {
+	Limit   int                      `json:"limit,omitempty"`
+	Offset  int                      `json:"offset,omitempty"`
+	Count   bool                     `json:"count,omitempty"`
+	OrderBy []string                 `json:"order_by,omitempty"`
+	Select  []string                 `json:"select,omitempty"`
+	Filters [][]interface{}          `json:"filters,omitempty"`
+	Options map[string]interface{}   `json:"options,omitempty"`
+
+	// Extra passes method-specific query options, e.g. {"flat": false}
+	Extra map[string]interface{} `json:"extra,omitempty"`
+	// Get asks the middleware to return the single matching object instead
+	// of a list; it fails if nothing matches
+	Get bool `json:"get,omitempty"`
+	// ForceSQLFilters applies filters in the database rather than in Python
+	ForceSQLFilters bool `json:"force_sql_filters,omitempty"`
+
+	// PageSize is the number of items QueryAll and QueryIter request per page
+	PageSize int `json:"-"`
+}
+
+// NewQueryParams creates a new QueryParams with defaults
+func NewQueryParams() *QueryParams {
+	return &QueryParams{}
+}
+
+// WithFilter adds a filter to the query
+func (q *QueryParams) WithFilter(field string, operator string, value interface{}) *QueryParams {
+	q.Filters = append(q.Filters, []interface{}{field, operator, value})
+	return q
+}
+
+// Where adds filters built with Field, And and Or to the query. All filters
+// passed to the query must match.
+func (q *QueryParams) Where(filters ...Filter) *QueryParams {
+	for _, f := range filters {
+		for _, term := range f.terms() {
+			q.Filters = append(q.Filters, term.([]interface{}))
+		}
+	}
+	return q
+}
+
+// args returns the [filters, options] arguments of a query call
+func (q *QueryParams) args() []interface{} {
+	var args []interface{}
+	// Convert filters to the format expected by TrueNAS
+	queryOptions := map[string]interface{}{}
+	for k, v := range q.Options {
+		queryOptions[k] = v
+	}
+	if q.Limit > 0 {
+		queryOptions["limit"] = q.Limit
+	}
+	if q.Offset > 0 {
+		queryOptions["offset"] = q.Offset
+	}
+	if q.Count {
+		queryOptions["count"] = q.Count
+	}
+	if len(q.OrderBy) > 0 {
+		queryOptions["order_by"] = q.OrderBy
+	}
+	if len(q.Select) > 0 {
+		queryOptions["select"] = q.Select
+	}
+	if len(q.Extra) > 0 {
+		queryOptions["extra"] = q.Extra
+	}
+	if q.Get {
+		queryOptions["get"] = true
+	}
+	if q.ForceSQLFilters {
+		queryOptions["force_sql_filters"] = true
+	}
+
+	if len(q.Filters) > 0 {
+		args = append(args, q.Filters)
+	} else {
+		args = append(args, []interface{}{})
+	}
+	if len(queryOptions) > 0 {
+		args = append(args, queryOptions)
+	}
+	return args
+}
+
+// WithLimit sets the limit for the query
+func (q *QueryParams) WithLimit(limit int) *QueryParams {
+	q.Limit = limit
+	return q
+}
+
+// WithOffset sets the offset for the query
+func (q *QueryParams) WithOffset(offset int) *QueryParams {
+	q.Offset = offset
+	return q
+}
+
+// WithSelect sets the fields to select
+func (q *QueryParams) WithSelect(fields ...string) *QueryParams {
+	q.Select = fields
+	return q
+}
+
+// WithOrderBy sets the fields to order results by
+func (q *QueryParams) WithOrderBy(fields ...string) *QueryParams {
+	q.OrderBy = fields
+	return q
+}
+
+// WithPageSize sets the page size used by QueryAll and QueryIter
+func (q *QueryParams) WithPageSize(size int) *QueryParams {
+	q.PageSize = size
+	return q
+}
+
+// WithExtra sets method-specific extra query options
+func (q *QueryParams) WithExtra(extra map[string]interface{}) *QueryParams {
+	q.Extra = extra
+	return q
+}
+
+// WithGet asks for the single matching object instead of a list
+func (q *QueryParams) WithGet() *QueryParams {
+	q.Get = true
+	return q
+}
+
+// WithForceSQLFilters applies filters in the database
+func (q *QueryParams) WithForceSQLFilters() *QueryParams {
+	q.ForceSQLFilters = true
+	return q
+}
@@ -0,0 +1,84 @@
+package truenas
+
+import (
+	"context"
+	"sync"
+)
+
+// KeyedMutex serializes work per key while different keys proceed in
+// parallel. The zero value is ready to use.
+type KeyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+// keyedLock is the lock of one key; refs counts holders and waiters so the
+// entry can be dropped once nobody uses it
+type keyedLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+// Lock waits until key is free and returns the function that frees it. It
+// gives up with ctx's error when ctx ends first.
+func (m *KeyedMutex) Lock(ctx context.Context, key string) (func(), error) {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyedLock)
+	}
+	l := m.locks[key]
+	if l == nil {
+		l = &keyedLock{ch: make(chan struct{}, 1)}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	select {
+	case l.ch <- struct{}{}:
+	case <-ctx.Done():
+		m.release(key, l)
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-l.ch
+			m.release(key, l)
+		})
+	}, nil
+}
+
+// release drops a reference to the lock of key
+func (m *KeyedMutex) release(key string, l *keyedLock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l.refs--
+	if l.refs == 0 {
+		delete(m.locks, key)
+	}
+}
+
+// Lock serializes mutations that touch the same parent object, such as a
+// pool, a VM or an iSCSI target, across all resources using this client.
+// Reads do not need it. Call the returned function to unlock.
+func (c *Client) Lock(ctx context.Context, key string) (func(), error) {
+	return c.locks.Lock(ctx, key)
+}
+
+// acquireSlot waits for a free request slot when the number of concurrent
+// requests is limited, and returns the function that frees it
+func (c *Client) acquireSlot(ctx context.Context) (func(), error) {
+	if c.requestSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.requestSlots <- struct{}{}:
+		return func() { <-c.requestSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.ctx.Done():
+		return nil, NewConnectionLostError(c.Host(), errClientClosed)
+	}
+}
@@ -0,0 +1,132 @@
+package truenas
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var m KeyedMutex
+	ctx := context.Background()
+
+	unlock, err := m.Lock(ctx, "pool/tank")
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	// A different key does not wait
+	other, err := m.Lock(ctx, "pool/backup")
+	if err != nil {
+		t.Fatalf("Lock() of another key error = %v", err)
+	}
+	other()
+
+	// The same key waits until unlocked
+	acquired := make(chan struct{})
+	go func() {
+		again, err := m.Lock(ctx, "pool/tank")
+		if err != nil {
+			t.Errorf("Lock() error = %v", err)
+			return
+		}
+		close(acquired)
+		again()
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Lock() of a held key returned before unlock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	unlock() // unlocking twice is harmless
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Lock() did not return after unlock")
+	}
+
+	// Waiting gives up with the context
+	unlock, _ = m.Lock(ctx, "vm/1")
+	defer unlock()
+	cancelCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := m.Lock(cancelCtx, "vm/1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestKeyedMutexReleasesKeys(t *testing.T) {
+	var m KeyedMutex
+	unlock, _ := m.Lock(context.Background(), "pool/tank")
+	unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.locks) != 0 {
+		t.Errorf("locks = %v, want no entries after unlock", m.locks)
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	const limit = 2
+	var inFlight, maxInFlight int32
+	host := startTestServer(t, func(conn *websocket.Conn, n int) {
+		// Answer requests concurrently, like the middleware does
+		var writeMu sync.Mutex
+		reply := func(id int64, result interface{}) {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+		}
+		for {
+			var req JSONRPCRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method != "pool.query" {
+				reply(req.ID, true)
+				continue
+			}
+			go func(id int64) {
+				current := atomic.AddInt32(&inFlight, 1)
+				for {
+					seen := atomic.LoadInt32(&maxInFlight)
+					if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+						break
+					}
+				}
+				time.Sleep(30 * time.Millisecond)
+				atomic.AddInt32(&inFlight, -1)
+				reply(id, []interface{}{})
+			}(req.ID)
+		}
+	})
+
+	c := NewClient(&Config{Host: host, APIKey: "test-key", MaxConcurrentRequests: limit})
+	defer c.Close()
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Call(context.Background(), "pool.query", nil, nil); err != nil {
+				t.Errorf("Call() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&maxInFlight); got != limit {
+		t.Errorf("server saw %d requests in flight, want %d", got, limit)
+	}
+}
@@ -0,0 +1,18 @@
+package truenas
+
+// StaticRoute is a static route as returned by staticroute.query and
+// staticroute.get_instance
+type StaticRoute struct {
+	ID          int64   `json:"id"`
+	Destination string  `json:"destination"`
+	Gateway     string  `json:"gateway"`
+	Description *string `json:"description"`
+}
+
+// StaticRouteRequest is the data of staticroute.create and
+// staticroute.update. Nil fields are left out.
+type StaticRouteRequest struct {
+	Destination *string `json:"destination,omitempty"`
+	Gateway     *string `json:"gateway,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
@@ -0,0 +1,136 @@
+package truenas
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// defaultJobTimeout bounds the wait for a job started with AsJob when the
+// call has no timeout of its own
+const defaultJobTimeout = 10 * time.Minute
+
+// CallOption adjusts a single Call
+type CallOption func(*callOptions)
+
+// callOptions holds the effect of the CallOptions passed to Call
+type callOptions struct {
+	// timeout bounds the whole call, including the job for AsJob; zero
+	// leaves the default request timeout in place
+	timeout time.Duration
+	// retry replaces the default retry policy of the method when set
+	retry *RetryPolicy
+	// job waits for the job the method starts and returns its result
+	job bool
+}
+
+// Retry backoff defaults
+const (
+	defaultRetryDelay    = 500 * time.Millisecond
+	defaultMaxRetryDelay = 5 * time.Second
+	// maxTransientRetries bounds retries of busy or locked errors
+	maxTransientRetries = 5
+)
+
+// RetryPolicy controls how often a call is retried. Transient middleware
+// errors (see IsRetryableError) are retried after a jittered exponential
+// backoff. A call is also re-sent right away when the connection drops
+// before a response arrives, unless TransientOnly is set.
+type RetryPolicy struct {
+	// MaxRetries is how many times the call is repeated; zero disables retries
+	MaxRetries int
+	// InitialDelay is the backoff before the first retry of a transient
+	// error, doubling up to MaxDelay. Zero uses 500ms and 5s.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	// TransientOnly skips re-sending after a dropped connection, for calls
+	// the server may already have run
+	TransientOnly bool
+}
+
+// transientRetryNamespaces are namespaces whose create, update and delete
+// calls are rejected with busy errors under concurrent mutations. The
+// rejection happens before anything changes, so those errors are retried,
+// but a call lost with the connection is not re-sent.
+var transientRetryNamespaces = map[string]bool{
+	"pool.dataset": true,
+	"zfs.snapshot": true,
+	"sharing.smb":  true,
+	"sharing.nfs":  true,
+}
+
+// defaultRetryPolicy retries read-only methods, which are always safe to
+// send again, and busy rejections of dataset, snapshot and share mutations
+func defaultRetryPolicy(method string) RetryPolicy {
+	if isIdempotent(method) {
+		return RetryPolicy{MaxRetries: maxIdempotentRetries}
+	}
+	if dot := strings.LastIndex(method, "."); dot > 0 && transientRetryNamespaces[method[:dot]] {
+		switch method[dot+1:] {
+		case "create", "update", "delete":
+			return RetryPolicy{MaxRetries: maxTransientRetries, TransientOnly: true}
+		}
+	}
+	return RetryPolicy{}
+}
+
+// backoff returns the jittered delay before retry number attempt, counting
+// from zero. The delay is drawn from the upper half of the exponential step
+// so that concurrent callers spread out.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxRetryDelay
+	}
+	for i := 0; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// WithTimeout bounds the call, replacing the request timeout. A deadline
+// on the call's context still applies when it is earlier.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithRetry sets the retry policy of the call, marking it safe to repeat
+// even when the method is not read-only
+func WithRetry(policy RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retry = &policy
+	}
+}
+
+// AsJob treats the method's result as a job id, waits for the job and
+// decodes the job's result into the call's result. Without WithTimeout or a
+// context deadline the job may run for up to ten minutes; when the call
+// times out or is cancelled the job is aborted.
+func AsJob() CallOption {
+	return func(o *callOptions) {
+		o.job = true
+	}
+}
+
+// buildCallOptions applies opts over the defaults for method
+func buildCallOptions(method string, opts []CallOption) callOptions {
+	var o callOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.retry == nil {
+		policy := defaultRetryPolicy(method)
+		o.retry = &policy
+	}
+	return o
+}
@@ -0,0 +1,184 @@
+package truenas
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/trueform/terraform-provider-trueform/internal/truenastest"
+)
+
+// newFakeClient starts a fake middleware and a client connected to it
+func newFakeClient(t *testing.T, cfg Config) (*truenastest.Server, *Client) {
+	t.Helper()
+	srv := truenastest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cfg.Host = srv.Host()
+	cfg.APIKey = srv.APIKey
+	c := NewClient(&cfg)
+	t.Cleanup(func() { _ = c.Close() })
+	return srv, c
+}
+
+// slowHandler answers after delay
+func slowHandler(delay time.Duration) truenastest.Handler {
+	return func(params []interface{}) (interface{}, error) {
+		time.Sleep(delay)
+		return true, nil
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	srv, c := newFakeClient(t, Config{})
+	srv.Handle("pool.scrub", slowHandler(300*time.Millisecond))
+
+	err := c.Call(context.Background(), "pool.scrub", []interface{}{1, "START"}, nil, WithTimeout(50*time.Millisecond))
+	if err == nil || !strings.Contains(err.Error(), "pool.scrub timed out after 50ms") {
+		t.Errorf("Call() error = %v, want timed out after 50ms", err)
+	}
+
+	// A longer per-call timeout outlasts the request timeout
+	srv2, c2 := newFakeClient(t, Config{Timeout: 50 * time.Millisecond})
+	srv2.Handle("pool.scrub", slowHandler(150*time.Millisecond))
+	if err := c2.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if err := c2.Call(context.Background(), "pool.scrub", []interface{}{1, "START"}, nil, WithTimeout(2*time.Second)); err != nil {
+		t.Errorf("Call() with WithTimeout error = %v", err)
+	}
+	if err := c2.Call(context.Background(), "pool.scrub", []interface{}{1, "START"}, nil); err == nil || !strings.Contains(err.Error(), "request timeout") {
+		t.Errorf("Call() without WithTimeout error = %v, want request timeout", err)
+	}
+}
+
+func TestContextDeadlineOverridesRequestTimeout(t *testing.T) {
+	srv, c := newFakeClient(t, Config{Timeout: 50 * time.Millisecond})
+	srv.Handle("pool.scrub", slowHandler(150*time.Millisecond))
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.Call(ctx, "pool.scrub", []interface{}{1, "START"}, nil); err != nil {
+		t.Errorf("Call() error = %v, want the context deadline to replace the request timeout", err)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	srv, c := newFakeClient(t, Config{})
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	// Drop the connection instead of answering the first create
+	var dropped atomic.Bool
+	srv.Handle("sharing.nfs.create", func(params []interface{}) (interface{}, error) {
+		if dropped.CompareAndSwap(false, true) {
+			srv.DropConnections()
+			time.Sleep(50 * time.Millisecond)
+			return nil, nil
+		}
+		return map[string]interface{}{"id": 1}, nil
+	})
+
+	err := c.Call(context.Background(), "sharing.nfs.create", []interface{}{map[string]interface{}{"path": "/mnt/tank"}}, nil)
+	if !IsConnectionLostError(err) {
+		t.Fatalf("Call() error = %v, want connection lost without a retry policy", err)
+	}
+
+	dropped.Store(false)
+	var share map[string]interface{}
+	err = c.Call(context.Background(), "sharing.nfs.create", []interface{}{map[string]interface{}{"path": "/mnt/tank"}}, &share, WithRetry(RetryPolicy{MaxRetries: 1}))
+	if err != nil {
+		t.Fatalf("Call() with WithRetry error = %v", err)
+	}
+	if share["id"] != float64(1) {
+		t.Errorf("Call() result = %v, want id 1", share)
+	}
+}
+
+func TestAsJob(t *testing.T) {
+	srv, c := newFakeClient(t, Config{})
+
+	var pool map[string]interface{}
+	err := c.Call(context.Background(), "pool.create", []interface{}{map[string]interface{}{"name": "tank"}}, &pool, AsJob())
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if pool["name"] != "tank" {
+		t.Errorf("Call() result = %v, want pool tank", pool)
+	}
+
+	srv.FailNextJob("pool.export", "[EBUSY] pool is busy")
+	err = c.Call(context.Background(), "pool.export", []interface{}{pool["id"], map[string]interface{}{"destroy": true}}, nil, AsJob())
+	if !IsJobError(err) {
+		t.Errorf("Call() error = %v, want job error", err)
+	}
+	if srv.Get("pool", pool["id"]) == nil {
+		t.Error("failed export removed the pool")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	bounds := []struct{ min, max time.Duration }{
+		{50 * time.Millisecond, 100 * time.Millisecond},
+		{100 * time.Millisecond, 200 * time.Millisecond},
+		{150 * time.Millisecond, 300 * time.Millisecond},
+		{150 * time.Millisecond, 300 * time.Millisecond},
+	}
+	for attempt, b := range bounds {
+		for i := 0; i < 20; i++ {
+			if d := policy.backoff(attempt); d < b.min || d > b.max {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, d, b.min, b.max)
+			}
+		}
+	}
+}
+
+func TestTransientRetry(t *testing.T) {
+	srv, c := newFakeClient(t, Config{})
+
+	// Busy rejections of dataset mutations are retried by default
+	srv.FailNext("pool.dataset.create", truenastest.Busy("pool is busy"))
+	var dataset map[string]interface{}
+	if err := c.Create(context.Background(), "pool.dataset", map[string]interface{}{"name": "tank/media"}, &dataset); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got := srv.CallCount("pool.dataset.create"); got != 2 {
+		t.Errorf("pool.dataset.create called %d times, want 2", got)
+	}
+
+	// Methods not marked safe fail on the first busy error
+	srv.FailNext("vm.create", truenastest.Busy("pool is busy"))
+	err := c.Create(context.Background(), "vm", map[string]interface{}{"name": "vm1"}, nil)
+	if !IsRetryableError(err) {
+		t.Errorf("Create() error = %v, want the busy error", err)
+	}
+	if got := srv.CallCount("vm.create"); got != 1 {
+		t.Errorf("vm.create called %d times, want 1", got)
+	}
+
+	// WithRetry marks a method safe, and retries are bounded
+	fast := RetryPolicy{MaxRetries: 2, InitialDelay: time.Millisecond}
+	for i := 0; i < 3; i++ {
+		srv.FailNext("vm.update", truenastest.Busy("pool is busy"))
+	}
+	err = c.Update(context.Background(), "vm", 1, map[string]interface{}{}, nil, WithRetry(fast))
+	if !IsRetryableError(err) {
+		t.Errorf("Update() error = %v, want the busy error after exhausting retries", err)
+	}
+	if got := srv.CallCount("vm.update"); got != 3 {
+		t.Errorf("vm.update called %d times, want 3", got)
+	}
+
+	// Jobs that fail because of a busy pool are started again
+	srv.FailNextJob("pool.create", "[EBUSY] pool is busy")
+	if err := c.Call(context.Background(), "pool.create", []interface{}{map[string]interface{}{"name": "tank"}}, nil, AsJob(), WithRetry(fast)); err != nil {
+		t.Errorf("Call() with AsJob error = %v", err)
+	}
+}
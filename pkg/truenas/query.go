@@ -0,0 +1,132 @@
+package truenas
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultPageSize is how many items QueryAll and QueryIter fetch per request
+const defaultPageSize = 100
+
+// QueryIterator pages through the results of a query. Use it like
+// bufio.Scanner:
+//
+//	it := c.QueryIter(ctx, "pool.snapshot", params)
+//	for it.Next() {
+//		snapshot := it.Item()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type QueryIterator struct {
+	client   *Client
+	ctx      context.Context
+	resource string
+	params   QueryParams
+	pageSize int
+
+	page    []map[string]interface{}
+	pos     int
+	offset  int
+	fetched int
+	item    map[string]interface{}
+	done    bool
+	err     error
+}
+
+// QueryIter returns an iterator over every result of resource.query. Pages
+// are fetched on demand with a stable order_by (by id unless params sets
+// one). params.Limit caps the total number of items returned.
+func (c *Client) QueryIter(ctx context.Context, resource string, params *QueryParams) *QueryIterator {
+	it := &QueryIterator{
+		client:   c,
+		ctx:      ctx,
+		resource: resource,
+		pageSize: defaultPageSize,
+	}
+	if params != nil {
+		it.params = *params
+		if params.PageSize > 0 {
+			it.pageSize = params.PageSize
+		}
+	}
+	if it.params.Count || it.params.Get {
+		it.err = fmt.Errorf("count and get queries cannot be paginated")
+	}
+	if len(it.params.OrderBy) == 0 {
+		it.params.OrderBy = []string{"id"}
+	}
+	it.offset = it.params.Offset
+	return it
+}
+
+// Next advances to the next item, fetching another page when needed. It
+// returns false when the results are exhausted or an error occurs.
+func (it *QueryIterator) Next() bool {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+	it.item = it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// Item returns the current item
+func (it *QueryIterator) Item() map[string]interface{} {
+	return it.item
+}
+
+// Err returns the first error encountered while paging
+func (it *QueryIterator) Err() error {
+	return it.err
+}
+
+func (it *QueryIterator) fetch() {
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return
+	}
+
+	limit := it.pageSize
+	if it.params.Limit > 0 {
+		remaining := it.params.Limit - it.fetched
+		if remaining <= 0 {
+			it.done = true
+			return
+		}
+		if remaining < limit {
+			limit = remaining
+		}
+	}
+
+	pageParams := it.params
+	pageParams.Limit = limit
+	pageParams.Offset = it.offset
+
+	var page []map[string]interface{}
+	if err := it.client.Query(it.ctx, it.resource, &pageParams, &page); err != nil {
+		it.err = fmt.Errorf("failed to query %s at offset %d: %w", it.resource, it.offset, err)
+		return
+	}
+
+	it.page = page
+	it.pos = 0
+	it.offset += len(page)
+	it.fetched += len(page)
+	if len(page) < limit {
+		it.done = true
+	}
+}
+
+// QueryAll pages through every result of resource.query and calls fn for
+// each item. Returning an error from fn stops paging and returns that error.
+func (c *Client) QueryAll(ctx context.Context, resource string, params *QueryParams, fn func(item map[string]interface{}) error) error {
+	it := c.QueryIter(ctx, resource, params)
+	for it.Next() {
+		if err := fn(it.Item()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
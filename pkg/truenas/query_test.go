@@ -0,0 +1,120 @@
+package truenas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// servePagedQuery serves total items from any *.query method, honouring
+// limit and offset. Each request's options are sent to seen.
+func servePagedQuery(total int, seen chan<- map[string]interface{}) func(conn *websocket.Conn, n int) {
+	return func(conn *websocket.Conn, n int) {
+		serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+			args, _ := req.Params.([]interface{})
+			options := map[string]interface{}{}
+			if len(args) > 1 {
+				options, _ = args[1].(map[string]interface{})
+			}
+			if seen != nil {
+				seen <- options
+			}
+
+			offset, _ := options["offset"].(float64)
+			limit, _ := options["limit"].(float64)
+			page := []interface{}{}
+			for i := int(offset); i < total && (limit == 0 || i < int(offset+limit)); i++ {
+				page = append(page, map[string]interface{}{"id": fmt.Sprintf("tank@snap-%03d", i)})
+			}
+			return page, true
+		})
+	}
+}
+
+func TestQueryAll(t *testing.T) {
+	seen := make(chan map[string]interface{}, 16)
+	c := newTestServer(t, servePagedQuery(250, seen))
+
+	var ids []string
+	err := c.QueryAll(context.Background(), "pool.snapshot", NewQueryParams(), func(item map[string]interface{}) error {
+		ids = append(ids, item["id"].(string))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("QueryAll() error = %v", err)
+	}
+
+	if len(ids) != 250 {
+		t.Fatalf("QueryAll() returned %d items, want 250", len(ids))
+	}
+	if ids[0] != "tank@snap-000" || ids[249] != "tank@snap-249" {
+		t.Errorf("QueryAll() items out of order: first %v, last %v", ids[0], ids[249])
+	}
+
+	close(seen)
+	pages := 0
+	for options := range seen {
+		pages++
+		orderBy, _ := options["order_by"].([]interface{})
+		if len(orderBy) != 1 || orderBy[0] != "id" {
+			t.Errorf("page %d order_by = %v, want [id]", pages, options["order_by"])
+		}
+	}
+	if pages != 3 {
+		t.Errorf("QueryAll() fetched %d pages, want 3", pages)
+	}
+}
+
+func TestQueryIter(t *testing.T) {
+	t.Run("limit caps total items", func(t *testing.T) {
+		c := newTestServer(t, servePagedQuery(250, nil))
+
+		it := c.QueryIter(context.Background(), "pool.snapshot", NewQueryParams().WithLimit(30).WithPageSize(20))
+		count := 0
+		for it.Next() {
+			count++
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("Err() = %v", err)
+		}
+		if count != 30 {
+			t.Errorf("iterated %d items, want 30", count)
+		}
+	})
+
+	t.Run("stops on cancelled context", func(t *testing.T) {
+		c := newTestServer(t, servePagedQuery(250, nil))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		it := c.QueryIter(ctx, "pool.snapshot", NewQueryParams().WithPageSize(10))
+		count := 0
+		for it.Next() {
+			count++
+			if count == 10 {
+				cancel()
+			}
+		}
+		if !errors.Is(it.Err(), context.Canceled) {
+			t.Errorf("Err() = %v, want context.Canceled", it.Err())
+		}
+		if count != 10 {
+			t.Errorf("iterated %d items, want 10", count)
+		}
+	})
+
+	t.Run("callback error stops paging", func(t *testing.T) {
+		c := newTestServer(t, servePagedQuery(250, nil))
+
+		stop := errors.New("stop")
+		err := c.QueryAll(context.Background(), "pool.snapshot", nil, func(item map[string]interface{}) error {
+			return stop
+		})
+		if err != stop {
+			t.Errorf("QueryAll() error = %v, want %v", err, stop)
+		}
+	})
+}
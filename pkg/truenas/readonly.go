@@ -0,0 +1,64 @@
+package truenas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// readMethods are the methods outside the *.query, *.get_instance, *.config
+// and *.choices families that only read state
+var readMethods = map[string]bool{
+	"core.get_jobs":    true,
+	"core.get_methods": true,
+	"core.ping":        true,
+	"system.info":      true,
+	"system.version":   true,
+}
+
+// readMethodSuffixes end the names of methods that only read state
+var readMethodSuffixes = []string{".query", ".get_instance", ".config", ".choices"}
+
+// IsReadMethod reports whether method only reads state, and so may run in
+// read-only mode
+func IsReadMethod(method string) bool {
+	if readMethods[method] {
+		return true
+	}
+	for _, suffix := range readMethodSuffixes {
+		if strings.HasSuffix(method, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnlyError reports a call that read-only mode blocked
+type ReadOnlyError struct {
+	Method string
+	// Address is the resource that made the call, if known
+	Address string
+}
+
+func (e *ReadOnlyError) Error() string {
+	if e.Address == "" {
+		return fmt.Sprintf("read-only mode blocked %s, which may change TrueNAS", e.Method)
+	}
+	return fmt.Sprintf("read-only mode blocked %s from %s, which may change TrueNAS", e.Method, e.Address)
+}
+
+// IsReadOnlyError checks if an error was caused by read-only mode
+func IsReadOnlyError(err error) bool {
+	var roErr *ReadOnlyError
+	return errors.As(err, &roErr)
+}
+
+// readOnlyGuard is the interceptor of Config.ReadOnly. It rejects every
+// method that is not known to only read state.
+func readOnlyGuard(ctx context.Context, method string, params interface{}, next Invoker) error {
+	if !IsReadMethod(method) {
+		return &ReadOnlyError{Method: method, Address: ResourceAddress(ctx)}
+	}
+	return next(ctx, method, params)
+}
@@ -0,0 +1,63 @@
+package truenas
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestIsReadMethod(t *testing.T) {
+	tests := map[string]bool{
+		"pool.query":                true,
+		"pool.dataset.get_instance": true,
+		"core.get_jobs":             true,
+		"smb.config":                true,
+		"vm.device.choices":         true,
+		"system.version":            true,
+		"pool.dataset.create":       false,
+		"pool.export":               false,
+		"vm.stop":                   false,
+		"core.job_abort":            false,
+		"app.upgrade":               false,
+	}
+	for method, want := range tests {
+		if got := IsReadMethod(method); got != want {
+			t.Errorf("IsReadMethod(%q) = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	srv, c := newFakeClient(t, Config{ReadOnly: true})
+	ctx := WithResourceAddress(context.Background(), `trueform_dataset["tank/media"]`)
+
+	var datasets []map[string]interface{}
+	if err := c.Query(ctx, "pool.dataset", nil, &datasets); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	err := c.Delete(ctx, "pool.dataset", "tank/media")
+	if !IsReadOnlyError(err) {
+		t.Fatalf("Delete() error = %v, want a read-only error", err)
+	}
+	if !strings.Contains(err.Error(), `pool.dataset.delete from trueform_dataset["tank/media"]`) {
+		t.Errorf("error = %q, want the method and resource", err)
+	}
+	if got := srv.CallCount("pool.dataset.delete"); got != 0 {
+		t.Errorf("server saw %d deletes, want none", got)
+	}
+}
+
+func TestReadOnlyChecksRewrittenCalls(t *testing.T) {
+	rewrite := func(ctx context.Context, method string, params interface{}, next Invoker) error {
+		return next(ctx, "pool.dataset.delete", []interface{}{"tank/media"})
+	}
+	srv, c := newFakeClient(t, Config{ReadOnly: true, Interceptors: []Interceptor{rewrite}})
+
+	if err := c.Query(context.Background(), "pool.dataset", nil, nil); !IsReadOnlyError(err) {
+		t.Errorf("Query() error = %v, want a read-only error for the rewritten call", err)
+	}
+	if got := srv.CallCount("pool.dataset.delete"); got != 0 {
+		t.Errorf("server saw %d deletes, want none", got)
+	}
+}
@@ -0,0 +1,69 @@
+package truenas
+
+import "strings"
+
+// redactedValue replaces secret values in anything the client shows to users
+const redactedValue = "********"
+
+// secretFields are parameter names that only ever hold credentials, so
+// their values must never be logged or included in error messages
+var secretFields = map[string]bool{
+	"api_key":           true,
+	"password":          true,
+	"privatekey":        true,
+	"private_key":       true,
+	"passphrase":        true,
+	"display_password":  true,
+	"otp_token":         true,
+	"otp_secret":        true,
+	"secret":            true,
+	"peersecret":        true,
+	"secret_access_key": true,
+}
+
+// secretPaths are generic names that hold a credential only inside a
+// particular object, keyed as "parent.name". Elements of a list count as
+// being under the list's key, so "datasets.key" covers each entry of the
+// datasets passed to pool.dataset.unlock. Elsewhere, such as in certificate
+// or SSH metadata, the same names are left alone.
+var secretPaths = map[string]bool{
+	"encryption_options.key": true,
+	"datasets.key":           true,
+}
+
+// isSecretField reports whether parameter name, found in an object stored
+// under parent, holds a secret. parent is empty at the top level.
+func isSecretField(parent, name string) bool {
+	name = strings.ToLower(name)
+	return secretFields[name] || secretPaths[strings.ToLower(parent)+"."+name]
+}
+
+// redact returns a copy of v with the values of secret fields replaced. It
+// understands the generic maps and slices produced by encoding/json.
+func redact(v interface{}) interface{} {
+	return redactIn("", v)
+}
+
+// redactIn redacts v, which is stored under the key parent
+func redactIn(parent string, v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			if isSecretField(parent, k) && item != nil {
+				out[k] = redactedValue
+				continue
+			}
+			out[k] = redactIn(k, item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = redactIn(parent, item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,147 @@
+package truenas
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	in := map[string]interface{}{
+		"username": "admin",
+		"Password": "secret",
+		"nested": []interface{}{
+			map[string]interface{}{"privatekey": "-----BEGIN", "name": "cert"},
+		},
+		"api_key": nil,
+	}
+
+	out := redact(in).(map[string]interface{})
+
+	if out["username"] != "admin" {
+		t.Errorf("username = %v, want admin", out["username"])
+	}
+	if out["Password"] != redactedValue {
+		t.Errorf("Password = %v, want %v", out["Password"], redactedValue)
+	}
+	nested := out["nested"].([]interface{})[0].(map[string]interface{})
+	if nested["privatekey"] != redactedValue {
+		t.Errorf("nested privatekey = %v, want %v", nested["privatekey"], redactedValue)
+	}
+	if nested["name"] != "cert" {
+		t.Errorf("nested name = %v, want cert", nested["name"])
+	}
+	if out["api_key"] != nil {
+		t.Errorf("api_key = %v, want nil to stay nil", out["api_key"])
+	}
+
+	// The input must not be modified
+	if in["Password"] != "secret" {
+		t.Error("redact() modified its input")
+	}
+}
+
+func TestRedactGenericNames(t *testing.T) {
+	tests := []struct {
+		name string
+		in   map[string]interface{}
+		path []string
+		want interface{}
+	}{
+		{
+			name: "encryption key",
+			in:   map[string]interface{}{"encryption_options": map[string]interface{}{"algorithm": "AES-256-GCM", "key": "0f1e2d"}},
+			path: []string{"encryption_options", "key"},
+			want: redactedValue,
+		},
+		{
+			name: "encryption algorithm",
+			in:   map[string]interface{}{"encryption_options": map[string]interface{}{"algorithm": "AES-256-GCM", "key": "0f1e2d"}},
+			path: []string{"encryption_options", "algorithm"},
+			want: "AES-256-GCM",
+		},
+		{
+			name: "certificate key type",
+			in:   map[string]interface{}{"key": "RSA", "key_length": 2048},
+			path: []string{"key"},
+			want: "RSA",
+		},
+		{
+			name: "property named key",
+			in:   map[string]interface{}{"properties": map[string]interface{}{"key": "visible"}},
+			path: []string{"properties", "key"},
+			want: "visible",
+		},
+		{
+			name: "unlock passphrase key",
+			in: map[string]interface{}{"datasets": []interface{}{
+				map[string]interface{}{"name": "tank/secure", "key": "0f1e2d"},
+			}},
+			path: []string{"datasets", "0", "key"},
+			want: redactedValue,
+		},
+		{
+			name: "unlock dataset name",
+			in: map[string]interface{}{"datasets": []interface{}{
+				map[string]interface{}{"name": "tank/secure", "key": "0f1e2d"},
+			}},
+			path: []string{"datasets", "0", "name"},
+			want: "tank/secure",
+		},
+		{
+			name: "chap secret",
+			in:   map[string]interface{}{"tag": 1, "user": "initiator", "secret": "chapsecret12"},
+			path: []string{"secret"},
+			want: redactedValue,
+		},
+		{
+			name: "mutual chap peer secret",
+			in:   map[string]interface{}{"tag": 1, "peeruser": "target", "peersecret": "peersecret12"},
+			path: []string{"peersecret"},
+			want: redactedValue,
+		},
+		{
+			name: "cloud credential secret access key",
+			in: map[string]interface{}{"provider": map[string]interface{}{
+				"type": "S3", "access_key_id": "AKIA", "secret_access_key": "wJalr",
+			}},
+			path: []string{"provider", "secret_access_key"},
+			want: redactedValue,
+		},
+		{
+			name: "cloud credential access key id",
+			in: map[string]interface{}{"provider": map[string]interface{}{
+				"type": "S3", "access_key_id": "AKIA", "secret_access_key": "wJalr",
+			}},
+			path: []string{"provider", "access_key_id"},
+			want: "AKIA",
+		},
+		{
+			name: "ssh private key",
+			in:   map[string]interface{}{"attributes": map[string]interface{}{"private_key": "-----BEGIN", "public_key": "ssh-ed25519"}},
+			path: []string{"attributes", "private_key"},
+			want: redactedValue,
+		},
+		{
+			name: "ssh public key",
+			in:   map[string]interface{}{"attributes": map[string]interface{}{"private_key": "-----BEGIN", "public_key": "ssh-ed25519"}},
+			path: []string{"attributes", "public_key"},
+			want: "ssh-ed25519",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got interface{} = redact(tt.in)
+			for _, k := range tt.path {
+				if i, err := strconv.Atoi(k); err == nil {
+					got = got.([]interface{})[i]
+					continue
+				}
+				got = got.(map[string]interface{})[k]
+			}
+			if got != tt.want {
+				t.Errorf("redact() %v = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
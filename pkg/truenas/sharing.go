@@ -0,0 +1,92 @@
+package truenas
+
+// SMBShare is an SMB share as returned by sharing.smb.query and
+// sharing.smb.get_instance
+type SMBShare struct {
+	ID            int64    `json:"id"`
+	Path          string   `json:"path"`
+	PathSuffix    *string  `json:"path_suffix"`
+	Name          string   `json:"name"`
+	Comment       *string  `json:"comment"`
+	Enabled       *bool    `json:"enabled"`
+	Home          *bool    `json:"home"`
+	Purpose       *string  `json:"purpose"`
+	Timemachine   *bool    `json:"timemachine"`
+	Ro            *bool    `json:"ro"`
+	Browsable     *bool    `json:"browsable"`
+	Recyclebin    *bool    `json:"recyclebin"`
+	Guestok       *bool    `json:"guestok"`
+	Abe           *bool    `json:"abe"`
+	Hostsallow    []string `json:"hostsallow"`
+	Hostsdeny     []string `json:"hostsdeny"`
+	Auxsmbconf    *string  `json:"auxsmbconf"`
+	ACL           *bool    `json:"acl"`
+	Durablehandle *bool    `json:"durablehandle"`
+	Shadowcopy    *bool    `json:"shadowcopy"`
+	Streams       *bool    `json:"streams"`
+	Fsrvp         *bool    `json:"fsrvp"`
+	AuditLogging  *bool    `json:"audit_logging"`
+	Locked        *bool    `json:"locked"`
+}
+
+// SMBShareRequest is the data of sharing.smb.create and sharing.smb.update.
+// Nil fields are left out.
+type SMBShareRequest struct {
+	Path          *string   `json:"path,omitempty"`
+	PathSuffix    *string   `json:"path_suffix,omitempty"`
+	Name          *string   `json:"name,omitempty"`
+	Comment       *string   `json:"comment,omitempty"`
+	Enabled       *bool     `json:"enabled,omitempty"`
+	Home          *bool     `json:"home,omitempty"`
+	Purpose       *string   `json:"purpose,omitempty"`
+	Timemachine   *bool     `json:"timemachine,omitempty"`
+	Ro            *bool     `json:"ro,omitempty"`
+	Browsable     *bool     `json:"browsable,omitempty"`
+	Recyclebin    *bool     `json:"recyclebin,omitempty"`
+	Guestok       *bool     `json:"guestok,omitempty"`
+	Abe           *bool     `json:"abe,omitempty"`
+	Hostsallow    *[]string `json:"hostsallow,omitempty"`
+	Hostsdeny     *[]string `json:"hostsdeny,omitempty"`
+	Auxsmbconf    *string   `json:"auxsmbconf,omitempty"`
+	ACL           *bool     `json:"acl,omitempty"`
+	Durablehandle *bool     `json:"durablehandle,omitempty"`
+	Shadowcopy    *bool     `json:"shadowcopy,omitempty"`
+	Streams       *bool     `json:"streams,omitempty"`
+	Fsrvp         *bool     `json:"fsrvp,omitempty"`
+}
+
+// NFSShare is an NFS share as returned by sharing.nfs.query and
+// sharing.nfs.get_instance
+type NFSShare struct {
+	ID           int64    `json:"id"`
+	Path         string   `json:"path"`
+	Aliases      []string `json:"aliases"`
+	Comment      *string  `json:"comment"`
+	Enabled      *bool    `json:"enabled"`
+	Networks     []string `json:"networks"`
+	Hosts        []string `json:"hosts"`
+	MaprootUser  *string  `json:"maproot_user"`
+	MaprootGroup *string  `json:"maproot_group"`
+	MapallUser   *string  `json:"mapall_user"`
+	MapallGroup  *string  `json:"mapall_group"`
+	Security     []string `json:"security"`
+	Ro           *bool    `json:"ro"`
+	Locked       *bool    `json:"locked"`
+}
+
+// NFSShareRequest is the data of sharing.nfs.create and sharing.nfs.update.
+// Nil fields are left out.
+type NFSShareRequest struct {
+	Path         *string   `json:"path,omitempty"`
+	Aliases      *[]string `json:"aliases,omitempty"`
+	Comment      *string   `json:"comment,omitempty"`
+	Enabled      *bool     `json:"enabled,omitempty"`
+	Networks     *[]string `json:"networks,omitempty"`
+	Hosts        *[]string `json:"hosts,omitempty"`
+	MaprootUser  *string   `json:"maproot_user,omitempty"`
+	MaprootGroup *string   `json:"maproot_group,omitempty"`
+	MapallUser   *string   `json:"mapall_user,omitempty"`
+	MapallGroup  *string   `json:"mapall_group,omitempty"`
+	Security     *[]string `json:"security,omitempty"`
+	Ro           *bool     `json:"ro,omitempty"`
+}
@@ -0,0 +1,159 @@
+package truenas
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Pool is a storage pool as returned by pool.query and pool.get_instance
+type Pool struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	GUID          string     `json:"guid"`
+	Status        string     `json:"status"`
+	Healthy       bool       `json:"healthy"`
+	Path          string     `json:"path"`
+	Size          *FlexInt64 `json:"size"`
+	Allocated     *FlexInt64 `json:"allocated"`
+	Free          *FlexInt64 `json:"free"`
+	Fragmentation *FlexInt64 `json:"fragmentation"`
+}
+
+// PoolVDev is one vdev of a pool topology
+type PoolVDev struct {
+	Type  string   `json:"type"`
+	Disks []string `json:"disks"`
+}
+
+// PoolCreateRequest is the data of pool.create. Topology maps a vdev class
+// such as "data", "cache" or "log" to its vdevs.
+type PoolCreateRequest struct {
+	Name                  string                `json:"name"`
+	Topology              map[string][]PoolVDev `json:"topology"`
+	Encryption            bool                  `json:"encryption,omitempty"`
+	Deduplication         *string               `json:"deduplication,omitempty"`
+	AllowDuplicateSerials bool                  `json:"allow_duplicate_serials,omitempty"`
+}
+
+// PoolExportOptions are the options of pool.export
+type PoolExportOptions struct {
+	Cascade         bool `json:"cascade,omitempty"`
+	RestartServices bool `json:"restart_services,omitempty"`
+	Destroy         bool `json:"destroy,omitempty"`
+}
+
+// Dataset is a ZFS filesystem or volume as returned by pool.dataset.query
+// and pool.dataset.get_instance
+type Dataset struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Pool            string    `json:"pool"`
+	Type            string    `json:"type"`
+	Comments        *Property `json:"comments"`
+	Compression     *Property `json:"compression"`
+	Atime           *Property `json:"atime"`
+	Deduplication   *Property `json:"deduplication"`
+	Quota           *Property `json:"quota"`
+	Refquota        *Property `json:"refquota"`
+	Reservation     *Property `json:"reservation"`
+	Refreservation  *Property `json:"refreservation"`
+	Copies          *Property `json:"copies"`
+	Snapdir         *Property `json:"snapdir"`
+	Readonly        *Property `json:"readonly"`
+	Recordsize      *Property `json:"recordsize"`
+	Casesensitivity *Property `json:"casesensitivity"`
+	Aclmode         *Property `json:"aclmode"`
+	Acltype         *Property `json:"acltype"`
+	Managedby       *Property `json:"managedby"`
+	Used            *Property `json:"used"`
+	Available       *Property `json:"available"`
+	Mountpoint      *string   `json:"mountpoint"`
+	Encrypted       *bool     `json:"encrypted"`
+	EncryptionRoot  *string   `json:"encryption_root"`
+	KeyLoaded       *bool     `json:"key_loaded"`
+}
+
+// DatasetUpdateRequest is the data of pool.dataset.update. Nil fields are
+// left unchanged.
+type DatasetUpdateRequest struct {
+	Comments       *string `json:"comments,omitempty"`
+	Compression    *string `json:"compression,omitempty"`
+	Atime          *string `json:"atime,omitempty"`
+	Deduplication  *string `json:"deduplication,omitempty"`
+	Quota          *int64  `json:"quota,omitempty"`
+	QuotaWarning   *int64  `json:"quota_warning,omitempty"`
+	QuotaCritical  *int64  `json:"quota_critical,omitempty"`
+	Refquota       *int64  `json:"refquota,omitempty"`
+	Reservation    *int64  `json:"reservation,omitempty"`
+	Refreservation *int64  `json:"refreservation,omitempty"`
+	Copies         *int64  `json:"copies,omitempty"`
+	Snapdir        *string `json:"snapdir,omitempty"`
+	Readonly       *string `json:"readonly,omitempty"`
+	Recordsize     *string `json:"recordsize,omitempty"`
+	Aclmode        *string `json:"aclmode,omitempty"`
+	Acltype        *string `json:"acltype,omitempty"`
+}
+
+// DatasetCreateRequest is the data of pool.dataset.create. Name is the full
+// path of the dataset, including its pool.
+type DatasetCreateRequest struct {
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	Casesensitivity *string `json:"casesensitivity,omitempty"`
+	ShareType       *string `json:"share_type,omitempty"`
+	DatasetUpdateRequest
+}
+
+// Snapshot is a ZFS snapshot as returned by zfs.snapshot.query and
+// zfs.snapshot.get_instance
+type Snapshot struct {
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	Dataset      string               `json:"dataset"`
+	SnapshotName string               `json:"snapshot_name"`
+	Holds        SnapshotHolds        `json:"holds"`
+	Properties   map[string]*Property `json:"properties"`
+}
+
+// SnapshotHolds are the tags of the user holds on a snapshot
+type SnapshotHolds []string
+
+// UnmarshalJSON decodes a list of tags, or an object keyed by tag as some
+// releases return
+func (h *SnapshotHolds) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return json.Unmarshal(data, (*[]string)(h))
+	}
+	var holds map[string]interface{}
+	if err := json.Unmarshal(data, &holds); err != nil {
+		return err
+	}
+	tags := make(SnapshotHolds, 0, len(holds))
+	for tag := range holds {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	*h = tags
+	return nil
+}
+
+// SnapshotCreateRequest is the data of zfs.snapshot.create
+type SnapshotCreateRequest struct {
+	Dataset    string            `json:"dataset"`
+	Name       string            `json:"name"`
+	Recursive  bool              `json:"recursive"`
+	VMWareSync *bool             `json:"vmware_sync,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// SnapshotUpdateRequest is the data of zfs.snapshot.update
+type SnapshotUpdateRequest struct {
+	UserPropertiesUpdate map[string]string `json:"user_properties_update,omitempty"`
+}
+
+// SnapshotDeleteOptions are the options of zfs.snapshot.delete
+type SnapshotDeleteOptions struct {
+	Defer     bool `json:"defer,omitempty"`
+	Recursive bool `json:"recursive"`
+}
@@ -0,0 +1,115 @@
+package truenas
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// pemPrefix marks a value that holds PEM data rather than a file path
+const pemPrefix = "-----BEGIN"
+
+// buildTLSConfig turns the TLS settings of cfg into the dialer's tls.Config
+func buildTLSConfig(cfg *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: !cfg.VerifySSL,
+		ServerName:         cfg.TLSServerName,
+	}
+
+	if cfg.CACertFile != "" || cfg.CACertPEM != "" {
+		pool := x509.NewCertPool()
+		if cfg.CACertFile != "" {
+			data, err := os.ReadFile(cfg.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA certificate file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("CA certificate file %s contains no PEM certificates", cfg.CACertFile)
+			}
+		}
+		if cfg.CACertPEM != "" && !pool.AppendCertsFromPEM([]byte(cfg.CACertPEM)) {
+			return nil, fmt.Errorf("CA certificate PEM contains no certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, fmt.Errorf("client certificate and client key must be set together")
+		}
+		certPEM, err := pemOrFile(cfg.ClientCert, "client certificate")
+		if err != nil {
+			return nil, err
+		}
+		keyPEM, err := pemOrFile(cfg.ClientKey, "client key")
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate or key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.TLSFingerprint != "" {
+		pin, err := parseFingerprint(cfg.TLSFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		// The pin identifies the server on its own, which also covers
+		// self-signed certificates
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("server presented no certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if subtle.ConstantTimeCompare(sum[:], pin) != 1 {
+				return fmt.Errorf("server certificate SHA-256 fingerprint %s does not match the pinned fingerprint", formatFingerprint(sum[:]))
+			}
+			return nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// pemOrFile returns value itself when it holds PEM data, otherwise the
+// contents of the file it names
+func pemOrFile(value, what string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), pemPrefix) {
+		return []byte(value), nil
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	return data, nil
+}
+
+// parseFingerprint accepts a SHA-256 fingerprint as hex, with or without
+// colons, in either case
+func parseFingerprint(fingerprint string) ([]byte, error) {
+	clean := strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", "")
+	clean = strings.TrimPrefix(strings.ToLower(clean), "sha256/")
+	pin, err := hex.DecodeString(clean)
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("invalid SHA-256 fingerprint %q: expected 64 hex digits", fingerprint)
+	}
+	return pin, nil
+}
+
+// formatFingerprint formats a fingerprint the way openssl x509 -fingerprint does
+func formatFingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,209 @@
+package truenas
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startTLSTestServer starts a WebSocket server answering every request with
+// true, after configure adjusts its TLS settings
+func startTLSTestServer(t *testing.T, configure func(*tls.Config)) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serveRequests(conn, func(req *JSONRPCRequest) (interface{}, bool) {
+			return true, true
+		})
+	}))
+	srv.TLS = &tls.Config{}
+	if configure != nil {
+		configure(srv.TLS)
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// selfSignedPEM returns a self-signed certificate and key in PEM form
+func selfSignedPEM(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestTLSConfig(t *testing.T) {
+	srv := startTLSTestServer(t, nil)
+	host := strings.TrimPrefix(srv.URL, "https://")
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte(caPEM), 0o600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	sum := sha256.Sum256(srv.Certificate().Raw)
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			name:    "system roots reject test CA",
+			cfg:     Config{VerifySSL: true},
+			wantErr: "certificate",
+		},
+		{
+			name: "ca_cert_pem",
+			cfg:  Config{VerifySSL: true, CACertPEM: caPEM},
+		},
+		{
+			name: "ca_cert_file",
+			cfg:  Config{VerifySSL: true, CACertFile: caFile},
+		},
+		{
+			name: "tls_server_name from certificate",
+			cfg:  Config{VerifySSL: true, CACertPEM: caPEM, TLSServerName: "example.com"},
+		},
+		{
+			name:    "tls_server_name mismatch",
+			cfg:     Config{VerifySSL: true, CACertPEM: caPEM, TLSServerName: "nas.internal"},
+			wantErr: "nas.internal",
+		},
+		{
+			name: "pinned fingerprint",
+			cfg:  Config{VerifySSL: true, TLSFingerprint: formatFingerprint(sum[:])},
+		},
+		{
+			name: "pinned fingerprint without colons",
+			cfg:  Config{VerifySSL: true, TLSFingerprint: hex.EncodeToString(sum[:])},
+		},
+		{
+			name:    "wrong pinned fingerprint",
+			cfg:     Config{VerifySSL: true, TLSFingerprint: strings.Repeat("ab", sha256.Size)},
+			wantErr: "does not match the pinned fingerprint",
+		},
+		{
+			name:    "invalid fingerprint",
+			cfg:     Config{TLSFingerprint: "abc"},
+			wantErr: "expected 64 hex digits",
+		},
+		{
+			name:    "invalid CA PEM",
+			cfg:     Config{VerifySSL: true, CACertPEM: "not a certificate"},
+			wantErr: "contains no certificates",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Host = host
+			cfg.APIKey = "test-key"
+			c := NewClient(&cfg)
+			defer c.Close()
+
+			err := c.Connect(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Connect() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Connect() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	certPEM, keyPEM := selfSignedPEM(t)
+	clientCA := x509.NewCertPool()
+	clientCA.AppendCertsFromPEM(certPEM)
+
+	srv := startTLSTestServer(t, func(cfg *tls.Config) {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = clientCA
+	})
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cert    string
+		key     string
+		wantErr bool
+	}{
+		{name: "PEM values", cert: string(certPEM), key: string(keyPEM)},
+		{name: "file paths", cert: certFile, key: keyFile},
+		{name: "no client certificate", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(&Config{Host: host, APIKey: "test-key", ClientCert: tt.cert, ClientKey: tt.key})
+			defer c.Close()
+
+			err := c.Connect(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Connect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateConfig(&Config{ClientCert: certFile}); err == nil {
+		t.Error("ValidateConfig() with certificate but no key error = nil")
+	}
+}
@@ -0,0 +1,104 @@
+package truenas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// addressKey is the context key of the resource address shown in traces
+type addressKey struct{}
+
+// WithResourceAddress returns a copy of ctx carrying the address of the
+// Terraform resource it serves, such as trueform_dataset["tank/media"].
+// Trace lines of calls made with the context include the address.
+func WithResourceAddress(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, addressKey{}, address)
+}
+
+// ResourceAddress returns the resource address set by WithResourceAddress
+func ResourceAddress(ctx context.Context) string {
+	address, _ := ctx.Value(addressKey{}).(string)
+	return address
+}
+
+// traceEntry is one line of a trace file: a call and its response. Secret
+// parameters and result fields are redacted.
+type traceEntry struct {
+	Time      time.Time       `json:"time"`
+	Address   string          `json:"address,omitempty"`
+	Method    string          `json:"method"`
+	LatencyMS float64         `json:"latency_ms"`
+	Params    interface{}     `json:"params,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// tracer appends every call to a trace file. The file is opened for
+// appending so that the separate provider processes Terraform starts for
+// plan and apply add to the same trace.
+type tracer struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newTracer(path string) (*tracer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	return &tracer{file: file}, nil
+}
+
+// trace writes a call that started at start and its outcome
+func (t *tracer) trace(ctx context.Context, start time.Time, method string, params interface{}, result json.RawMessage, err error) {
+	entry := traceEntry{
+		Time:      start.UTC(),
+		Address:   ResourceAddress(ctx),
+		Method:    method,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if strings.HasPrefix(method, "auth.") {
+		// Login params are positional credentials that field names cannot
+		// identify
+		if params != nil {
+			entry.Params = redactedValue
+		}
+	} else {
+		entry.Params = redactJSON(params)
+	}
+	if result != nil {
+		entry.Result = redactRaw(result)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	line, merr := json.Marshal(&entry)
+	if merr != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = t.file.Write(append(line, '\n'))
+}
+
+func (t *tracer) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file.Close()
+}
+
+// openTrace opens the trace file on first connect
+func (c *Client) openTrace() error {
+	if c.traceFile == "" || c.tracer != nil {
+		return nil
+	}
+	var err error
+	c.tracer, err = newTracer(c.traceFile)
+	return err
+}
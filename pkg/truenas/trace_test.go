@@ -0,0 +1,84 @@
+package truenas
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTraceFile(t *testing.T) {
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+	srv, c := newFakeClient(t, Config{TraceFile: traceFile})
+	srv.Handle("user.create", func(params []interface{}) (interface{}, error) {
+		return map[string]interface{}{"id": 7, "username": "svc", "password": "hunter2"}, nil
+	})
+
+	ctx := WithResourceAddress(context.Background(), `trueform_user["svc"]`)
+	params := map[string]interface{}{"username": "svc", "password": "hunter2"}
+	if err := c.Create(ctx, "user", params, nil); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Fatalf("reading trace: %v", err)
+	}
+	for _, secret := range []string{"hunter2", srv.APIKey} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("trace contains secret %q:\n%s", secret, data)
+		}
+	}
+
+	var entries []traceEntry
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		var entry traceEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("trace line %q is not JSON: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 || entries[0].Method != "auth.login_with_api_key" {
+		t.Fatalf("trace = %v, want the login first", entries)
+	}
+	last := entries[len(entries)-1]
+	if last.Method != "user.create" {
+		t.Fatalf("last trace entry method = %s, want user.create", last.Method)
+	}
+	if last.Address != `trueform_user["svc"]` {
+		t.Errorf("address = %q, want the resource address from the context", last.Address)
+	}
+	if last.Time.IsZero() || last.LatencyMS < 0 {
+		t.Errorf("time = %v, latency = %v", last.Time, last.LatencyMS)
+	}
+	if !strings.Contains(string(last.Result), `"username":"svc"`) {
+		t.Errorf("result = %s, want the response", last.Result)
+	}
+}
+
+func TestTraceFileAppends(t *testing.T) {
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+	for i := 0; i < 2; i++ {
+		_, c := newFakeClient(t, Config{TraceFile: traceFile})
+		if err := c.Call(context.Background(), "core.ping", nil, nil); err != nil {
+			t.Fatalf("Call() error = %v", err)
+		}
+		_ = c.Close()
+	}
+
+	data, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Fatalf("reading trace: %v", err)
+	}
+	if got := strings.Count(string(data), `"method":"core.ping"`); got != 2 {
+		t.Errorf("trace has %d core.ping lines, want one per client", got)
+	}
+}
@@ -0,0 +1,112 @@
+package truenas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Ptr returns a pointer to v, for the optional fields of request structs
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Property is a ZFS property as the middleware reports it. Optional fields
+// are nil when the middleware leaves them out or sends null.
+type Property struct {
+	Value    *string     `json:"value"`
+	Rawvalue *string     `json:"rawvalue"`
+	Parsed   interface{} `json:"parsed"`
+	Source   *string     `json:"source"`
+}
+
+// UnmarshalJSON decodes a property object. A plain value, as some releases
+// return for user properties such as comments, fills in Value, Rawvalue and
+// Parsed.
+func (p *Property) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		type property Property
+		return json.Unmarshal(data, (*property)(p))
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v == nil {
+		*p = Property{}
+		return nil
+	}
+	value := fmt.Sprint(v)
+	if f, ok := v.(float64); ok {
+		value = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	*p = Property{Value: &value, Rawvalue: &value, Parsed: v}
+	return nil
+}
+
+// StringValue returns the property's value. It reports false when p is nil
+// or has no value.
+func (p *Property) StringValue() (string, bool) {
+	if p == nil || p.Value == nil {
+		return "", false
+	}
+	return *p.Value, true
+}
+
+// Int64Value returns the property's parsed value as an integer, as for
+// sizes and quotas. It reports false when p is nil or the parsed value is
+// not a number.
+func (p *Property) Int64Value() (int64, bool) {
+	if p == nil {
+		return 0, false
+	}
+	parsed, ok := p.Parsed.(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(parsed), true
+}
+
+// Reference is a related object that the middleware reports either as its
+// id or as the full object, such as a user's primary group
+type Reference struct {
+	ID int64 `json:"id"`
+}
+
+// UnmarshalJSON decodes either an id or an object with an id field
+func (r *Reference) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		type reference Reference
+		return json.Unmarshal(data, (*reference)(r))
+	}
+	return json.Unmarshal(data, &r.ID)
+}
+
+// FlexInt64 is an integer that the middleware reports either as a number
+// or as a decimal string, as it does for pool fragmentation and iSCSI extent
+// file sizes. Optional integers in responses use it so that one oddly typed
+// field does not fail a whole read.
+type FlexInt64 int64
+
+// UnmarshalJSON decodes a number or a decimal string
+func (n *FlexInt64) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*n = FlexInt64(v)
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q: %w", v, err)
+		}
+		*n = FlexInt64(i)
+	case nil:
+	default:
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package truenas
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPropertyUnmarshal(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantValue  string
+		wantOK     bool
+		wantInt    int64
+		wantIntOK  bool
+		wantSource string
+	}{
+		{in: `{"value": "lz4", "rawvalue": "lz4", "parsed": "lz4", "source": "LOCAL"}`, wantValue: "lz4", wantOK: true, wantSource: "LOCAL"},
+		{in: `{"value": "1G", "rawvalue": "1073741824", "parsed": 1073741824, "source": "LOCAL"}`, wantValue: "1G", wantOK: true, wantInt: 1073741824, wantIntOK: true, wantSource: "LOCAL"},
+		{in: `{"value": null, "parsed": null}`},
+		{in: `"a comment"`, wantValue: "a comment", wantOK: true},
+		{in: `1048576`, wantValue: "1048576", wantOK: true, wantInt: 1048576, wantIntOK: true},
+		{in: `null`},
+	}
+
+	for _, tt := range tests {
+		var p Property
+		if err := json.Unmarshal([]byte(tt.in), &p); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		if got, ok := p.StringValue(); got != tt.wantValue || ok != tt.wantOK {
+			t.Errorf("Unmarshal(%s).StringValue() = %q, %v, want %q, %v", tt.in, got, ok, tt.wantValue, tt.wantOK)
+		}
+		if got, ok := p.Int64Value(); got != tt.wantInt || ok != tt.wantIntOK {
+			t.Errorf("Unmarshal(%s).Int64Value() = %d, %v, want %d, %v", tt.in, got, ok, tt.wantInt, tt.wantIntOK)
+		}
+		if tt.wantSource != "" && (p.Source == nil || *p.Source != tt.wantSource) {
+			t.Errorf("Unmarshal(%s).Source = %v, want %q", tt.in, p.Source, tt.wantSource)
+		}
+	}
+}
+
+func TestPropertyNil(t *testing.T) {
+	var p *Property
+	if _, ok := p.StringValue(); ok {
+		t.Error("nil StringValue() ok = true, want false")
+	}
+	if _, ok := p.Int64Value(); ok {
+		t.Error("nil Int64Value() ok = true, want false")
+	}
+}
+
+func TestReferenceUnmarshal(t *testing.T) {
+	var user struct {
+		Group  *Reference  `json:"group"`
+		Groups []Reference `json:"groups"`
+	}
+	in := `{"group": {"id": 41, "bsdgrp_gid": 3000}, "groups": [42, {"id": 43}]}`
+	if err := json.Unmarshal([]byte(in), &user); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if user.Group == nil || user.Group.ID != 41 {
+		t.Errorf("Group = %v, want id 41", user.Group)
+	}
+	if want := []Reference{{ID: 42}, {ID: 43}}; !reflect.DeepEqual(user.Groups, want) {
+		t.Errorf("Groups = %v, want %v", user.Groups, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"group": null}`), &user); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if user.Group != nil {
+		t.Errorf("Group = %v, want nil", user.Group)
+	}
+}
+
+func TestFlexInt64Unmarshal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: `12`, want: 12},
+		{in: `"7"`, want: 7},
+		{in: `null`, want: 0},
+		{in: `"7%"`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var n FlexInt64
+		err := json.Unmarshal([]byte(tt.in), &n)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) error = nil, want error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		if int64(n) != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, n, tt.want)
+		}
+	}
+}
+
+func TestISCSIExtentUnmarshal(t *testing.T) {
+	var extent ISCSIExtent
+	in := `{"id": 1, "name": "vmstore", "type": "DISK", "filesize": "0", "blocksize": 512, "avail_threshold": null}`
+	if err := json.Unmarshal([]byte(in), &extent); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if extent.Filesize == nil || *extent.Filesize != 0 {
+		t.Errorf("Filesize = %v, want 0", extent.Filesize)
+	}
+	if extent.Blocksize == nil || *extent.Blocksize != 512 {
+		t.Errorf("Blocksize = %v, want 512", extent.Blocksize)
+	}
+	if extent.AvailThreshold != nil {
+		t.Errorf("AvailThreshold = %v, want nil", extent.AvailThreshold)
+	}
+}
+
+func TestSnapshotHoldsUnmarshal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want SnapshotHolds
+	}{
+		{in: `["backup", "replication"]`, want: SnapshotHolds{"backup", "replication"}},
+		{in: `{"replication": "Mon Jun 2 2025", "backup": "Mon Jun 2 2025"}`, want: SnapshotHolds{"backup", "replication"}},
+		{in: `{}`, want: SnapshotHolds{}},
+	}
+
+	for _, tt := range tests {
+		var holds SnapshotHolds
+		if err := json.Unmarshal([]byte(tt.in), &holds); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(holds, tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, holds, tt.want)
+		}
+	}
+}
+
+func TestRequestOmitsNilFields(t *testing.T) {
+	data, err := json.Marshal(DatasetUpdateRequest{Comments: Ptr(""), Readonly: Ptr("OFF")})
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+	if got, want := string(data), `{"comments":"","readonly":"OFF"}`; got != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,203 @@
+package truenas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// versionPattern finds the release in strings such as "25.04.2" or
+// "TrueNAS-SCALE-25.10.0-MASTER-20250601"
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// Version is a TrueNAS release, such as 25.04.2.1
+type Version struct {
+	// Raw is the string the server reported
+	Raw   string
+	Parts []int
+
+	// release is the dotted release as written in Raw, e.g. "25.04.2"
+	release string
+}
+
+// ParseVersion parses the release out of a TrueNAS version string
+func ParseVersion(s string) (Version, error) {
+	match := versionPattern.FindString(s)
+	if match == "" {
+		return Version{}, fmt.Errorf("invalid TrueNAS version %q", s)
+	}
+	fields := strings.Split(match, ".")
+	parts := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid TrueNAS version %q: %w", s, err)
+		}
+		parts[i] = n
+	}
+	return Version{Raw: s, Parts: parts, release: match}, nil
+}
+
+// IsZero reports whether the version is unknown
+func (v Version) IsZero() bool {
+	return len(v.Parts) == 0
+}
+
+// String returns the release in dotted form
+func (v Version) String() string {
+	if v.release != "" {
+		return v.release
+	}
+	fields := make([]string, len(v.Parts))
+	for i, n := range v.Parts {
+		fields[i] = strconv.Itoa(n)
+	}
+	return strings.Join(fields, ".")
+}
+
+// Compare returns -1, 0 or 1 as v is older than, equal to or newer than
+// other. Missing trailing parts count as zero, so 25.04 equals 25.04.0.
+func (v Version) Compare(other Version) int {
+	for i := 0; i < len(v.Parts) || i < len(other.Parts); i++ {
+		var a, b int
+		if i < len(v.Parts) {
+			a = v.Parts[i]
+		}
+		if i < len(other.Parts) {
+			b = other.Parts[i]
+		}
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether v is minimum or newer
+func (v Version) AtLeast(minimum Version) bool {
+	return v.Compare(minimum) >= 0
+}
+
+// VersionError reports a server older than a feature requires
+type VersionError struct {
+	Required Version
+	Actual   Version
+}
+
+func (e *VersionError) Error() string {
+	if e.Actual.IsZero() {
+		return fmt.Sprintf("requires TrueNAS %s or later, but the server version is unknown", e.Required)
+	}
+	return fmt.Sprintf("requires TrueNAS %s or later, but the server runs %s", e.Required, e.Actual)
+}
+
+// negotiate asks the server for its version after connecting. Failures are
+// logged rather than returned, so an unexpected answer never prevents
+// connecting; the version then stays unknown. The method list is only
+// fetched when needed, as core.get_methods returns every method's schema.
+func (c *Client) negotiate(ctx context.Context) {
+	var raw string
+	if err := c.call(ctx, "system.version", nil, &raw); err != nil {
+		tflog.Warn(ctx, "Could not determine TrueNAS version", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	version, err := ParseVersion(raw)
+	if err != nil {
+		tflog.Warn(ctx, "Could not determine TrueNAS version", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	c.versionMu.Lock()
+	changed := version.Raw != c.version.Raw
+	c.version = version
+	c.versionMu.Unlock()
+
+	if changed {
+		// The server was upgraded or another endpoint answered
+		c.methodsMu.Lock()
+		c.methods = nil
+		c.methodsMu.Unlock()
+	}
+
+	tflog.Info(ctx, "Connected to TrueNAS", map[string]interface{}{
+		"version": version.Raw,
+	})
+}
+
+// Version returns the TrueNAS version reported on connect. It is zero
+// before the first connect or when the server did not report one.
+func (c *Client) Version() Version {
+	c.versionMu.RLock()
+	defer c.versionMu.RUnlock()
+	return c.version
+}
+
+// HasMethod reports whether the server offers method. The method list is
+// fetched on first use and cached. It returns true when the list cannot be
+// fetched, so callers only skip what is known missing.
+func (c *Client) HasMethod(ctx context.Context, method string) bool {
+	methods := c.methodList(ctx)
+	return methods == nil || methods[method]
+}
+
+// methodList returns the names of the server's methods, fetching them with
+// core.get_methods unless they are cached. It returns nil on failure.
+func (c *Client) methodList(ctx context.Context) map[string]bool {
+	c.methodsMu.Lock()
+	methods := c.methods
+	c.methodsMu.Unlock()
+	if methods != nil {
+		return methods
+	}
+
+	// The lock is not held during the call, which may reconnect and so
+	// reset the cache in negotiate. Only the method names are kept; the
+	// values hold each method's schema.
+	var raw map[string]json.RawMessage
+	if err := c.Call(ctx, "core.get_methods", nil, &raw); err != nil {
+		tflog.Warn(ctx, "Could not list TrueNAS API methods", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+	methods = make(map[string]bool, len(raw))
+	for name := range raw {
+		methods[name] = true
+	}
+
+	c.methodsMu.Lock()
+	c.methods = methods
+	c.methodsMu.Unlock()
+	return methods
+}
+
+// RequireVersion returns a *VersionError when the server is older than
+// minimum, or when its version is unknown and so cannot be checked
+func (c *Client) RequireVersion(minimum string) error {
+	required, err := ParseVersion(minimum)
+	if err != nil {
+		return err
+	}
+	actual := c.Version()
+	if !actual.IsZero() && actual.AtLeast(required) {
+		return nil
+	}
+	return &VersionError{Required: required, Actual: actual}
+}
+
+// methodNotFound explains a MethodNotFound error for method with the
+// server version, as it usually means the NAS is older or newer than the
+// provider expects
+func (c *Client) methodNotFound(method string, err error) error {
+	version := c.Version()
+	if version.IsZero() {
+		return err
+	}
+	return fmt.Errorf("method %s is not available on TrueNAS %s: %w", method, version, err)
+}
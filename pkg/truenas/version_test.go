@@ -0,0 +1,191 @@
+package truenas
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "25.04.2", want: "25.04.2"},
+		{in: "TrueNAS-SCALE-25.04.2.1", want: "25.04.2.1"},
+		{in: "25.10.0-MASTER-20250601-120000", want: "25.10.0"},
+		{in: "v25.04", want: "25.04"},
+		{in: "MASTER", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseVersion(%q) error = nil, want error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseVersion(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want || got.Raw != tt.in {
+			t.Errorf("ParseVersion(%q) = %v (raw %q), want %v", tt.in, got, got.Raw, tt.want)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "25.04", b: "25.04.0", want: 0},
+		{a: "25.04.2", b: "25.04.10", want: -1},
+		{a: "25.10", b: "25.04.2.1", want: 1},
+		{a: "24.10.2", b: "25.04", want: -1},
+	}
+
+	for _, tt := range tests {
+		a, _ := ParseVersion(tt.a)
+		b, _ := ParseVersion(tt.b)
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// serveVersion answers system.version with version, core.get_methods with
+// methods, and every listed method with true. Calls to core.get_methods
+// are counted in listed.
+func serveVersion(listed *int32, version string, methods ...string) func(conn *websocket.Conn, n int) {
+	return func(conn *websocket.Conn, n int) {
+		for {
+			var req JSONRPCRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+			switch req.Method {
+			case "auth.login_with_api_key":
+				resp["result"] = true
+			case "system.version":
+				resp["result"] = version
+			case "core.get_methods":
+				atomic.AddInt32(listed, 1)
+				list := map[string]interface{}{}
+				for _, m := range methods {
+					list[m] = map[string]interface{}{"description": m}
+				}
+				resp["result"] = list
+			default:
+				resp["result"] = true
+				found := false
+				for _, m := range methods {
+					found = found || m == req.Method
+				}
+				if !found {
+					delete(resp, "result")
+					resp["error"] = map[string]interface{}{"code": ErrCodeMethodNotFound, "message": "Method does not exist"}
+				}
+			}
+			_ = conn.WriteJSON(resp)
+		}
+	}
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	var listed int32
+	c := newTestServer(t, serveVersion(&listed, "TrueNAS-SCALE-24.10.2", "pool.query"))
+
+	if !c.Version().IsZero() {
+		t.Errorf("Version() before connect = %v, want zero", c.Version())
+	}
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	if got := c.Version().String(); got != "24.10.2" {
+		t.Errorf("Version() = %v, want 24.10.2", got)
+	}
+	if n := atomic.LoadInt32(&listed); n != 0 {
+		t.Errorf("core.get_methods called %d times on connect, want 0", n)
+	}
+	if !c.HasMethod(context.Background(), "pool.query") || c.HasMethod(context.Background(), "app.query") {
+		t.Error("HasMethod() does not match core.get_methods")
+	}
+	if n := atomic.LoadInt32(&listed); n != 1 {
+		t.Errorf("core.get_methods called %d times, want 1", n)
+	}
+
+	if err := c.RequireVersion("24.10"); err != nil {
+		t.Errorf("RequireVersion(24.10) error = %v", err)
+	}
+	err := c.RequireVersion("25.04")
+	if err == nil || !strings.Contains(err.Error(), "requires TrueNAS 25.04 or later, but the server runs 24.10.2") {
+		t.Errorf("RequireVersion(25.04) error = %v", err)
+	}
+
+	err = c.Call(context.Background(), "app.query", nil, nil)
+	if !IsMethodNotFoundError(err) || !strings.Contains(err.Error(), "app.query is not available on TrueNAS 24.10.2") {
+		t.Errorf("Call() error = %v, want method not available", err)
+	}
+}
+
+func TestNegotiateVersionUnknown(t *testing.T) {
+	// A server that answers everything with true reports no usable version
+	c := newTestServer(t, func(conn *websocket.Conn, n int) {
+		serveLogin(conn, nil, nil)
+	})
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	if !c.Version().IsZero() {
+		t.Errorf("Version() = %v, want zero", c.Version())
+	}
+	if !c.HasMethod(context.Background(), "app.query") {
+		t.Error("HasMethod() = false with an unknown method list")
+	}
+	err := c.RequireVersion("24.10")
+	var versionErr *VersionError
+	if !errors.As(err, &versionErr) || !versionErr.Actual.IsZero() {
+		t.Fatalf("RequireVersion() with unknown version error = %v, want *VersionError", err)
+	}
+	if !strings.Contains(err.Error(), "server version is unknown") {
+		t.Errorf("RequireVersion() error = %v, want unknown version", err)
+	}
+}
+
+func TestAPIVersionPath(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		want    string
+		wantErr string
+	}{
+		{cfg: Config{Host: "nas", APIVersion: "25.04.2"}, want: "wss://nas/api/v25.04.2"},
+		{cfg: Config{Host: "nas", APIVersion: "v25.04.2"}, want: "wss://nas/api/v25.04.2"},
+		{cfg: Config{Host: "nas", APIVersion: "current"}, wantErr: "invalid API version"},
+		{cfg: Config{Host: "nas", APIVersion: "25.04", APIPath: "/api/current"}, wantErr: "not both"},
+	}
+
+	for _, tt := range tests {
+		got, err := buildEndpoint(&tt.cfg)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("buildEndpoint(%+v) error = %v, want %q", tt.cfg, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("buildEndpoint(%+v) = %v, %v, want %v", tt.cfg, got, err, tt.want)
+		}
+	}
+}
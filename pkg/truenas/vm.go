@@ -0,0 +1,94 @@
+package truenas
+
+// VM is a virtual machine as returned by vm.query and vm.get_instance
+type VM struct {
+	ID                  int64      `json:"id"`
+	Name                string     `json:"name"`
+	Description         *string    `json:"description"`
+	VCPUs               *FlexInt64 `json:"vcpus"`
+	Cores               *FlexInt64 `json:"cores"`
+	Threads             *FlexInt64 `json:"threads"`
+	Memory              *FlexInt64 `json:"memory"`
+	MinMemory           *FlexInt64 `json:"min_memory"`
+	Bootloader          *string    `json:"bootloader"`
+	BootloaderOVMF      *string    `json:"bootloader_ovmf"`
+	Autostart           *bool      `json:"autostart"`
+	HideFromMSR         *bool      `json:"hide_from_msr"`
+	EnsureDisplayDevice *bool      `json:"ensure_display_device"`
+	Time                *string    `json:"time"`
+	ShutdownTimeout     *FlexInt64 `json:"shutdown_timeout"`
+	ArchType            *string    `json:"arch_type"`
+	MachineType         *string    `json:"machine_type"`
+	UUID                *string    `json:"uuid"`
+	CPUMode             *string    `json:"cpu_mode"`
+	CPUModel            *string    `json:"cpu_model"`
+	Status              *VMStatus  `json:"status"`
+}
+
+// VMStatus is the run state of a VM, such as RUNNING or STOPPED
+type VMStatus struct {
+	State string `json:"state"`
+}
+
+// VMRequest is the data of vm.create and vm.update. Nil fields are left
+// out.
+type VMRequest struct {
+	Name                *string `json:"name,omitempty"`
+	Description         *string `json:"description,omitempty"`
+	VCPUs               *int64  `json:"vcpus,omitempty"`
+	Cores               *int64  `json:"cores,omitempty"`
+	Threads             *int64  `json:"threads,omitempty"`
+	Memory              *int64  `json:"memory,omitempty"`
+	MinMemory           *int64  `json:"min_memory,omitempty"`
+	Bootloader          *string `json:"bootloader,omitempty"`
+	BootloaderOVMF      *string `json:"bootloader_ovmf,omitempty"`
+	Autostart           *bool   `json:"autostart,omitempty"`
+	HideFromMSR         *bool   `json:"hide_from_msr,omitempty"`
+	EnsureDisplayDevice *bool   `json:"ensure_display_device,omitempty"`
+	Time                *string `json:"time,omitempty"`
+	ShutdownTimeout     *int64  `json:"shutdown_timeout,omitempty"`
+	ArchType            *string `json:"arch_type,omitempty"`
+	MachineType         *string `json:"machine_type,omitempty"`
+	CPUMode             *string `json:"cpu_mode,omitempty"`
+	CPUModel            *string `json:"cpu_model,omitempty"`
+}
+
+// VMDevice is a device attached to a VM as returned by vm.device.query and
+// vm.device.get_instance
+type VMDevice struct {
+	ID         int64               `json:"id"`
+	VM         int64               `json:"vm"`
+	DType      string              `json:"dtype"`
+	Order      *FlexInt64          `json:"order"`
+	Attributes *VMDeviceAttributes `json:"attributes"`
+}
+
+// VMDeviceAttributes are the attributes of a VM device. Which fields apply
+// depends on the device type: DISK, NIC, CDROM, DISPLAY, PCI, USB or RAW.
+// Type is the disk bus, the NIC model or the display protocol.
+type VMDeviceAttributes struct {
+	Path                *string `json:"path,omitempty"`
+	Type                *string `json:"type,omitempty"`
+	PhysicalSectorsize  *int64  `json:"physical_sectorsize,omitempty"`
+	LogicalSectorsize   *int64  `json:"logical_sectorsize,omitempty"`
+	Mac                 *string `json:"mac,omitempty"`
+	NICAttach           *string `json:"nic_attach,omitempty"`
+	TrustGuestRXFilters *bool   `json:"trust_guest_rx_filters,omitempty"`
+	Port                *int64  `json:"port,omitempty"`
+	Bind                *string `json:"bind,omitempty"`
+	Password            *string `json:"password,omitempty"`
+	Web                 *bool   `json:"web,omitempty"`
+	Resolution          *string `json:"resolution,omitempty"`
+	Pptdev              *string `json:"pptdev,omitempty"`
+	Device              *string `json:"device,omitempty"`
+	Size                *int64  `json:"size,omitempty"`
+}
+
+// VMDeviceRequest is the data of vm.device.create and vm.device.update. Nil
+// fields are left out.
+type VMDeviceRequest struct {
+	VM         *int64              `json:"vm,omitempty"`
+	DType      *string             `json:"dtype,omitempty"`
+	Order      *int64              `json:"order,omitempty"`
+	Attributes *VMDeviceAttributes `json:"attributes,omitempty"`
+}